@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Milover/fetchref/internal/fetch"
+	"github.com/spf13/cobra"
+)
+
+var bibCmd = &cobra.Command{
+	Use:   "bib",
+	Short: "Maintain BibTeX bibliographies.",
+	Long:  "Maintain BibTeX bibliographies.",
+}
+
+var bibRefreshCmd = &cobra.Command{
+	Use:   "refresh <file>",
+	Short: "Refresh the entries of a BibTeX file with Crossref metadata.",
+	Long: "Refresh the entries of a BibTeX file, which have a DOI, an ISBN or " +
+		"an arXiv identifier, with Crossref (or arXiv) metadata.\n" +
+		"Missing fields are filled in, and divergent fields are optionally " +
+		"overwritten, while citation keys, comments and the order of entries " +
+		"are retained. The changes are written as a unified diff, or " +
+		"the patched file is written to a file, e.g.:\n\n" +
+		"\tfetchref bib refresh refs.bib | patch refs.bib\n" +
+		"\tfetchref bib refresh --merge overwrite --fields title,journal -o refs.new.bib refs.bib",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Args:          cobra.ExactArgs(1),
+	RunE:          bibRefresh,
+}
+
+var bibLintCmd = &cobra.Command{
+	Use:   "lint <file...>",
+	Short: "Check BibTeX files for issues.",
+	Long: "Check BibTeX files for duplicate keys and DOIs, missing required " +
+		"fields, unprotected capitalisation in titles, invalid DOIs and ISBNs, " +
+		"malformed page ranges, inconsistent journal names and retracted works.\n" +
+		"The issues are written as text, JSON Lines or SARIF, and the command " +
+		"fails if any issue is at the fail level, or above.",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Args:          cobra.MinimumNArgs(1),
+	RunE:          bibLint,
+}
+
+func bibRefresh(cmd *cobra.Command, args []string) error {
+	return fetch.Refresh(os.Stdout, args[0])
+}
+
+func bibLint(cmd *cobra.Command, args []string) error {
+	return fetch.Lint(os.Stdout, args)
+}
+
+func init() {
+	bibCmd.AddCommand(bibRefreshCmd, bibLintCmd)
+
+	bibRefreshCmd.Flags().Var(
+		&fetch.RefreshPolicy,
+		"merge",
+		"metadata merge policy: fill (missing fields only) or overwrite (also divergent fields)",
+	)
+	bibRefreshCmd.Flags().StringSliceVar(
+		&fetch.RefreshFields,
+		"fields",
+		nil,
+		"fields which are overwritten, if the merge policy is overwrite (default all)",
+	)
+	bibRefreshCmd.Flags().StringVarP(
+		&fetch.RefreshOutput,
+		"output",
+		"o",
+		"",
+		"write the patched file, instead of a diff",
+	)
+
+	bibLintCmd.Flags().Var(
+		&fetch.LintFmt,
+		"format",
+		"output format: text, json or sarif",
+	)
+	bibLintCmd.Flags().Var(
+		&fetch.LintFailLevel,
+		"fail-level",
+		"lowest level of issues for which the command fails: note, warning or error",
+	)
+	bibLintCmd.Flags().BoolVar(
+		&fetch.LintOffline,
+		"offline",
+		false,
+		"do not check whether cited works have been retracted",
+	)
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Milover/fetchref/internal/fetch"
+	"github.com/spf13/cobra"
+)
+
+var findCmd = &cobra.Command{
+	Use:   "find <dir> [query...]",
+	Short: "Search the documents in a directory by their text and metadata.",
+	Long: "Search the documents (PDFs) in a directory tree by their text and metadata.\n" +
+		"The documents are indexed first, or reindexed if they were modified.\n" +
+		"Besides full text terms, the query may contain the fields 'author:', " +
+		"'title:', 'journal:', 'doi:' and 'year:', e.g.:\n\n" +
+		"\tfetchref find ~/papers gravitational author:abbott year:2010..2016 'journal:\"physical review\"'",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Args:          cobra.MinimumNArgs(1),
+	RunE:          find,
+}
+
+func find(cmd *cobra.Command, args []string) error {
+	return fetch.Find(os.Stdout, args[0], args[1:])
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Milover/fetchref/internal/fetch"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import a BibTeX, RIS or CSL-JSON bibliography.",
+	Long: "Import a BibTeX, RIS or CSL-JSON bibliography.\n" +
+		"Entries with a DOI or an ISBN are enriched, or repaired, with Crossref " +
+		"metadata, and the changes are reported as field-level diffs. " +
+		"The cleaned bibliography is written to standard output, " +
+		"or to a file, in any of the supported formats.",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Args:          cobra.ExactArgs(1),
+	RunE:          importBib,
+}
+
+func importBib(cmd *cobra.Command, args []string) error {
+	return fetch.Import(os.Stdout, os.Stderr, args[0])
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Milover/fetchref/internal/fetch"
+	"github.com/spf13/cobra"
+)
+
+var latexCmd = &cobra.Command{
+	Use:   "latex <main.aux|main.tex>",
+	Short: "Resolve the citations of a LaTeX document.",
+	Long: "Resolve the citations of a LaTeX document.\n" +
+		"Citations (\\cite, \\autocite... or \\citation) are collected from " +
+		"the document, and the files it inputs, or its auxiliary file, and " +
+		"checked against its bibliography files. Missing keys which are DOIs, " +
+		"ISBNs or arXiv identifiers, or are noted as such in comments, " +
+		"e.g., '% smith2020: 10.1000/xyz', are fetched and appended to " +
+		"the bibliography, with the key as is.",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Args:          cobra.ExactArgs(1),
+	RunE:          latex,
+}
+
+func latex(cmd *cobra.Command, args []string) error {
+	return fetch.Latex(os.Stdout, args[0])
+}
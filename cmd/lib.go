@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Milover/fetchref/internal/fetch"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// libListTag is the tag by which listed records are filtered.
+	libListTag string
+	// libListJSON controls whether records are listed as JSON Lines.
+	libListJSON bool
+	// libRmFiles controls whether the files of removed records are deleted.
+	libRmFiles bool
+	// libTagRemove controls whether tags are removed instead of added.
+	libTagRemove bool
+)
+
+var libCmd = &cobra.Command{
+	Use:   "lib",
+	Short: "Manage the library of fetched articles and citations.",
+	Long: "Manage the library of fetched articles and citations.\n" +
+		"Records are referred to by their ID, DOI, handle or file path.",
+}
+
+var libListCmd = &cobra.Command{
+	Use:           "list",
+	Short:         "List library records.",
+	Long:          "List library records.",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Args:          cobra.NoArgs,
+	RunE:          libList,
+}
+
+var libShowCmd = &cobra.Command{
+	Use:           "show <record...>",
+	Short:         "Show library records.",
+	Long:          "Show library records.",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Args:          cobra.MinimumNArgs(1),
+	RunE:          libShow,
+}
+
+var libRmCmd = &cobra.Command{
+	Use:           "rm <record...>",
+	Short:         "Remove library records.",
+	Long:          "Remove library records, and optionally their files.",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Args:          cobra.MinimumNArgs(1),
+	RunE:          libRm,
+}
+
+var libMvCmd = &cobra.Command{
+	Use:   "mv <record> <destination>",
+	Short: "Move the files of a library record.",
+	Long: "Move the files of a library record into a directory, " +
+		"or rename the record's only file.",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Args:          cobra.ExactArgs(2),
+	RunE:          libMv,
+}
+
+var libTagCmd = &cobra.Command{
+	Use:           "tag <record> <tag...>",
+	Short:         "Tag a library record.",
+	Long:          "Add tags to, or remove tags from, a library record.",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Args:          cobra.MinimumNArgs(2),
+	RunE:          libTag,
+}
+
+func libList(cmd *cobra.Command, args []string) error {
+	return fetch.LibList(os.Stdout, libListTag, libListJSON)
+}
+
+func libShow(cmd *cobra.Command, args []string) error {
+	return fetch.LibShow(os.Stdout, args)
+}
+
+func libRm(cmd *cobra.Command, args []string) error {
+	return fetch.LibRemove(args, libRmFiles)
+}
+
+func libMv(cmd *cobra.Command, args []string) error {
+	return fetch.LibMove(args[0], args[1])
+}
+
+func libTag(cmd *cobra.Command, args []string) error {
+	return fetch.LibTag(args[0], args[1:], libTagRemove)
+}
+
+func init() {
+	libCmd.AddCommand(libListCmd, libShowCmd, libRmCmd, libMvCmd, libTagCmd)
+
+	libListCmd.Flags().StringVar(
+		&libListTag,
+		"tag",
+		"",
+		"list only records with the tag",
+	)
+	libListCmd.Flags().BoolVar(
+		&libListJSON,
+		"json",
+		false,
+		"list records as JSON Lines",
+	)
+	libRmCmd.Flags().BoolVar(
+		&libRmFiles,
+		"files",
+		false,
+		"also delete the records' files",
+	)
+	libTagCmd.Flags().BoolVar(
+		&libTagRemove,
+		"remove",
+		false,
+		"remove the tags instead of adding them",
+	)
+}
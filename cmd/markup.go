@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Milover/fetchref/internal/fetch"
+	"github.com/spf13/cobra"
+)
+
+var markupCmd = &cobra.Command{
+	Use:   "markup <file.md|file.typ|file.org>",
+	Short: "Resolve the citations of a Markdown, Typst or Org document.",
+	Long: "Resolve the citations of a Markdown (Pandoc), Typst or Org document.\n" +
+		"Cited keys which are DOIs, ISBNs or arXiv identifiers, e.g., " +
+		"'[@doi:10.1000/xyz]', '#cite(label(\"10.1000/xyz\"))' or " +
+		"'[cite:@arXiv:2101.01234]', are fetched, and written as a CSL-JSON, " +
+		"Hayagriva, BibTeX or RIS bibliography. The document is optionally " +
+		"rewritten to cite generated keys, e.g., 'Smith_2020', instead.",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Args:          cobra.ExactArgs(1),
+	RunE:          markupDoc,
+}
+
+func markupDoc(cmd *cobra.Command, args []string) error {
+	return fetch.Markup(os.Stdout, args[0])
+}
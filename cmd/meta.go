@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Milover/fetchref/internal/fetch"
+	"github.com/spf13/cobra"
+)
+
+var metaCmd = &cobra.Command{
+	Use:           "meta <DOI...>",
+	Short:         "Fetch metadata from Crossref for supplied DOI(s)/ISBN(s)/ISSN(s).",
+	Long:          "Fetch metadata from Crossref for supplied DOI(s)/ISBN(s)/ISSN(s).",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Args:          cobra.MinimumNArgs(1),
+	RunE:          meta,
+}
+
+func meta(cmd *cobra.Command, args []string) error {
+	return fetch.Meta(os.Stdout, args)
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -19,19 +20,33 @@ var rootCmd = &cobra.Command{
 	SilenceErrors: true,
 	Args:          cobra.MinimumNArgs(1),
 	RunE:          run,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return fetch.Setup()
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// The HTTP client state, e.g., cookies, is persisted whether or not the run
+// succeeds, since cobra skips post-run hooks of failed runs.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	if err = errors.Join(err, fetch.Teardown()); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		if errors.Is(err, fetch.ErrRetracted) {
+			os.Exit(exitRetracted)
+		}
 		os.Exit(1)
 	}
 }
 
+// exitRetracted is the exit status of runs which fetched retracted works,
+// if --fail-on-retraction is set.
+const exitRetracted = 3
+
 func init() {
-	rootCmd.AddCommand(sourceCmd, citeCmd)
+	rootCmd.AddCommand(sourceCmd, citeCmd, metaCmd, searchCmd, libCmd, findCmd,
+		importCmd, bibCmd, latexCmd, markupCmd)
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
@@ -39,7 +54,7 @@ func init() {
 		&fetch.GlobalReqTimeout,
 		"timeout",
 		fetch.GlobalReqTimeout,
-		"HTTP request timeout",
+		"HTTP request timeout, for downloads only until the response headers arrive",
 	)
 	rootCmd.PersistentFlags().BoolVar(
 		&fetch.NoUserAgent,
@@ -47,6 +62,113 @@ func init() {
 		false,
 		"omit User-Agent header from HTTP requests",
 	)
+	rootCmd.PersistentFlags().StringVar(
+		&fetch.Mailto,
+		"mailto",
+		"",
+		"e-mail address supplied to APIs which require one, e.g., Unpaywall",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&fetch.TDMToken,
+		"tdm-token",
+		"",
+		"Crossref TDM click-through token supplied to publishers",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&fetch.Proxy.Prefix,
+		"proxy-prefix",
+		"",
+		"institutional proxy URL prefix, e.g., 'https://ezproxy.example.edu/login?url='",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&fetch.Proxy.Host,
+		"proxy-host",
+		"",
+		"institutional proxy host name for host name rewriting, e.g., 'ezproxy.example.edu'",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&fetch.ConfigFile,
+		"config",
+		fetch.ConfigFile,
+		"configuration file",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&fetch.CookieFile,
+		"cookies",
+		"",
+		"Netscape cookies file from which cookies are loaded and to which they are saved",
+	)
+	rootCmd.MarkFlagsMutuallyExclusive("proxy-prefix", "proxy-host")
+	rootCmd.PersistentFlags().StringVar(
+		&fetch.LibraryFile,
+		"library",
+		fetch.LibraryFile,
+		"library manifest file, in which fetched articles and citations are recorded",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&fetch.NoLibrary,
+		"no-library",
+		false,
+		"do not record fetched articles and citations in the library",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&fetch.OutputDir,
+		"output-dir",
+		"",
+		"directory into which articles and citations are written",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&fetch.PDFDir,
+		"pdf-dir",
+		"",
+		"directory into which articles are written, overrides --output-dir",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&fetch.CiteDir,
+		"cite-dir",
+		"",
+		"directory into which citations are written, overrides --output-dir",
+	)
+	rootCmd.PersistentFlags().Var(
+		&fetch.Layout,
+		"layout",
+		"directory layout: flat, by-year, by-journal, by-first-author or by-type",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&fetch.NameTemplate.Template,
+		"name-template",
+		fetch.NameTemplate.Template,
+		"article and citation file name template, e.g., '{year}_{first_author}_{short_title}'",
+	)
+	rootCmd.PersistentFlags().Var(
+		&fetch.NameTemplate.Case,
+		"name-case",
+		"case of file name words: lower, upper, title or keep",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&fetch.NameTemplate.Sep,
+		"name-sep",
+		fetch.NameTemplate.Sep,
+		"file name word separator",
+	)
+	rootCmd.PersistentFlags().IntVar(
+		&fetch.NameTemplate.MaxLen,
+		"name-max",
+		fetch.NameTemplate.MaxLen,
+		"maximum length of file (and directory) names in bytes, 0 for unlimited",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&fetch.NameTemplate.Unicode,
+		"name-unicode",
+		false,
+		"keep non-ASCII letters in file names instead of transliterating them",
+	)
+
+	rootCmd.PersistentFlags().Var(
+		&fetch.OAVersion,
+		"oa-version",
+		"preferred version of open access copies: published, accepted or submitted",
+	)
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -99,6 +221,266 @@ func init() {
 		false,
 		"write each citation to a different file",
 	)
+	rootCmd.Flags().Var(
+		&fetch.OnConflict,
+		"on-conflict",
+		"policy for existing output files: overwrite, skip, suffix or hash",
+	)
+	sourceCmd.Flags().Var(
+		&fetch.OnConflict,
+		"on-conflict",
+		"policy for existing output files: overwrite, skip, suffix or hash",
+	)
+	citeCmd.Flags().Var(
+		&fetch.OnConflict,
+		"on-conflict",
+		"policy for existing output files: overwrite, skip, suffix or hash",
+	)
+	rootCmd.Flags().BoolVar(
+		&fetch.FailOnRetraction,
+		"fail-on-retraction",
+		false,
+		"exit with status 3 if any fetched work has been retracted",
+	)
+	sourceCmd.Flags().BoolVar(
+		&fetch.FailOnRetraction,
+		"fail-on-retraction",
+		false,
+		"exit with status 3 if any fetched work has been retracted",
+	)
+	citeCmd.Flags().BoolVar(
+		&fetch.FailOnRetraction,
+		"fail-on-retraction",
+		false,
+		"exit with status 3 if any fetched work has been retracted",
+	)
+	rootCmd.Flags().IntVar(
+		&fetch.References,
+		"references",
+		0,
+		"also fetch the works referenced by fetched works, to this depth",
+	)
+	rootCmd.Flags().Float64Var(
+		&fetch.ReferenceMinScore,
+		"ref-min-score",
+		fetch.ReferenceMinScore,
+		"minimum Crossref score of works matching references without a DOI",
+	)
+	sourceCmd.Flags().IntVar(
+		&fetch.References,
+		"references",
+		0,
+		"also fetch the works referenced by fetched works, to this depth",
+	)
+	sourceCmd.Flags().Float64Var(
+		&fetch.ReferenceMinScore,
+		"ref-min-score",
+		fetch.ReferenceMinScore,
+		"minimum Crossref score of works matching references without a DOI",
+	)
+	citeCmd.Flags().IntVar(
+		&fetch.References,
+		"references",
+		0,
+		"also fetch the works referenced by fetched works, to this depth",
+	)
+	citeCmd.Flags().Float64Var(
+		&fetch.ReferenceMinScore,
+		"ref-min-score",
+		fetch.ReferenceMinScore,
+		"minimum Crossref score of works matching references without a DOI",
+	)
+	metaCmd.Flags().IntVar(
+		&fetch.References,
+		"references",
+		0,
+		"also fetch the works referenced by fetched works, to this depth",
+	)
+	metaCmd.Flags().Float64Var(
+		&fetch.ReferenceMinScore,
+		"ref-min-score",
+		fetch.ReferenceMinScore,
+		"minimum Crossref score of works matching references without a DOI",
+	)
 	rootCmd.MarkFlagsMutuallyExclusive("cite-file", "cite-separate")
 	citeCmd.MarkFlagsMutuallyExclusive("cite-file", "cite-separate")
+
+	rootCmd.Flags().Int64Var(
+		&fetch.MinDownloadSize,
+		"min-size",
+		fetch.MinDownloadSize,
+		"minimum size of a downloaded file in bytes, smaller files are rejected",
+	)
+	rootCmd.Flags().BoolVar(
+		&fetch.VerifyPDF,
+		"verify-pdf",
+		false,
+		"validate the structure of downloaded PDFs before keeping them",
+	)
+	rootCmd.Flags().DurationVar(
+		&fetch.TransferTimeout,
+		"transfer-timeout",
+		fetch.TransferTimeout,
+		"download transfer timeout, after the response headers arrive, 0 for none",
+	)
+	rootCmd.Flags().DurationVar(
+		&fetch.StallTimeout,
+		"stall-timeout",
+		fetch.StallTimeout,
+		"abort downloads which receive no data for this long, 0 for never",
+	)
+	rootCmd.Flags().BoolVar(
+		&fetch.EmbedMetadata,
+		"embed-meta",
+		false,
+		"embed the article metadata (Info dictionary and XMP) into downloaded PDFs",
+	)
+	rootCmd.Flags().BoolVar(
+		&fetch.WriteSidecar,
+		"sidecar",
+		false,
+		"write a '<name>.fetchref.json' metadata sidecar file next to each downloaded article",
+	)
+	rootCmd.Flags().BoolVar(
+		&fetch.NoProgress,
+		"no-progress",
+		false,
+		"do not show download progress bars",
+	)
+	sourceCmd.Flags().Int64Var(
+		&fetch.MinDownloadSize,
+		"min-size",
+		fetch.MinDownloadSize,
+		"minimum size of a downloaded file in bytes, smaller files are rejected",
+	)
+	sourceCmd.Flags().BoolVar(
+		&fetch.VerifyPDF,
+		"verify-pdf",
+		false,
+		"validate the structure of downloaded PDFs before keeping them",
+	)
+	sourceCmd.Flags().DurationVar(
+		&fetch.TransferTimeout,
+		"transfer-timeout",
+		fetch.TransferTimeout,
+		"download transfer timeout, after the response headers arrive, 0 for none",
+	)
+	sourceCmd.Flags().DurationVar(
+		&fetch.StallTimeout,
+		"stall-timeout",
+		fetch.StallTimeout,
+		"abort downloads which receive no data for this long, 0 for never",
+	)
+	sourceCmd.Flags().BoolVar(
+		&fetch.EmbedMetadata,
+		"embed-meta",
+		false,
+		"embed the article metadata (Info dictionary and XMP) into downloaded PDFs",
+	)
+	sourceCmd.Flags().BoolVar(
+		&fetch.WriteSidecar,
+		"sidecar",
+		false,
+		"write a '<name>.fetchref.json' metadata sidecar file next to each downloaded article",
+	)
+	sourceCmd.Flags().BoolVar(
+		&fetch.NoProgress,
+		"no-progress",
+		false,
+		"do not show download progress bars",
+	)
+
+	searchCmd.Flags().StringVar(
+		&fetch.SearchISSN,
+		"issn",
+		"",
+		"list works published in the journal with the ISSN",
+	)
+	searchCmd.Flags().IntVar(
+		&fetch.SearchRows,
+		"rows",
+		fetch.SearchRows,
+		"maximum number of search results",
+	)
+
+	findCmd.Flags().Var(
+		&fetch.FindFmt,
+		"format",
+		"output format: table, json or bibtex",
+	)
+	findCmd.Flags().IntVar(
+		&fetch.FindLimit,
+		"limit",
+		fetch.FindLimit,
+		"maximum number of search results, 0 for unlimited",
+	)
+	findCmd.Flags().BoolVar(
+		&fetch.FindReindex,
+		"reindex",
+		false,
+		"reindex all documents, not only new and modified ones",
+	)
+	findCmd.Flags().BoolVar(
+		&fetch.FindNoMeta,
+		"no-meta",
+		false,
+		"do not request Crossref metadata of indexed documents",
+	)
+
+	importCmd.Flags().Var(
+		&fetch.ImportFmt,
+		"format",
+		"output format: bibtex, ris, csl-json or hayagriva",
+	)
+	importCmd.Flags().Var(
+		&fetch.ImportPolicy,
+		"merge",
+		"metadata merge policy: fill (missing fields only) or overwrite (also divergent fields)",
+	)
+	importCmd.Flags().StringVarP(
+		&fetch.ImportOutput,
+		"output",
+		"o",
+		"",
+		"write the bibliography to a file, instead of standard output",
+	)
+	importCmd.Flags().BoolVar(
+		&fetch.ImportNoMeta,
+		"no-meta",
+		false,
+		"do not request Crossref metadata, only clean and convert entries",
+	)
+
+	latexCmd.Flags().StringSliceVar(
+		&fetch.LatexBibs,
+		"bib",
+		nil,
+		"BibTeX files against which citations are checked, in addition to those of the document",
+	)
+	latexCmd.Flags().StringVarP(
+		&fetch.LatexOutput,
+		"output",
+		"o",
+		"",
+		"BibTeX file to which fetched entries are appended (default the first bibliography file)",
+	)
+
+	markupCmd.Flags().Var(
+		&fetch.MarkupFmt,
+		"format",
+		"bibliography format: csl-json, hayagriva, bibtex or ris",
+	)
+	markupCmd.Flags().StringVarP(
+		&fetch.MarkupOutput,
+		"output",
+		"o",
+		"",
+		"write the bibliography to a file, instead of standard output",
+	)
+	markupCmd.Flags().BoolVar(
+		&fetch.MarkupRewrite,
+		"rewrite",
+		false,
+		"rewrite the document to cite generated keys instead of identifiers",
+	)
 }
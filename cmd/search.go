@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Milover/fetchref/internal/fetch"
+	"github.com/spf13/cobra"
+)
+
+var searchCmd = &cobra.Command{
+	Use:           "search [query...]",
+	Short:         "Search Crossref for works matching a query and/or published in a journal.",
+	Long:          "Search Crossref for works matching a query and/or published in a journal.",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE:          search,
+}
+
+func search(cmd *cobra.Command, args []string) error {
+	return fetch.Search(os.Stdout, args)
+}
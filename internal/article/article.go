@@ -1,9 +1,13 @@
 package article
 
 import (
+	"net/http"
 	"net/url"
 	"strings"
 	"unicode"
+
+	"github.com/Milover/fetchref/internal/arxiv"
+	"github.com/Milover/fetchref/internal/crossref"
 )
 
 type fileNameFunc func(*Article) string
@@ -14,8 +18,19 @@ type Article struct {
 	Handle   Handle // article identifier DOI, ISBN, ISSN...
 	DOI      string
 	Title    string
-	Url      *url.URL // PDF download link
+	Url      *url.URL    // PDF download link
+	Header   http.Header // additional download request headers
+	License  string      // license of the downloaded article (URL)
+	File     string      // name of the downloaded article file
+	Source   string      // name of the source the article was downloaded from
+	CiteFile string      // name of the file the citation was written to
 	Citation []byte
+	Meta     *crossref.Work // Crossref metadata, if available
+	ArXiv    *arxiv.Entry   // arXiv metadata, if available
+
+	// Updates are the notices which update the work, e.g., retractions
+	// or corrections, by the notice DOI
+	Updates []crossref.WorkUpdate
 
 	// generator generates a (file) name for the article
 	generator fileNameFunc
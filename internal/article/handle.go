@@ -5,8 +5,27 @@ type HandleType int
 const (
 	DOI HandleType = iota
 	ISBN
+	ISSN
+	ArXiv
+	PMID
+	PMCID
 )
 
+// handleTypeNames are the user-friendly HandleType names.
+var handleTypeNames = [...]string{
+	"doi",
+	"isbn",
+	"issn",
+	"arxiv",
+	"pmid",
+	"pmcid",
+}
+
+// String returns the HandleType (name) as a user-friendly string.
+func (t HandleType) String() string {
+	return handleTypeNames[t]
+}
+
 type Handle struct {
 	Value string
 	Type  HandleType
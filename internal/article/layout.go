@@ -0,0 +1,77 @@
+package article
+
+import "fmt"
+
+var (
+	ErrBadLayout = fmt.Errorf(
+		"unknown layout, available layouts are: %q",
+		layoutNames)
+)
+
+var (
+	// layoutNames are the user-friendly Layout names.
+	layoutNames = [...]string{
+		"flat",
+		"by-year",
+		"by-journal",
+		"by-first-author",
+		"by-type",
+	}
+	// layoutDirs are the directory templates of each Layout.
+	layoutDirs = [...]string{
+		"",
+		"{year}",
+		"{container}",
+		"{first_author}",
+		"{type}",
+	}
+)
+
+// Layout is a directory layout preset, i.e., the directory into which
+// an article is filed, based on its metadata.
+type Layout int
+
+// Available layouts.
+const (
+	Flat Layout = iota
+	ByYear
+	ByJournal
+	ByFirstAuthor
+	ByType
+)
+
+// Set sets the value of the layout based on the provided layout name.
+func (l *Layout) Set(name string) error {
+	for i, n := range layoutNames {
+		if name == n {
+			*l = Layout(i)
+			return nil
+		}
+	}
+	return ErrBadLayout
+}
+
+// String returns the Layout (name) as a user-friendly string.
+func (l Layout) String() string {
+	return layoutNames[l]
+}
+
+// Type returns the type used by Layout.Set.
+func (l Layout) Type() string {
+	return "string"
+}
+
+// Dir returns the directory template of the layout, e.g., '{year}',
+// or an empty string if the layout is Flat.
+func (l Layout) Dir() string {
+	return layoutDirs[l]
+}
+
+// WithLayout returns a copy of the template, in which file names are
+// prefixed by the directory template of the layout.
+func (t NameTemplate) WithLayout(l Layout) NameTemplate {
+	if len(l.Dir()) != 0 {
+		t.Template = l.Dir() + "/" + t.Template
+	}
+	return t
+}
@@ -0,0 +1,422 @@
+package article
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Milover/fetchref/internal/crossref"
+)
+
+var (
+	ErrBadCase = fmt.Errorf(
+		"unknown case, available cases are: %q",
+		caseNames)
+)
+
+var (
+	// caseNames are the user-friendly Case names.
+	caseNames = [...]string{
+		"lower",
+		"upper",
+		"title",
+		"keep",
+	}
+
+	// placeholderPattern matches a template placeholder, e.g., '{year}'.
+	placeholderPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+
+	// reservedNames are file names reserved by Windows, irrespective
+	// of the extension.
+	reservedNames = map[string]bool{
+		"CON": true, "PRN": true, "AUX": true, "NUL": true,
+		"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+		"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+		"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+		"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+	}
+
+	// stopWords are words omitted from abbreviations and short titles.
+	stopWords = map[string]bool{
+		"a": true, "an": true, "and": true, "at": true, "for": true,
+		"in": true, "of": true, "on": true, "the": true, "to": true,
+	}
+)
+
+// emptyMark marks empty placeholder values in rendered templates.
+const emptyMark = '\x00'
+
+// maxShortTitleWords is the maximum number of words of a short title.
+const maxShortTitleWords = 6
+
+// maxAuthors is the maximum number of authors listed by the '{authors}'
+// placeholder, before the list is cut short by 'etal'.
+const maxAuthors = 3
+
+// Case is the letter case of the words of a placeholder value.
+type Case int
+
+// Available cases.
+const (
+	Lower Case = iota
+	Upper
+	Title
+	Keep
+)
+
+// Set sets the value of the case based on the provided case name.
+func (c *Case) Set(name string) error {
+	for i, n := range caseNames {
+		if name == n {
+			*c = Case(i)
+			return nil
+		}
+	}
+	return ErrBadCase
+}
+
+// String returns the Case (name) as a user-friendly string.
+func (c Case) String() string {
+	return caseNames[c]
+}
+
+// Type returns the type used by Case.Set.
+func (c Case) Type() string {
+	return "string"
+}
+
+// apply returns the word in the case.
+func (c Case) apply(w string) string {
+	switch c {
+	case Lower:
+		return strings.ToLower(w)
+	case Upper:
+		return strings.ToUpper(w)
+	case Title:
+		r, n := utf8.DecodeRuneInString(w)
+		return string(unicode.ToUpper(r)) + strings.ToLower(w[n:])
+	}
+	return w
+}
+
+// placeholders are the available template placeholders and functions
+// returning their (raw) values. Values of verbatim placeholders are only
+// made safe for use in file names, while the others are split into words,
+// which are joined by the separator, and transformed to the case.
+var placeholders = map[string]struct {
+	value    func(*Article) string
+	verbatim bool
+}{
+	"title":            {value: func(a *Article) string { return a.Title }},
+	"short_title":      {value: shortTitle},
+	"year":             {value: year},
+	"first_author":     {value: firstAuthor},
+	"authors":          {value: authors},
+	"container":        {value: container},
+	"container_abbrev": {value: containerAbbrev},
+	"publisher":        {value: publisher},
+	"volume":           {value: func(a *Article) string { return meta(a).Volume }},
+	"issue":            {value: func(a *Article) string { return meta(a).Issue }},
+	"type":             {value: func(a *Article) string { return meta(a).Type }},
+	"doi_safe":         {value: func(a *Article) string { return a.DOI }, verbatim: true},
+	"handle":           {value: func(a *Article) string { return a.Handle.Value }, verbatim: true},
+}
+
+// NameTemplate generates (relative) file names of articles from a template,
+// e.g., '{year}_{first_author}_{short_title}', in which placeholders are
+// replaced by article metadata. Slashes in the template separate
+// directories.
+//
+// Placeholder values are transliterated to ASCII, unless Unicode is set,
+// split into words, which are transformed to the Case and joined by
+// the separator Sep. Each file name component is truncated at a word
+// boundary to at most MaxLen bytes, if MaxLen is positive, and characters
+// and names reserved by common file systems are replaced.
+type NameTemplate struct {
+	Template string
+	Case     Case
+	Sep      string
+	MaxLen   int
+	Unicode  bool
+}
+
+// DefaultNameTemplate generates lower snake-case file names from
+// article titles.
+var DefaultNameTemplate = NameTemplate{
+	Template: "{title}",
+	Case:     Lower,
+	Sep:      "_",
+	MaxLen:   200,
+}
+
+// Validate reports whether the template is valid, i.e., whether its braces
+// are balanced and all placeholders are known.
+func (t NameTemplate) Validate() error {
+	if len(strings.TrimSpace(t.Template)) == 0 {
+		return fmt.Errorf("empty name template")
+	}
+	rest := placeholderPattern.ReplaceAllStringFunc(t.Template, func(s string) string {
+		return ""
+	})
+	if strings.ContainsAny(rest, "{}") {
+		return fmt.Errorf("unbalanced braces in name template: %q", t.Template)
+	}
+	for _, m := range placeholderPattern.FindAllStringSubmatch(t.Template, -1) {
+		if _, found := placeholders[m[1]]; !found {
+			return fmt.Errorf("unknown placeholder in name template: %q", m[0])
+		}
+	}
+	return nil
+}
+
+// Generate generates the file name of the article from the template.
+// Empty directory names are omitted, while an empty file name is replaced
+// by the article handle. No extension is added to the file name.
+func (t NameTemplate) Generate(a *Article) string {
+	comps := strings.Split(t.Template, "/")
+	parts := make([]string, 0, len(comps))
+	for i, c := range comps {
+		c = placeholderPattern.ReplaceAllStringFunc(c, func(s string) string {
+			p, found := placeholders[s[1:len(s)-1]]
+			if !found {
+				return ""
+			}
+			var v string
+			if p.verbatim {
+				v = t.safe(p.value(a))
+			} else {
+				v = t.words(p.value(a))
+			}
+			if len(v) == 0 {
+				return string(emptyMark)
+			}
+			return v
+		})
+		c = t.component(c)
+		if len(c) == 0 && i == len(comps)-1 {
+			c = t.component(t.safe(a.Handle.Value))
+		}
+		if len(c) != 0 {
+			parts = append(parts, c)
+		}
+	}
+	return filepath.Join(parts...)
+}
+
+// words splits s into words, i.e., runs of letters and digits, which are
+// transformed to the case and joined by the separator.
+func (t NameTemplate) words(s string) string {
+	if !t.Unicode {
+		s = Transliterate(s)
+	}
+	ws := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.Is(unicode.Mn, r)
+	})
+	for i := range ws {
+		ws[i] = t.Case.apply(ws[i])
+	}
+	return strings.Join(ws, t.Sep)
+}
+
+// safe replaces all characters of s, which are not letters, digits, '.', '-'
+// or '_', by '_', and transliterates s to ASCII, unless Unicode is set.
+func (t NameTemplate) safe(s string) string {
+	if !t.Unicode {
+		s = Transliterate(s)
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune(".-_", r) {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// component makes a rendered file name component safe to use, i.e., it
+// removes empty placeholders, along with their separators, replaces reserved
+// characters, truncates it to MaxLen bytes and renames reserved names.
+func (t NameTemplate) component(c string) string {
+	for i := strings.IndexRune(c, emptyMark); i != -1; i = strings.IndexRune(c, emptyMark) {
+		before := strings.TrimRightFunc(c[:i], t.isSep)
+		after := c[i+1:]
+		if len(before) == 0 {
+			after = strings.TrimLeftFunc(after, t.isSep)
+		}
+		c = before + after
+	}
+	c = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`<>:"\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, c)
+	c = strings.TrimFunc(c, t.isSep)
+	if t.MaxLen > 0 && len(c) > t.MaxLen {
+		c = strings.TrimRightFunc(t.truncate(c), t.isSep)
+	}
+	if c == "." || c == ".." {
+		return ""
+	}
+	base := strings.ToUpper(c)
+	if i := strings.IndexByte(base, '.'); i != -1 {
+		base = base[:i]
+	}
+	if reservedNames[base] {
+		c += "_"
+	}
+	return c
+}
+
+// isSep reports whether r separates words in a file name.
+func (t NameTemplate) isSep(r rune) bool {
+	return strings.ContainsRune(" ._-", r) || strings.ContainsRune(t.Sep, r)
+}
+
+// truncate truncates c to at most MaxLen bytes, at the last word boundary,
+// if there is one in the second half of the truncated string.
+func (t NameTemplate) truncate(c string) string {
+	n := t.MaxLen
+	for n > 0 && !utf8.RuneStart(c[n]) {
+		n--
+	}
+	c = c[:n]
+	if i := strings.LastIndexFunc(c, t.isSep); i > n/2 {
+		c = c[:i]
+	}
+	return c
+}
+
+// Transliterate transliterates s to ASCII. Letters which cannot be
+// transliterated are dropped, as are combining marks.
+func Transliterate(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		case unicode.Is(unicode.Mn, r):
+		case unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r):
+			b.WriteRune(' ')
+		default:
+			b.WriteString(transliterations[r])
+		}
+	}
+	return b.String()
+}
+
+// meta returns the article metadata, or an empty work, if it is not set.
+func meta(a *Article) *crossref.Work {
+	if a.Meta == nil {
+		return &crossref.Work{}
+	}
+	return a.Meta
+}
+
+// first returns the first non-empty string, or an empty string.
+func first(ss []string) string {
+	for _, s := range ss {
+		if len(s) != 0 {
+			return s
+		}
+	}
+	return ""
+}
+
+// shortTitle returns the short title of the article, or its title up to
+// the subtitle, cut to maxShortTitleWords significant words.
+func shortTitle(a *Article) string {
+	if s := first(meta(a).ShortTitle); len(s) != 0 {
+		return s
+	}
+	title := a.Title
+	if i := strings.IndexAny(title, ":?.!"); i > 0 {
+		title = title[:i]
+	}
+	ws := strings.Fields(title)
+	for len(ws) > 1 && stopWords[strings.ToLower(ws[0])] {
+		ws = ws[1:]
+	}
+	if len(ws) > maxShortTitleWords {
+		ws = ws[:maxShortTitleWords]
+	}
+	return strings.Join(ws, " ")
+}
+
+// year returns the year of publication of the article.
+func year(a *Article) string {
+	if y := meta(a).Year(); y > 0 {
+		return strconv.Itoa(y)
+	}
+	return ""
+}
+
+// authorName returns the family name of the author, or the name,
+// if the author is an organization.
+func authorName(au crossref.Author) string {
+	if len(au.Family) != 0 {
+		return au.Family
+	}
+	return au.Name
+}
+
+// firstAuthor returns the family name of the first author of the article.
+func firstAuthor(a *Article) string {
+	if m := meta(a); len(m.Author) != 0 {
+		return authorName(m.Author[0])
+	}
+	return ""
+}
+
+// authors returns the family names of the (first maxAuthors) authors
+// of the article.
+func authors(a *Article) string {
+	m := meta(a)
+	var names []string
+	for i, au := range m.Author {
+		if i == maxAuthors {
+			names = append(names, "etal")
+			break
+		}
+		names = append(names, authorName(au))
+	}
+	return strings.Join(names, " ")
+}
+
+// container returns the title of the journal (or book, proceedings...)
+// in which the article was published.
+func container(a *Article) string {
+	return first(meta(a).ContainerTitle)
+}
+
+// containerAbbrev returns the abbreviated container title, either as
+// registered, or from the initials of its significant words.
+func containerAbbrev(a *Article) string {
+	if s := first(meta(a).ShortContainerTitle); len(s) != 0 {
+		return s
+	}
+	ws := strings.Fields(container(a))
+	if len(ws) < 2 {
+		return container(a)
+	}
+	var b strings.Builder
+	for _, w := range ws {
+		if stopWords[strings.ToLower(w)] {
+			continue
+		}
+		r, _ := utf8.DecodeRuneInString(w)
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
+
+// publisher returns the publisher of the article.
+func publisher(a *Article) string {
+	return meta(a).Publisher
+}
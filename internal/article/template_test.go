@@ -0,0 +1,159 @@
+package article
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/stretchr/testify/assert"
+)
+
+var testWork = crossref.Work{
+	DOI:            "10.1103/PhysRevLett.116.061102",
+	Title:          []string{"Observation of Gravitational Waves from a Binary Black Hole Merger"},
+	ContainerTitle: []string{"Physical Review Letters"},
+	Publisher:      "American Physical Society (APS)",
+	Author: []crossref.Author{
+		{Given: "B. P.", Family: "Abbott"},
+		{Given: "R.", Family: "Abbott"},
+		{Given: "T. D.", Family: "Abbott"},
+		{Given: "M. R.", Family: "Abernathy"},
+	},
+	Issued: crossref.DateParts{DateParts: [][]int{{2016, 2, 11}}},
+}
+
+type templateTest struct {
+	Name     string
+	Template NameTemplate
+	Article  Article
+	Output   string
+}
+
+var templateTests = []templateTest{
+	{
+		Name:     "default",
+		Template: DefaultNameTemplate,
+		Article:  Article{Title: "Schrödinger's Cat: a Paradox?"},
+		Output:   "schrodinger_s_cat_a_paradox",
+	},
+	{
+		Name: "fields",
+		Template: NameTemplate{
+			Template: "{year}_{first_author}_{short_title}",
+			Case:     Lower,
+			Sep:      "-",
+		},
+		Output: "2016_abbott_observation-of-gravitational-waves-from-a",
+	},
+	{
+		Name: "directories",
+		Template: NameTemplate{
+			Template: "{container_abbrev}/{year}/{doi_safe}",
+			Case:     Upper,
+			Sep:      "_",
+		},
+		Output: filepath.Join("PRL", "2016", "10.1103_PhysRevLett.116.061102"),
+	},
+	{
+		Name: "authors-title-case",
+		Template: NameTemplate{
+			Template: "{authors} - {title}",
+			Case:     Title,
+		},
+		Output: "AbbottAbbottAbbottEtal - ObservationOfGravitationalWavesFromABinaryBlackHoleMerger",
+	},
+	{
+		Name: "empty-fields",
+		Template: NameTemplate{
+			Template: "{volume}/{year}_{issue}_{first_author}",
+			Sep:      "_",
+		},
+		Output: "2016_abbott",
+	},
+	{
+		Name: "truncate",
+		Template: NameTemplate{
+			Template: "{title}",
+			Sep:      "_",
+			MaxLen:   30,
+		},
+		Output: "observation_of_gravitational",
+	},
+	{
+		Name: "reserved",
+		Template: NameTemplate{
+			Template: "{title}",
+			Sep:      "_",
+			Case:     Keep,
+		},
+		Article: Article{Title: "Con"},
+		Output:  "Con_",
+	},
+	{
+		Name: "unicode",
+		Template: NameTemplate{
+			Template: "{title}",
+			Sep:      "_",
+			Unicode:  true,
+		},
+		Article: Article{Title: "Schrödinger's Cat"},
+		Output:  "schrödinger_s_cat",
+	},
+	{
+		Name: "empty-name",
+		Template: NameTemplate{
+			Template: "{year}/{first_author}",
+			Sep:      "_",
+		},
+		Article: Article{
+			Handle: Handle{Type: DOI, Value: "10.1000/182"},
+			Meta:   &crossref.Work{Issued: testWork.Issued},
+		},
+		Output: filepath.Join("2016", "10.1000_182"),
+	},
+}
+
+func TestNameTemplate(t *testing.T) {
+	assert := assert.New(t)
+	for _, tt := range templateTests {
+		a := tt.Article
+		if a.Meta == nil && len(a.Title) == 0 {
+			a.Title = testWork.Title[0]
+			a.DOI = testWork.DOI
+			a.Meta = &testWork
+		}
+		assert.Nil(tt.Template.Validate(), tt.Name)
+		assert.Equal(tt.Output, tt.Template.Generate(&a), tt.Name)
+	}
+}
+
+func TestNameTemplateValidate(t *testing.T) {
+	assert := assert.New(t)
+	for _, s := range []string{"", "{title", "title}", "{nope}", "{year}_{titel}"} {
+		err := NameTemplate{Template: s}.Validate()
+		assert.NotNil(err, s)
+	}
+	assert.True(strings.Contains(
+		NameTemplate{Template: "{nope}"}.Validate().Error(), "{nope}"))
+}
+
+func TestNameTemplateWithLayout(t *testing.T) {
+	a := Article{Title: testWork.Title[0], DOI: testWork.DOI, Meta: &testWork}
+	var tests = []struct {
+		Layout Layout
+		Output string
+	}{
+		{Layout: Flat, Output: "2016_abbott"},
+		{Layout: ByYear, Output: filepath.Join("2016", "2016_abbott")},
+		{Layout: ByJournal, Output: filepath.Join("physical_review_letters", "2016_abbott")},
+		{Layout: ByFirstAuthor, Output: filepath.Join("abbott", "2016_abbott")},
+		{Layout: ByType, Output: "2016_abbott"}, // type is not set
+	}
+	for _, tt := range tests {
+		t.Run(tt.Layout.String(), func(t *testing.T) {
+			tmpl := NameTemplate{Template: "{year}_{first_author}", Sep: "_"}.WithLayout(tt.Layout)
+			assert.Equal(t, tt.Output, tmpl.Generate(&a))
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package arxiv
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// newID matches arXiv identifiers used since April 2007,
+	// e.g., '0704.0001', '2101.01234' or '2101.01234v2'.
+	newID = regexp.MustCompile(`^[0-9]{2}(0[1-9]|1[0-2])\.[0-9]{4,5}(v[0-9]+)?$`)
+
+	// oldID matches arXiv identifiers used before April 2007,
+	// e.g., 'hep-th/9901001' or 'math.GT/0309136v1'.
+	oldID = regexp.MustCompile(`^[a-z]+(-[a-z]+)*(\.[A-Z]{2})?/[0-9]{2}(0[1-9]|1[0-2])[0-9]{3}(v[0-9]+)?$`)
+
+	// version matches the version suffix of an arXiv identifier.
+	version = regexp.MustCompile(`v[0-9]+$`)
+)
+
+// Clean strips the (case-insensitive) 'arXiv:' prefix from an arXiv
+// identifier, if present.
+func Clean(n string) string {
+	if len(n) > 6 && strings.EqualFold(n[:6], "arxiv:") {
+		return n[6:]
+	}
+	return n
+}
+
+// IsValid reports whether n is a syntactically valid arXiv identifier,
+// in either the new ('YYMM.NNNNN') or the old ('archive/YYMMNNN') scheme,
+// with an optional version suffix and 'arXiv:' prefix.
+func IsValid(n string) bool {
+	str := Clean(n)
+	return newID.MatchString(str) || oldID.MatchString(str)
+}
+
+// Unversioned returns the arXiv identifier without the version suffix.
+func Unversioned(n string) string {
+	return version.ReplaceAllString(Clean(n), "")
+}
+
+// DOI returns the DOI registered by arXiv for the identifier.
+// NOTE: this is not the DOI of the published version of the article.
+func DOI(n string) string {
+	return DOIPrefix + Unversioned(n)
+}
@@ -0,0 +1,90 @@
+package arxiv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type arxivTest struct {
+	Name   string
+	Input  string
+	Output bool
+}
+
+var arxivTests = []arxivTest{
+	{
+		Name:   "good-new",
+		Input:  "2101.01234",
+		Output: true,
+	},
+	{
+		Name:   "good-new-4-digit",
+		Input:  "0704.0001",
+		Output: true,
+	},
+	{
+		Name:   "good-new-version",
+		Input:  "2101.01234v2",
+		Output: true,
+	},
+	{
+		Name:   "good-new-prefix",
+		Input:  "arXiv:2101.01234v2",
+		Output: true,
+	},
+	{
+		Name:   "good-old",
+		Input:  "hep-th/9901001",
+		Output: true,
+	},
+	{
+		Name:   "good-old-subject-class",
+		Input:  "math.GT/0309136",
+		Output: true,
+	},
+	{
+		Name:   "good-old-prefix-version",
+		Input:  "arxiv:cond-mat/0211034v3",
+		Output: true,
+	},
+	{
+		Name:   "bad-new-month",
+		Input:  "2113.01234",
+		Output: false,
+	},
+	{
+		Name:   "bad-new-digits",
+		Input:  "2101.012",
+		Output: false,
+	},
+	{
+		Name:   "bad-old-digits",
+		Input:  "hep-th/990100",
+		Output: false,
+	},
+	{
+		Name:   "bad-doi",
+		Input:  "10.1109/5.771073",
+		Output: false,
+	},
+	{
+		Name:   "bad-cake",
+		Input:  "cake",
+		Output: false,
+	},
+}
+
+func TestIsValid(t *testing.T) {
+	for _, tt := range arxivTests {
+		t.Run(tt.Name, func(t *testing.T) {
+			out := IsValid(tt.Input)
+			assert.Equal(t, tt.Output, out)
+		})
+	}
+}
+
+func TestDOI(t *testing.T) {
+	assert.Equal(t, "10.48550/arXiv.2101.01234", DOI("arXiv:2101.01234v2"))
+	assert.Equal(t, "10.48550/arXiv.hep-th/9901001", DOI("hep-th/9901001v1"))
+}
@@ -0,0 +1,199 @@
+package arxiv
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Milover/fetchref/internal/crossref"
+)
+
+// cslName is a CSL-JSON name variable.
+type cslName struct {
+	Family string `json:"family,omitempty"`
+	Given  string `json:"given,omitempty"`
+}
+
+// cslDate is a CSL-JSON date variable.
+type cslDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+// cslItem is a (partial) CSL-JSON item describing an arXiv preprint.
+type cslItem struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Author    []cslName `json:"author,omitempty"`
+	Issued    cslDate   `json:"issued"`
+	Abstract  string    `json:"abstract,omitempty"`
+	Publisher string    `json:"publisher"`
+	Number    string    `json:"number"`
+	Archive   string    `json:"archive"`
+	DOI       string    `json:"DOI,omitempty"`
+	URL       string    `json:"URL"`
+}
+
+// NormalizeSpace squeezes all white space in s into single spaces,
+// and trims it. arXiv titles and abstracts are often line-wrapped.
+func NormalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// splitName splits a full author name into family and given names.
+func splitName(name string) cslName {
+	f := strings.Fields(name)
+	if len(f) == 0 {
+		return cslName{}
+	}
+	return cslName{
+		Family: f[len(f)-1],
+		Given:  strings.Join(f[:len(f)-1], " "),
+	}
+}
+
+// published returns the date on which the (first version of the)
+// entry was published.
+func (e Entry) published() time.Time {
+	t, _ := time.Parse(time.RFC3339, e.Published)
+	return t
+}
+
+// key generates a citation key from the first author's family name
+// and the year of publication, e.g., 'Einstein_1905', or from the arXiv
+// identifier n, e.g., 'arXiv:2101.01234', if the entry has no authors.
+func (e Entry) key(n string) string {
+	var b strings.Builder
+	if len(e.Authors) != 0 {
+		for _, r := range splitName(e.Authors[0].Name).Family {
+			if unicode.IsLetter(r) {
+				b.WriteRune(r)
+			}
+		}
+	}
+	if b.Len() == 0 {
+		return "arXiv:" + Unversioned(n)
+	}
+	if y := e.published().Year(); y > 1 {
+		b.WriteRune('_')
+		b.WriteString(strconv.Itoa(y))
+	}
+	return b.String()
+}
+
+// bibtexEscapes are the BibTeX special characters escaped by escapeBibTeX.
+var bibtexEscapes = strings.NewReplacer(
+	`{`, `\{`, `}`, `\}`, `%`, `\%`, `&`, `\&`, `_`, `\_`, `#`, `\#`,
+)
+
+// escapeBibTeX escapes BibTeX special characters in s, except within
+// math, e.g., '$x_1$', which arXiv titles often contain, and escape
+// sequences, e.g., '\&', which are kept as they are.
+func escapeBibTeX(s string) string {
+	var b strings.Builder
+	for len(s) != 0 {
+		i := strings.IndexAny(s, `$\`)
+		if i < 0 {
+			b.WriteString(bibtexEscapes.Replace(s))
+			break
+		}
+		b.WriteString(bibtexEscapes.Replace(s[:i]))
+		s = s[i:]
+		n := 2 // an escape sequence
+		if s[0] == '$' {
+			n = strings.IndexByte(s[1:], '$') + 2
+			if n == 1 {
+				// unbalanced, not math
+				b.WriteString(`\$`)
+				s = s[1:]
+				continue
+			}
+		}
+		if n > len(s) {
+			n = len(s)
+		}
+		b.WriteString(s[:n])
+		s = s[n:]
+	}
+	return b.String()
+}
+
+// BibTeX formats the entry as a BibTeX citation. The identifier n is
+// used as the eprint, and doi, if not empty, as the DOI of the citation.
+func BibTeX(e Entry, n, doi string) []byte {
+	var authors []string
+	for _, a := range e.Authors {
+		authors = append(authors, a.Name)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "@misc{%v,\n", e.key(n))
+	fmt.Fprintf(&b, "\ttitle = {%v},\n", escapeBibTeX(NormalizeSpace(e.Title)))
+	fmt.Fprintf(&b, "\tauthor = {%v},\n", escapeBibTeX(strings.Join(authors, " and ")))
+	if y := e.published().Year(); y > 1 {
+		fmt.Fprintf(&b, "\tyear = {%v},\n", y)
+	}
+	fmt.Fprintf(&b, "\teprint = {%v},\n", Unversioned(n))
+	fmt.Fprintf(&b, "\teprinttype = {arXiv},\n")
+	fmt.Fprintf(&b, "\tarchiveprefix = {arXiv},\n")
+	if len(e.PrimaryCategory.Term) != 0 {
+		fmt.Fprintf(&b, "\tprimaryclass = {%v},\n", e.PrimaryCategory.Term)
+	}
+	if len(doi) != 0 {
+		fmt.Fprintf(&b, "\tdoi = {%v},\n", doi)
+	}
+	fmt.Fprintf(&b, "\turl = {https://%v/%v/%v},\n", Mirrors[0], Abstract, Unversioned(n))
+	b.WriteString("}\n")
+
+	return []byte(b.String())
+}
+
+// CiteprocJSON formats the entry as a CSL-JSON citation. The identifier n
+// is used as the item number, and doi, if not empty, as the DOI of the
+// citation.
+func CiteprocJSON(e Entry, n, doi string) ([]byte, error) {
+	item := cslItem{
+		ID:        e.key(n),
+		Type:      "article",
+		Title:     NormalizeSpace(e.Title),
+		Abstract:  NormalizeSpace(e.Summary),
+		Publisher: "arXiv",
+		Number:    "arXiv:" + Unversioned(n),
+		Archive:   "arXiv",
+		DOI:       doi,
+		URL:       fmt.Sprintf("https://%v/%v/%v", Mirrors[0], Abstract, Unversioned(n)),
+	}
+	for _, a := range e.Authors {
+		item.Author = append(item.Author, splitName(a.Name))
+	}
+	if t := e.published(); t.Year() > 1 {
+		item.Issued.DateParts = [][]int{{t.Year(), int(t.Month()), t.Day()}}
+	}
+	return json.MarshalIndent(item, "", "\t")
+}
+
+// Work returns the entry as a Crossref work, with doi, if not empty,
+// as its DOI.
+func (e Entry) Work(doi string) crossref.Work {
+	w := crossref.Work{
+		DOI:       doi,
+		Title:     []string{NormalizeSpace(e.Title)},
+		Abstract:  NormalizeSpace(e.Summary),
+		Publisher: "arXiv",
+		Type:      "posted-content",
+		URL:       e.ID,
+		Source:    "arxiv",
+	}
+	for _, a := range e.Authors {
+		n := splitName(a.Name)
+		w.Author = append(w.Author, crossref.Author{Family: n.Family, Given: n.Given})
+	}
+	if t := e.published(); t.Year() > 1 {
+		w.Posted.DateParts = [][]int{{t.Year(), int(t.Month()), t.Day()}}
+		w.Issued = w.Posted
+	}
+	return w
+}
@@ -0,0 +1,63 @@
+package arxiv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBibTeX(t *testing.T) {
+	var tests = []struct {
+		Name   string
+		Entry  Entry
+		Output string
+	}{
+		{
+			Name: "escaped",
+			Entry: Entry{
+				Title:     "Q&A on 100% of the $x_1 \\& \\{y\\}$ data_set #1 {sic}\n  \\& more",
+				Published: "2021-01-04T18:59:59Z",
+				Authors:   []Author{{Name: "Jane O'Doe"}},
+			},
+			Output: `@misc{ODoe_2021,
+	title = {Q\&A on 100\% of the $x_1 \& \{y\}$ data\_set \#1 \{sic\} \& more},
+	author = {Jane O'Doe},
+	year = {2021},
+	eprint = {2101.01234},
+	eprinttype = {arXiv},
+	archiveprefix = {arXiv},
+	url = {https://arxiv.org/abs/2101.01234},
+}
+`,
+		},
+		{
+			Name: "unbalanced-math",
+			Entry: Entry{
+				Title:     "A $5 model",
+				Published: "2021-01-04T18:59:59Z",
+			},
+			Output: `@misc{arXiv:2101.01234,
+	title = {A \$5 model},
+	author = {},
+	year = {2021},
+	eprint = {2101.01234},
+	eprinttype = {arXiv},
+	archiveprefix = {arXiv},
+	url = {https://arxiv.org/abs/2101.01234},
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Output, string(BibTeX(tt.Entry, "2101.01234v2", "")))
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	e := Entry{Published: "1905-06-30T00:00:00Z"}
+	assert.Equal(t, "arXiv:hep-th/9901001", e.key("hep-th/9901001v3"))
+	e.Authors = []Author{{Name: "Albert Einstein"}}
+	assert.Equal(t, "Einstein_1905", e.key("hep-th/9901001v3"))
+}
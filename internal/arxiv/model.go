@@ -0,0 +1,82 @@
+package arxiv
+
+import "encoding/xml"
+
+// Some™ models used by arXiv's API.
+//
+// For more information about the particular fields see:
+//	https://info.arxiv.org/help/api/user-manual.html
+
+var (
+	// Mirrors is a list of arXiv mirror URLs, from which (PDF) articles
+	// can be downloaded.
+	Mirrors = []string{
+		"arxiv.org",
+		"export.arxiv.org",
+	}
+)
+
+const (
+	// URL is the arXiv API URL.
+	URL string = "export.arxiv.org"
+	// API is the path to arXiv's (Atom) query API.
+	API string = "api/query"
+	// PDF is the path to arXiv's PDF download endpoint.
+	PDF string = "pdf"
+	// Abstract is the path to arXiv's abstract (landing) page.
+	Abstract string = "abs"
+	// DOIPrefix is the prefix of DOIs registered by arXiv (through DataCite).
+	DOIPrefix string = "10.48550/arXiv."
+)
+
+// arXiv's API query parameters.
+const (
+	QueryKeyIDList string = "id_list"
+)
+
+// Author holds data about an author of an arXiv article.
+type Author struct {
+	Name        string `xml:"http://www.w3.org/2005/Atom name" json:"name"`
+	Affiliation string `xml:"http://arxiv.org/schemas/atom affiliation" json:"affiliation,omitempty"`
+}
+
+// Category holds an arXiv (or ACM/MSC) subject classification.
+type Category struct {
+	Term   string `xml:"term,attr" json:"term"`
+	Scheme string `xml:"scheme,attr" json:"scheme,omitempty"`
+}
+
+// Entry holds metadata about a single arXiv article.
+type Entry struct {
+	// ID is the URL of the article's abstract page, including the version.
+	ID        string   `xml:"http://www.w3.org/2005/Atom id" json:"id"`
+	Updated   string   `xml:"http://www.w3.org/2005/Atom updated" json:"updated"`
+	Published string   `xml:"http://www.w3.org/2005/Atom published" json:"published"`
+	Title     string   `xml:"http://www.w3.org/2005/Atom title" json:"title"`
+	Summary   string   `xml:"http://www.w3.org/2005/Atom summary" json:"summary"`
+	Authors   []Author `xml:"http://www.w3.org/2005/Atom author" json:"author"`
+	// DOI is the DOI of the published version of the article, if any.
+	DOI        string `xml:"http://arxiv.org/schemas/atom doi" json:"doi,omitempty"`
+	JournalRef string `xml:"http://arxiv.org/schemas/atom journal_ref" json:"journal_ref,omitempty"`
+	Comment    string `xml:"http://arxiv.org/schemas/atom comment" json:"comment,omitempty"`
+	// PrimaryCategory is the primary arXiv subject classification.
+	PrimaryCategory Category   `xml:"http://arxiv.org/schemas/atom primary_category" json:"primary_category"`
+	Categories      []Category `xml:"http://www.w3.org/2005/Atom category" json:"category"`
+	Links           []Link     `xml:"http://www.w3.org/2005/Atom link" json:"link"`
+}
+
+// Feed is the (Atom) response from querying arXiv's API.
+type Feed struct {
+	XMLName      xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+	TotalResults int      `xml:"http://a9.com/-/spec/opensearch/1.1/ totalResults"`
+	Entries      []Entry  `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+// Link holds a link related to an arXiv article, e.g., the abstract page,
+// PDF download link or the published version's DOI.
+type Link struct {
+	Href  string `xml:"href,attr" json:"href"`
+	Rel   string `xml:"rel,attr" json:"rel,omitempty"`
+	Type  string `xml:"type,attr" json:"type,omitempty"`
+	Title string `xml:"title,attr" json:"title,omitempty"`
+}
@@ -0,0 +1,347 @@
+// Package bib implements bibliography entries, their conversion from
+// Crossref metadata, and their parsing and formatting as BibTeX, RIS and
+// CSL-JSON.
+package bib
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Milover/fetchref/internal/crossref"
+)
+
+// Field is a field of a bibliography entry. The value is stored as it
+// appears in a BibTeX file, i.e., with (La)TeX markup, but without the
+// outer braces or quotes.
+type Field struct {
+	Name  string
+	Value string
+	// Macro reports whether the value is written as is, i.e., without
+	// braces, e.g., a macro ('jan'), a number or a concatenation.
+	Macro bool
+
+	// prefix is the parsed text preceding the value, e.g., '\n  title = ',
+	// and raw is the parsed text of the value, e.g., '{Title}', including
+	// any trailing white space. value0 and macro0 are the parsed value.
+	prefix string
+	raw    string
+	value0 string
+	macro0 bool
+	line   int // line number of the parsed field
+}
+
+// Line returns the line number of the field, if it was parsed, or 0.
+func (f *Field) Line() int {
+	return f.line
+}
+
+// Entry is a bibliography entry. Field names are lower case and kept in
+// order of appearance.
+type Entry struct {
+	Type   string // entry type, e.g., 'article', lower case
+	Key    string // citation key
+	Fields []Field
+
+	// the parsed text of the entry, which is retained, along with the
+	// parsed text of its fields, so that modified entries can be written
+	// with their formatting intact, see Entry.text.
+	head    string // e.g., '@article{key,'
+	tail    string // e.g., ',\n}'
+	rawType string
+	rawKey  string
+	line    int // line number of the parsed entry
+}
+
+// Line returns the line number of the entry, if it was parsed, or 0.
+func (e *Entry) Line() int {
+	return e.line
+}
+
+// Get returns the value of the field, or an empty string if the field
+// is not set.
+func (e *Entry) Get(name string) string {
+	if f := e.Field(name); f != nil {
+		return f.Value
+	}
+	return ""
+}
+
+// Field returns the field, or nil if it is not set.
+func (e *Entry) Field(name string) *Field {
+	name = strings.ToLower(name)
+	for i := range e.Fields {
+		if e.Fields[i].Name == name {
+			return &e.Fields[i]
+		}
+	}
+	return nil
+}
+
+// Has reports whether the field is set.
+func (e *Entry) Has(name string) bool {
+	return e.Field(name) != nil
+}
+
+// Set sets the (braced) value of the field, replacing an existing value in
+// place, or adding the field to the end of the entry.
+func (e *Entry) Set(name, value string) {
+	e.set(name, value, false)
+}
+
+// SetMacro sets the value of the field, written as is, e.g., a macro
+// ('jan') or a number. See Set.
+func (e *Entry) SetMacro(name, value string) {
+	e.set(name, value, true)
+}
+
+// set sets the value of the field.
+func (e *Entry) set(name, value string, macro bool) {
+	if f := e.Field(name); f != nil {
+		f.Value, f.Macro = value, macro
+		return
+	}
+	e.Fields = append(e.Fields, Field{
+		Name:  strings.ToLower(name),
+		Value: value,
+		Macro: macro,
+	})
+}
+
+// Del removes the field.
+func (e *Entry) Del(name string) {
+	name = strings.ToLower(name)
+	fs := e.Fields[:0]
+	for _, f := range e.Fields {
+		if f.Name != name {
+			fs = append(fs, f)
+		}
+	}
+	e.Fields = fs
+}
+
+// entryTypes maps Crossref work types to BibTeX entry types. Other work
+// types are mapped to 'misc'.
+var entryTypes = map[string]string{
+	"journal-article":     "article",
+	"book":                "book",
+	"monograph":           "book",
+	"edited-book":         "book",
+	"reference-book":      "book",
+	"book-chapter":        "incollection",
+	"book-section":        "incollection",
+	"book-part":           "incollection",
+	"reference-entry":     "incollection",
+	"proceedings-article": "inproceedings",
+	"proceedings":         "proceedings",
+	"dissertation":        "phdthesis",
+	"report":              "techreport",
+	"standard":            "techreport",
+}
+
+// months are the BibTeX month abbreviations.
+var months = [12]string{
+	"jan", "feb", "mar", "apr", "may", "jun",
+	"jul", "aug", "sep", "oct", "nov", "dec",
+}
+
+// verbatim fields are written as is, i.e., special characters are not
+// escaped.
+var verbatim = map[string]bool{
+	"doi": true, "url": true, "eprint": true, "file": true, "isbn": true,
+	"issn": true,
+}
+
+// Verbatim reports whether the field value is written as is, i.e.,
+// special characters are not escaped.
+func Verbatim(name string) bool {
+	return verbatim[strings.ToLower(name)]
+}
+
+// FromWork converts a Crossref work to a bibliography entry, keyed
+// by Key.
+func FromWork(w crossref.Work) Entry {
+	e := Entry{Type: "misc", Key: Key(w)}
+	if t, found := entryTypes[w.Type]; found {
+		e.Type = t
+	}
+	set := func(name, value string) {
+		value = strings.Join(strings.Fields(value), " ")
+		if len(value) == 0 {
+			return
+		}
+		if !Verbatim(name) {
+			value = Escape(stripTags(value))
+		}
+		e.Set(name, value)
+	}
+
+	if len(w.Title) != 0 {
+		title := w.Title[0]
+		if len(w.Subtitle) != 0 && len(w.Subtitle[0]) != 0 {
+			title += ": " + w.Subtitle[0]
+		}
+		set("title", title)
+	}
+	set("author", Names(w.Author))
+	if e.Type == "book" || e.Type == "incollection" || e.Type == "proceedings" {
+		set("editor", Names(w.Editor))
+	}
+	if len(w.ContainerTitle) != 0 {
+		switch e.Type {
+		case "article":
+			set("journal", w.ContainerTitle[0])
+		case "incollection", "inproceedings":
+			set("booktitle", w.ContainerTitle[0])
+		default:
+			set("howpublished", w.ContainerTitle[0])
+		}
+	}
+	if y := w.Year(); y > 0 {
+		set("year", strconv.Itoa(y))
+	}
+	if m := w.Issued.Month(); m >= 1 && m <= 12 {
+		e.SetMacro("month", months[m-1])
+	}
+	set("volume", w.Volume)
+	set("number", w.Issue)
+	set("pages", Pages(w.Page))
+	if len(w.Page) == 0 {
+		set("pages", w.ArticleNumber)
+	}
+	switch e.Type {
+	case "phdthesis":
+		set("school", w.Publisher)
+	case "techreport":
+		set("institution", w.Publisher)
+	default:
+		set("publisher", w.Publisher)
+	}
+	if len(w.ISBN) != 0 {
+		set("isbn", w.ISBN[0])
+	}
+	if len(w.ISSN) != 0 {
+		set("issn", w.ISSN[0])
+	}
+	set("doi", w.DOI)
+	set("url", w.URL)
+	return e
+}
+
+// Key generates a citation key from the first author's family name and
+// the year of publication, e.g., 'Einstein_1905'.
+func Key(w crossref.Work) string {
+	var b strings.Builder
+	if len(w.Author) != 0 {
+		name := w.Author[0].Family
+		if len(name) == 0 {
+			name = w.Author[0].Name
+		}
+		for _, r := range name {
+			if unicode.IsLetter(r) {
+				b.WriteRune(r)
+			}
+		}
+	}
+	if y := w.Year(); y > 0 {
+		if b.Len() != 0 {
+			b.WriteRune('_')
+		}
+		b.WriteString(strconv.Itoa(y))
+	}
+	if b.Len() == 0 {
+		return KeyFromDOI(w.DOI)
+	}
+	return b.String()
+}
+
+// KeyFromDOI generates a citation key from a DOI, by replacing all
+// characters which are not letters or digits with '_'s.
+func KeyFromDOI(doi string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, doi)
+}
+
+// Names formats the names of authors (or editors) as a BibTeX name list,
+// e.g., 'Einstein, Albert and {LIGO Scientific Collaboration}'.
+func Names(authors []crossref.Author) string {
+	var names []string
+	for _, a := range authors {
+		switch {
+		case len(a.Family) != 0 && len(a.Given) != 0:
+			names = append(names, a.Family+", "+a.Given)
+		case len(a.Family) != 0:
+			names = append(names, a.Family)
+		case len(a.Name) != 0:
+			names = append(names, "{"+a.Name+"}")
+		}
+	}
+	return strings.Join(names, " and ")
+}
+
+// Pages formats a page range with an en dash ('--'), e.g., '12--19'.
+func Pages(p string) string {
+	p = strings.TrimSpace(p)
+	if i := strings.IndexAny(p, "-–—"); i > 0 {
+		first := strings.TrimSpace(p[:i])
+		last := strings.TrimLeft(p[i:], "-–— ")
+		if len(last) != 0 {
+			return first + "--" + last
+		}
+		return first
+	}
+	return p
+}
+
+// escapes are the characters escaped by Escape.
+var escapes = strings.NewReplacer(
+	`&`, `\&`, `%`, `\%`, `#`, `\#`, `_`, `\_`, `$`, `\$`,
+)
+
+// Escape escapes (La)TeX special characters in s, unless they are
+// already escaped.
+func Escape(s string) string {
+	var b strings.Builder
+	for len(s) != 0 {
+		i := strings.IndexByte(s, '\\')
+		if i < 0 {
+			b.WriteString(escapes.Replace(s))
+			break
+		}
+		b.WriteString(escapes.Replace(s[:i]))
+		// keep escape sequences, e.g., '\&' or '\textit', as they are
+		n := i + 2
+		if n > len(s) {
+			n = len(s)
+		}
+		b.WriteString(s[i:n])
+		s = s[n:]
+	}
+	return b.String()
+}
+
+// stripTags removes HTML/XML tags, e.g., '<i>' or '<mml:math>', which
+// Crossref includes in some titles.
+func stripTags(s string) string {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '<')
+		if i < 0 || i+1 == len(s) {
+			break
+		}
+		j := strings.IndexByte(s[i:], '>')
+		if c := s[i+1]; j < 0 || !(c == '/' || unicode.IsLetter(rune(c))) {
+			b.WriteString(s[:i+1])
+			s = s[i+1:]
+			continue
+		}
+		b.WriteString(s[:i])
+		s = s[i+j+1:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
@@ -0,0 +1,82 @@
+package bib
+
+import (
+	"testing"
+
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromWork(t *testing.T) {
+	assert := assert.New(t)
+	w := crossref.Work{
+		Type:           "journal-article",
+		Title:          []string{"Observation of <i>Gravitational</i> Waves & more"},
+		Author:         []crossref.Author{{Family: "Abbott", Given: "B. P."}, {Name: "LIGO Scientific Collaboration"}},
+		ContainerTitle: []string{"Physical Review Letters"},
+		Volume:         "116",
+		Issue:          "6",
+		Page:           "061102-061110",
+		Publisher:      "American Physical Society (APS)",
+		DOI:            "10.1103/physrevlett.116.061102",
+		Issued:         crossref.DateParts{DateParts: [][]int{{2016, 2}}},
+	}
+	assert.Equal(`@article{Abbott_2016,
+	title = {Observation of Gravitational Waves \& more},
+	author = {Abbott, B. P. and {LIGO Scientific Collaboration}},
+	journal = {Physical Review Letters},
+	year = {2016},
+	month = feb,
+	volume = {116},
+	number = {6},
+	pages = {061102--061110},
+	publisher = {American Physical Society (APS)},
+	doi = {10.1103/physrevlett.116.061102},
+}
+`, FromWork(w).BibTeX())
+
+	assert.Equal("10_1000_182", Key(crossref.Work{DOI: "10.1000/182"}))
+}
+
+func TestEscape(t *testing.T) {
+	assert := assert.New(t)
+	var tests = []struct {
+		In  string
+		Out string
+	}{
+		{"A & B", `A \& B`},
+		{`A \& B`, `A \& B`},
+		{"50% of x_1 #1 $5", `50\% of x\_1 \#1 \$5`},
+		{`\textit{a}_b`, `\textit{a}\_b`},
+		{`trailing \`, `trailing \`},
+	}
+	for _, tt := range tests {
+		assert.Equal(tt.Out, Escape(tt.In), tt.In)
+	}
+}
+
+func TestPages(t *testing.T) {
+	assert := assert.New(t)
+	for in, out := range map[string]string{
+		"12-19":   "12--19",
+		"12--19":  "12--19",
+		"12 – 19": "12--19",
+		"e1234":   "e1234",
+		"12-":     "12",
+	} {
+		assert.Equal(out, Pages(in), in)
+	}
+}
+
+func TestEntry(t *testing.T) {
+	assert := assert.New(t)
+	e := Entry{Type: "misc", Key: "k"}
+	e.Set("Title", "a")
+	e.Set("year", "2000")
+	e.Set("title", "b")
+	assert.Equal([]Field{{Name: "title", Value: "b"}, {Name: "year", Value: "2000"}}, e.Fields)
+	assert.True(e.Has("YEAR"))
+	e.Del("title")
+	assert.Equal("", e.Get("title"))
+	assert.Equal("2000", e.Get("year"))
+}
@@ -0,0 +1,432 @@
+package bib
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// BibTeX formats the entry as BibTeX.
+func (e Entry) BibTeX() string {
+	var b strings.Builder
+	b.WriteString("@" + e.Type + "{" + e.Key + ",\n")
+	for _, f := range e.Fields {
+		b.WriteString("\t" + f.Name + " = " + f.format() + ",\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// format formats the field value, i.e., braced, unless it is a macro.
+func (f Field) format() string {
+	if f.Macro {
+		return f.Value
+	}
+	return "{" + f.Value + "}"
+}
+
+// WriteBibTeX writes the entries to w as BibTeX, separated by blank lines.
+func WriteBibTeX(w io.Writer, entries []Entry) error {
+	for i, e := range entries {
+		s := e.BibTeX()
+		if i != 0 {
+			s = "\n" + s
+		}
+		if _, err := io.WriteString(w, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// text returns the text of the entry. The text of parsed entries is
+// retained, apart from modified fields, which are written in place,
+// while added fields are written after the last field, indented as the
+// first field.
+func (e *Entry) text() string {
+	if len(e.head) == 0 {
+		return strings.TrimSuffix(e.BibTeX(), "\n")
+	}
+	var b strings.Builder
+	if e.Type != strings.ToLower(e.rawType) || e.Key != e.rawKey {
+		b.WriteString("@" + e.Type + "{" + e.Key + ",")
+	} else {
+		b.WriteString(e.head)
+	}
+	indent := "\t"
+	for _, f := range e.Fields {
+		if len(f.prefix) != 0 {
+			lead := f.prefix[:len(f.prefix)-len(strings.TrimLeft(f.prefix, " \t\r\n"))]
+			indent = lead[strings.LastIndexByte(lead, '\n')+1:]
+			break
+		}
+	}
+	for i, f := range e.Fields {
+		if i != 0 {
+			b.WriteByte(',')
+		}
+		switch {
+		case len(f.prefix) == 0:
+			b.WriteString("\n" + indent + f.Name + " = " + f.format())
+		case f.Value == f.value0 && f.Macro == f.macro0:
+			b.WriteString(f.prefix + f.raw)
+		default:
+			trailing := f.raw[len(strings.TrimRight(f.raw, " \t\r\n")):]
+			b.WriteString(f.prefix + f.format() + trailing)
+		}
+	}
+	tail := e.tail
+	if len(e.Fields) == 0 {
+		// all fields were removed
+		tail = strings.TrimPrefix(tail, ",")
+	}
+	b.WriteString(tail)
+	return b.String()
+}
+
+// File is a parsed BibTeX file. Text other than entries, i.e., comments,
+// and @comment, @preamble and @string blocks, is retained, as is the
+// formatting of entries, so that the file can be written back unchanged,
+// apart from modified entries.
+type File struct {
+	Blocks []Block
+}
+
+// Block is a part of a BibTeX file, i.e., either an entry, or text.
+type Block struct {
+	Entry *Entry
+	Text  string
+}
+
+// Entries returns the entries of the file, in order.
+func (f *File) Entries() []*Entry {
+	var es []*Entry
+	for _, b := range f.Blocks {
+		if b.Entry != nil {
+			es = append(es, b.Entry)
+		}
+	}
+	return es
+}
+
+// Add adds the entry to the end of the file, separated by a blank line.
+func (f *File) Add(e Entry) {
+	var last string
+	for i := len(f.Blocks) - 1; i >= 0 && len(last) < 2; i-- {
+		if f.Blocks[i].Entry != nil {
+			last = "}" + last
+			break
+		}
+		last = f.Blocks[i].Text + last
+	}
+	switch {
+	case len(last) == 0:
+	case strings.HasSuffix(last, "\n\n"):
+	case strings.HasSuffix(last, "\n"):
+		f.Blocks = append(f.Blocks, Block{Text: "\n"})
+	default:
+		f.Blocks = append(f.Blocks, Block{Text: "\n\n"})
+	}
+	f.Blocks = append(f.Blocks, Block{Entry: &e}, Block{Text: "\n"})
+}
+
+// Bytes returns the text of the file.
+func (f *File) Bytes() []byte {
+	var b strings.Builder
+	for _, bl := range f.Blocks {
+		if bl.Entry != nil {
+			b.WriteString(bl.Entry.text())
+		} else {
+			b.WriteString(bl.Text)
+		}
+	}
+	return []byte(b.String())
+}
+
+// ParseBibTeX parses a BibTeX file.
+func ParseBibTeX(data []byte) (*File, error) {
+	p := &bibParser{s: string(data)}
+	f := &File{}
+	text := 0 // start of the pending text block
+	for {
+		i := strings.IndexByte(p.s[p.pos:], '@')
+		if i < 0 {
+			break
+		}
+		at := p.pos + i
+		p.pos = at + 1
+		typ := p.ident()
+		p.space()
+		if len(typ) == 0 || p.eof() || (p.peek() != '{' && p.peek() != '(') {
+			continue // a stray '@', part of the text
+		}
+		switch strings.ToLower(typ) {
+		case "comment", "preamble", "string":
+			if err := p.skipBlock(); err != nil {
+				return nil, fmt.Errorf("bibtex: line %d: %w", p.line(at), err)
+			}
+			continue
+		}
+		e, err := p.entry(typ, at)
+		if err != nil {
+			return nil, fmt.Errorf("bibtex: line %d: %w", p.line(at), err)
+		}
+		if at > text {
+			f.Blocks = append(f.Blocks, Block{Text: p.s[text:at]})
+		}
+		f.Blocks = append(f.Blocks, Block{Entry: e})
+		text = p.pos
+	}
+	if text < len(p.s) {
+		f.Blocks = append(f.Blocks, Block{Text: p.s[text:]})
+	}
+	return f, nil
+}
+
+// bibParser is a BibTeX parser.
+type bibParser struct {
+	s   string
+	pos int
+
+	// lineOff is the offset of the last line number lookup, at lineNo
+	lineOff int
+	lineNo  int
+}
+
+func (p *bibParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *bibParser) peek() byte {
+	return p.s[p.pos]
+}
+
+// line returns the line number of the offset.
+func (p *bibParser) line(off int) int {
+	if off < p.lineOff || p.lineNo == 0 {
+		p.lineOff, p.lineNo = 0, 1
+	}
+	p.lineNo += strings.Count(p.s[p.lineOff:off], "\n")
+	p.lineOff = off
+	return p.lineNo
+}
+
+// space skips white space.
+func (p *bibParser) space() {
+	for !p.eof() && strings.IndexByte(" \t\r\n", p.peek()) >= 0 {
+		p.pos++
+	}
+}
+
+// isIdent reports whether c may be part of a BibTeX identifier, i.e., an
+// entry type, field name or macro name.
+func isIdent(c byte) bool {
+	return c > ' ' && c < 0x7f && strings.IndexByte(`"#%'(),={}`, c) < 0
+}
+
+// ident reads an identifier.
+func (p *bibParser) ident() string {
+	start := p.pos
+	for !p.eof() && isIdent(p.peek()) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// closing returns the closing delimiter of the opening delimiter.
+func closing(open byte) byte {
+	if open == '(' {
+		return ')'
+	}
+	return '}'
+}
+
+// skipBlock skips a brace (or parenthesis) delimited block.
+func (p *bibParser) skipBlock() error {
+	open := p.peek()
+	if open == '{' {
+		_, err := p.braced()
+		return err
+	}
+	end := strings.IndexByte(p.s[p.pos:], closing(open))
+	if end < 0 {
+		return fmt.Errorf("unterminated block")
+	}
+	p.pos += end + 1
+	return nil
+}
+
+// entry reads an entry, starting at the opening delimiter, of type typ,
+// which starts at offset at.
+func (p *bibParser) entry(typ string, at int) (*Entry, error) {
+	end := closing(p.peek())
+	p.pos++
+	p.space()
+	keyStart := p.pos
+	for !p.eof() && p.peek() != ',' && p.peek() != end &&
+		strings.IndexByte(" \t\r\n", p.peek()) < 0 {
+		p.pos++
+	}
+	e := &Entry{
+		Type:    strings.ToLower(typ),
+		Key:     p.s[keyStart:p.pos],
+		rawType: typ,
+		line:    p.line(at),
+	}
+	e.rawKey = e.Key
+	p.space()
+	if p.eof() {
+		return nil, fmt.Errorf("unterminated entry %v", e.Key)
+	}
+	if p.peek() == end {
+		e.head = p.s[at:p.pos]
+		p.pos++
+		e.tail = string(end)
+		return e, nil
+	}
+	if p.peek() != ',' {
+		return nil, fmt.Errorf("expected ',' after key %v", e.Key)
+	}
+	p.pos++
+	e.head = p.s[at:p.pos]
+	for {
+		start := p.pos
+		p.space()
+		if p.eof() {
+			return nil, fmt.Errorf("unterminated entry %v", e.Key)
+		}
+		if p.peek() == end {
+			p.pos++
+			e.tail = p.s[start:p.pos]
+			if len(e.Fields) != 0 {
+				e.tail = "," + e.tail
+			}
+			return e, nil
+		}
+		line := p.line(p.pos)
+		name := p.ident()
+		if len(name) == 0 {
+			return nil, fmt.Errorf("expected field name in entry %v", e.Key)
+		}
+		p.space()
+		if p.eof() || p.peek() != '=' {
+			return nil, fmt.Errorf("expected '=' after field %v in entry %v", name, e.Key)
+		}
+		p.pos++
+		p.space()
+		valStart := p.pos
+		value, macro, err := p.value()
+		if err != nil {
+			return nil, fmt.Errorf("field %v in entry %v: %w", name, e.Key, err)
+		}
+		p.space()
+		e.Fields = append(e.Fields, Field{
+			Name:   strings.ToLower(name),
+			Value:  value,
+			Macro:  macro,
+			prefix: p.s[start:valStart],
+			raw:    p.s[valStart:p.pos],
+			value0: value,
+			macro0: macro,
+			line:   line,
+		})
+		if p.eof() {
+			return nil, fmt.Errorf("unterminated entry %v", e.Key)
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case end:
+			p.pos++
+			e.tail = string(end)
+			return e, nil
+		default:
+			return nil, fmt.Errorf("expected ',' after field %v in entry %v", name, e.Key)
+		}
+	}
+}
+
+// value reads a field value, i.e., a braced or quoted string, a number or
+// a macro name, or a concatenation ('#') of these. Braced and quoted
+// strings are returned without their delimiters, while numbers, macro
+// names and concatenations are returned as is, and reported as macros.
+func (p *bibParser) value() (string, bool, error) {
+	start := p.pos
+	var parts []string
+	macro := false
+	for {
+		p.space()
+		if p.eof() {
+			return "", false, fmt.Errorf("missing value")
+		}
+		switch c := p.peek(); {
+		case c == '{':
+			s, err := p.braced()
+			if err != nil {
+				return "", false, err
+			}
+			parts = append(parts, s)
+		case c == '"':
+			s, err := p.quoted()
+			if err != nil {
+				return "", false, err
+			}
+			parts = append(parts, s)
+		case isIdent(c):
+			parts = append(parts, p.ident())
+			macro = true
+		default:
+			return "", false, fmt.Errorf("unexpected %q", c)
+		}
+		save := p.pos
+		p.space()
+		if !p.eof() && p.peek() == '#' {
+			p.pos++
+			continue
+		}
+		p.pos = save
+		break
+	}
+	if len(parts) == 1 {
+		return parts[0], macro, nil
+	}
+	return p.s[start:p.pos], true, nil
+}
+
+// braced reads a braced string, and returns its content.
+func (p *bibParser) braced() (string, error) {
+	start := p.pos
+	depth := 0
+	for ; !p.eof(); p.pos++ {
+		switch p.peek() {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				p.pos++
+				return p.s[start+1 : p.pos-1], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unbalanced braces")
+}
+
+// quoted reads a quoted string, and returns its content.
+func (p *bibParser) quoted() (string, error) {
+	start := p.pos
+	depth := 0
+	for p.pos++; !p.eof(); p.pos++ {
+		switch p.peek() {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '"':
+			if depth == 0 {
+				p.pos++
+				return p.s[start+1 : p.pos-1], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
@@ -0,0 +1,74 @@
+package bib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testBibTeX = `% references
+@String{prl = "Physical Review Letters"}
+
+@ARTICLE{Abbott_2016,
+  title   = {{O}bservation of Gravitational Waves},
+  author  = "Abbott, B. P. and others",
+  journal = prl,
+  year    = 2016,
+  month   = feb # "~11",
+}
+
+@book{Knuth_1984, title={The {\TeX}book}}
+@comment{not an entry}
+`
+
+func TestParseBibTeX(t *testing.T) {
+	assert := assert.New(t)
+	f, err := ParseBibTeX([]byte(testBibTeX))
+	if !assert.Nil(err) {
+		return
+	}
+	assert.Equal(testBibTeX, string(f.Bytes()))
+
+	es := f.Entries()
+	if !assert.Len(es, 2) {
+		return
+	}
+	assert.Equal("article", es[0].Type)
+	assert.Equal("Abbott_2016", es[0].Key)
+	assert.Equal("{O}bservation of Gravitational Waves", es[0].Get("title"))
+	assert.Equal("Abbott, B. P. and others", es[0].Get("author"))
+	assert.Equal("prl", es[0].Get("journal"))
+	assert.True(es[0].Field("journal").Macro)
+	assert.Equal(`feb # "~11"`, es[0].Get("month"))
+	assert.Equal(`The {\TeX}book`, es[1].Get("title"))
+
+	es[0].Set("pages", "061102")
+	es[0].Set("year", "2016")
+	es[1].Del("title")
+	es[1].Set("publisher", "Addison-Wesley")
+	assert.Equal(`% references
+@String{prl = "Physical Review Letters"}
+
+@ARTICLE{Abbott_2016,
+  title   = {{O}bservation of Gravitational Waves},
+  author  = "Abbott, B. P. and others",
+  journal = prl,
+  year    = {2016},
+  month   = feb # "~11",
+  pages = {061102},
+}
+
+@book{Knuth_1984,
+	publisher = {Addison-Wesley}}
+@comment{not an entry}
+`, string(f.Bytes()))
+
+	for _, in := range []string{
+		"@article{k, title = {a}",
+		"@misc{k, note = {a}}}@misc{",
+		"@article{k, title {a}}",
+	} {
+		_, err := ParseBibTeX([]byte(in))
+		assert.NotNil(err, in)
+	}
+}
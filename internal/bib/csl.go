@@ -0,0 +1,319 @@
+package bib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// cslTypes maps CSL item types to BibTeX entry types. Other item types
+// are mapped to 'misc'.
+var cslTypes = map[string]string{
+	"article-journal":  "article",
+	"article-magazine": "article",
+	"article":          "article",
+	"book":             "book",
+	"chapter":          "incollection",
+	"entry":            "incollection",
+	"paper-conference": "inproceedings",
+	"thesis":           "phdthesis",
+	"report":           "techreport",
+	"manuscript":       "unpublished",
+}
+
+// bibTypesCSL maps BibTeX entry types to CSL item types. Other entry types
+// are mapped to 'document'.
+var bibTypesCSL = map[string]string{
+	"article":       "article-journal",
+	"book":          "book",
+	"incollection":  "chapter",
+	"inbook":        "chapter",
+	"inproceedings": "paper-conference",
+	"conference":    "paper-conference",
+	"proceedings":   "book",
+	"phdthesis":     "thesis",
+	"mastersthesis": "thesis",
+	"thesis":        "thesis",
+	"techreport":    "report",
+	"report":        "report",
+	"unpublished":   "manuscript",
+}
+
+// CSLItem is a CSL-JSON bibliography item. Only commonly used variables
+// are supported.
+type CSLItem struct {
+	ID             cslString `json:"id"`
+	Type           string    `json:"type"`
+	Title          string    `json:"title,omitempty"`
+	Author         []CSLName `json:"author,omitempty"`
+	Editor         []CSLName `json:"editor,omitempty"`
+	ContainerTitle string    `json:"container-title,omitempty"`
+	Issued         *CSLDate  `json:"issued,omitempty"`
+	Volume         cslString `json:"volume,omitempty"`
+	Issue          cslString `json:"issue,omitempty"`
+	Page           cslString `json:"page,omitempty"`
+	Publisher      string    `json:"publisher,omitempty"`
+	PublisherPlace string    `json:"publisher-place,omitempty"`
+	ISBN           string    `json:"ISBN,omitempty"`
+	ISSN           string    `json:"ISSN,omitempty"`
+	DOI            string    `json:"DOI,omitempty"`
+	URL            string    `json:"URL,omitempty"`
+	Abstract       string    `json:"abstract,omitempty"`
+	Note           string    `json:"note,omitempty"`
+	Keyword        string    `json:"keyword,omitempty"`
+	Language       string    `json:"language,omitempty"`
+}
+
+// CSLName is a CSL-JSON name.
+type CSLName struct {
+	Family  string `json:"family,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Literal string `json:"literal,omitempty"`
+}
+
+// CSLDate is a CSL-JSON date.
+type CSLDate struct {
+	DateParts [][]cslInt `json:"date-parts,omitempty"`
+	Raw       string     `json:"raw,omitempty"`
+	Literal   string     `json:"literal,omitempty"`
+}
+
+// year returns the year, and month (or 0), of the date.
+func (d *CSLDate) year() (int, int) {
+	if d == nil {
+		return 0, 0
+	}
+	if len(d.DateParts) != 0 && len(d.DateParts[0]) != 0 {
+		y, m := int(d.DateParts[0][0]), 0
+		if len(d.DateParts[0]) > 1 {
+			m = int(d.DateParts[0][1])
+		}
+		return y, m
+	}
+	s := d.Raw
+	if len(s) == 0 {
+		s = d.Literal
+	}
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r < '0' || r > '9' }) {
+		if len(f) == 4 {
+			y, _ := strconv.Atoi(f)
+			return y, 0
+		}
+	}
+	return 0, 0
+}
+
+// cslString is a CSL-JSON string, which may also be given as a number,
+// e.g., '"volume": 12'.
+type cslString string
+
+// UnmarshalJSON unmarshals a JSON string or number.
+func (s *cslString) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var v string
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		*s = cslString(v)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*s = cslString(n)
+	return nil
+}
+
+// cslInt is a CSL-JSON number, which may also be given as a string, e.g.,
+// '"date-parts": [["2016", "2"]]'.
+type cslInt int
+
+// UnmarshalJSON unmarshals a JSON number or string.
+func (n *cslInt) UnmarshalJSON(data []byte) error {
+	var s cslString
+	if err := s.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	v, err := strconv.Atoi(strings.TrimSpace(string(s)))
+	if err != nil {
+		return fmt.Errorf("invalid date part: %v", string(data))
+	}
+	*n = cslInt(v)
+	return nil
+}
+
+// ParseCSL parses CSL-JSON data, i.e., an array of items, or a single item.
+func ParseCSL(data []byte) ([]Entry, error) {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\ufeff")))
+	var items []CSLItem
+	if bytes.HasPrefix(data, []byte("{")) {
+		items = make([]CSLItem, 1)
+		if err := json.Unmarshal(data, &items[0]); err != nil {
+			return nil, fmt.Errorf("csl-json: %w", err)
+		}
+	} else if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("csl-json: %w", err)
+	}
+	entries := make([]Entry, len(items))
+	for i, it := range items {
+		entries[i] = it.Entry()
+	}
+	return entries, nil
+}
+
+// Entry converts the item to a bibliography entry.
+func (it CSLItem) Entry() Entry {
+	e := Entry{Type: "misc", Key: string(it.ID)}
+	if t, found := cslTypes[it.Type]; found {
+		e.Type = t
+	}
+	set := func(name, value string) {
+		value = strings.Join(strings.Fields(value), " ")
+		if len(value) == 0 {
+			return
+		}
+		if !Verbatim(name) {
+			value = Escape(stripTags(value))
+		}
+		e.Set(name, value)
+	}
+	set("title", it.Title)
+	names := func(ns []CSLName) string {
+		var names []Name
+		for _, n := range ns {
+			names = append(names, Name{
+				Family:  Escape(n.Family),
+				Given:   Escape(n.Given),
+				Literal: Escape(n.Literal),
+			})
+		}
+		return FormatNames(names)
+	}
+	e.Set("author", names(it.Author))
+	e.Set("editor", names(it.Editor))
+	for _, name := range []string{"author", "editor"} {
+		if len(e.Get(name)) == 0 {
+			e.Del(name)
+		}
+	}
+	switch e.Type {
+	case "article":
+		set("journal", it.ContainerTitle)
+	case "incollection", "inproceedings":
+		set("booktitle", it.ContainerTitle)
+	default:
+		set("howpublished", it.ContainerTitle)
+	}
+	if y, m := it.Issued.year(); y > 0 {
+		set("year", strconv.Itoa(y))
+		if m >= 1 && m <= 12 {
+			e.SetMacro("month", months[m-1])
+		}
+	}
+	set("volume", string(it.Volume))
+	set("number", string(it.Issue))
+	set("pages", Pages(string(it.Page)))
+	switch e.Type {
+	case "phdthesis":
+		set("school", it.Publisher)
+	case "techreport":
+		set("institution", it.Publisher)
+	default:
+		set("publisher", it.Publisher)
+	}
+	set("address", it.PublisherPlace)
+	set("isbn", it.ISBN)
+	set("issn", it.ISSN)
+	set("doi", it.DOI)
+	set("url", it.URL)
+	set("abstract", it.Abstract)
+	set("note", it.Note)
+	set("keywords", it.Keyword)
+	set("language", it.Language)
+	return e
+}
+
+// CSL converts the entry to a CSL-JSON item.
+func (e Entry) CSL() CSLItem {
+	get := func(name string) string {
+		return strings.TrimSpace(Plain(e.Get(name)))
+	}
+	it := CSLItem{
+		ID:             cslString(e.Key),
+		Type:           "document",
+		Title:          get("title"),
+		ContainerTitle: get("journal"),
+		Volume:         cslString(get("volume")),
+		Issue:          cslString(get("number")),
+		Page:           cslString(strings.ReplaceAll(get("pages"), "–", "-")),
+		Publisher:      get("publisher"),
+		PublisherPlace: get("address"),
+		ISBN:           get("isbn"),
+		ISSN:           get("issn"),
+		DOI:            get("doi"),
+		URL:            get("url"),
+		Abstract:       get("abstract"),
+		Note:           get("note"),
+		Keyword:        get("keywords"),
+		Language:       get("language"),
+	}
+	if t, found := bibTypesCSL[e.Type]; found {
+		it.Type = t
+	}
+	if len(it.ContainerTitle) == 0 {
+		it.ContainerTitle = get("booktitle")
+	}
+	for _, name := range []string{"school", "institution"} {
+		if len(it.Publisher) == 0 {
+			it.Publisher = get(name)
+		}
+	}
+	names := func(ns []Name) []CSLName {
+		var names []CSLName
+		for _, n := range ns {
+			names = append(names, CSLName(n))
+		}
+		return names
+	}
+	it.Author = names(ParseNames(e.Get("author")))
+	it.Editor = names(ParseNames(e.Get("editor")))
+	if y := Year(&e); y > 0 {
+		parts := []cslInt{cslInt(y)}
+		if m := month(e.Get("month")); m > 0 {
+			parts = append(parts, cslInt(m))
+		}
+		it.Issued = &CSLDate{DateParts: [][]cslInt{parts}}
+	}
+	return it
+}
+
+// month returns the month (1-12) of a BibTeX month field value, e.g.,
+// 'feb', 'February' or '2', or 0.
+func month(s string) int {
+	s = strings.ToLower(strings.TrimSpace(Plain(s)))
+	if m, err := strconv.Atoi(s); err == nil && m >= 1 && m <= 12 {
+		return m
+	}
+	for i, m := range months {
+		if len(s) >= 3 && strings.HasPrefix(s, m) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// WriteCSL writes the entries to w as a CSL-JSON array.
+func WriteCSL(w io.Writer, entries []Entry) error {
+	items := make([]CSLItem, len(entries))
+	for i, e := range entries {
+		items[i] = e.CSL()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(items)
+}
@@ -0,0 +1,222 @@
+package bib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Format is a bibliography file format.
+type Format int
+
+const (
+	BibTeX Format = iota
+	RIS
+	CSLJSON
+	Hayagriva // output only
+)
+
+var (
+	// formatNames are the user-friendly Format names.
+	formatNames = [...]string{
+		"bibtex",
+		"ris",
+		"csl-json",
+		"hayagriva",
+	}
+
+	// formatExtensions are the file name extensions of the formats.
+	formatExtensions = [...]string{
+		".bib",
+		".ris",
+		".json",
+		".yml",
+	}
+)
+
+// Set sets the format from its name.
+func (f *Format) Set(name string) error {
+	for i, n := range formatNames {
+		if strings.EqualFold(name, n) {
+			*f = Format(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown bibliography format: %v, expected one of: %v",
+		name, strings.Join(formatNames[:], ", "))
+}
+
+// String returns the format name.
+func (f Format) String() string {
+	return formatNames[f]
+}
+
+// Type returns the format type name.
+func (f Format) Type() string {
+	return "string"
+}
+
+// Extension returns the file name extension of the format.
+func (f Format) Extension() string {
+	return formatExtensions[f]
+}
+
+// Detect detects the format of the named bibliography file from its file
+// name extension, or, if the extension is not known, from its content.
+func Detect(name string, data []byte) (Format, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".bib", ".bibtex":
+		return BibTeX, nil
+	case ".ris":
+		return RIS, nil
+	case ".json":
+		return CSLJSON, nil
+	case ".yml", ".yaml":
+		return Hayagriva, nil
+	}
+	data = bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\ufeff")), " \t\r\n")
+	switch {
+	case bytes.HasPrefix(data, []byte("[")), bytes.HasPrefix(data, []byte("{")):
+		return CSLJSON, nil
+	case risTag.Match(bytes.TrimRight(bytes.SplitN(data, []byte("\n"), 2)[0], " \r")):
+		return RIS, nil
+	case bytes.Contains(data, []byte("@")):
+		return BibTeX, nil
+	}
+	return 0, fmt.Errorf("%v: unknown bibliography format", name)
+}
+
+// Parse parses bibliography data of the format. Hayagriva bibliographies
+// cannot be parsed.
+func Parse(data []byte, f Format) ([]Entry, error) {
+	switch f {
+	case RIS:
+		return ParseRIS(data)
+	case CSLJSON:
+		return ParseCSL(data)
+	case Hayagriva:
+		return nil, fmt.Errorf("reading %v bibliographies is not supported", f)
+	}
+	file, err := ParseBibTeX(data)
+	if err != nil {
+		return nil, err
+	}
+	var es []Entry
+	for _, e := range file.Entries() {
+		es = append(es, *e)
+	}
+	return es, nil
+}
+
+// Write writes the entries to w in the format.
+func Write(w io.Writer, entries []Entry, f Format) error {
+	switch f {
+	case RIS:
+		return WriteRIS(w, entries)
+	case CSLJSON:
+		return WriteCSL(w, entries)
+	case Hayagriva:
+		return WriteHayagriva(w, entries)
+	}
+	return WriteBibTeX(w, entries)
+}
+
+// GenerateKey generates a citation key for the entry, from the family name
+// of its first author (or editor) and its year, e.g., 'Einstein_1905', or
+// from its DOI, or title, if these are not set.
+func GenerateKey(e *Entry) string {
+	var b strings.Builder
+	names := ParseNames(e.Get("author"))
+	if len(names) == 0 {
+		names = ParseNames(e.Get("editor"))
+	}
+	if len(names) != 0 {
+		name := names[0].Family
+		if len(name) == 0 {
+			name = names[0].Literal
+		}
+		for _, r := range name {
+			if unicode.IsLetter(r) {
+				b.WriteRune(r)
+			}
+		}
+	}
+	if y := Year(e); y > 0 {
+		if b.Len() != 0 {
+			b.WriteRune('_')
+		}
+		b.WriteString(strconv.Itoa(y))
+	}
+	if b.Len() == 0 {
+		if d := e.Get("doi"); len(d) != 0 {
+			return KeyFromDOI(d)
+		}
+		for _, w := range strings.Fields(Plain(e.Get("title"))) {
+			for _, r := range w {
+				if unicode.IsLetter(r) || unicode.IsDigit(r) {
+					b.WriteRune(r)
+				}
+			}
+			break
+		}
+	}
+	return b.String()
+}
+
+// Year returns the year of the entry, i.e., the leading digits of its
+// 'year' or 'date' field, or 0.
+func Year(e *Entry) int {
+	s := e.Get("year")
+	if len(s) == 0 {
+		s = e.Get("date")
+	}
+	s = Plain(s)
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	y, _ := strconv.Atoi(s[:end])
+	return y
+}
+
+// UniqueKeys makes the citation keys of the entries unique, by generating
+// keys for entries without one, and suffixing duplicate keys with 'a',
+// 'b', 'c'..., e.g., 'Einstein_1905a'.
+func UniqueKeys(entries []Entry) {
+	count := make(map[string]int)
+	for i := range entries {
+		if len(entries[i].Key) == 0 {
+			entries[i].Key = GenerateKey(&entries[i])
+		}
+		count[entries[i].Key]++
+	}
+	used := make(map[string]bool)
+	for i := range entries {
+		key := entries[i].Key
+		if count[key] == 1 {
+			used[key] = true
+			continue
+		}
+		for n := 0; ; n++ {
+			k := key + suffix(n)
+			if !used[k] && (count[k] == 0 || k == key) {
+				entries[i].Key = k
+				used[k] = true
+				break
+			}
+		}
+	}
+}
+
+// suffix returns the n-th key suffix, i.e., 'a', 'b'... 'z', 'aa', 'ab'...
+func suffix(n int) string {
+	s := string(rune('a' + n%26))
+	if n >= 26 {
+		s = suffix(n/26-1) + s
+	}
+	return s
+}
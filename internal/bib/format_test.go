@@ -0,0 +1,242 @@
+package bib
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRIS = "TY  - JOUR\r\n" +
+	"AU  - Abbott, B. P.\r\n" +
+	"AU  - LIGO Scientific Collaboration\r\n" +
+	"TI  - Observation of gravitational waves\r\n" +
+	"  from a binary black hole merger\r\n" +
+	"T2  - Physical Review Letters\r\n" +
+	"PY  - 2016/02/11\r\n" +
+	"VL  - 116\r\n" +
+	"SP  - 061102\r\n" +
+	"SN  - 0031-9007\r\n" +
+	"DO  - 10.1103/PhysRevLett.116.061102\r\n" +
+	"KW  - gravitational waves\r\n" +
+	"KW  - black holes\r\n" +
+	"ER  - \r\n" +
+	"\r\n" +
+	"TY  - BOOK\r\n" +
+	"ID  - Knuth_1984\r\n" +
+	"AU  - Knuth, Donald E.\r\n" +
+	"TI  - The TeXbook\r\n" +
+	"PY  - 1984\r\n" +
+	"PB  - Addison-Wesley\r\n" +
+	"SN  - 978-0-201-13447-6\r\n" +
+	"ER  - \r\n"
+
+const testCSL = `[
+  {
+    "id": "Abbott_2016",
+    "type": "article-journal",
+    "title": "Observation of gravitational waves from a binary black hole merger",
+    "author": [
+      {
+        "family": "Abbott",
+        "given": "B. P."
+      },
+      {
+        "literal": "LIGO Scientific Collaboration"
+      }
+    ],
+    "container-title": "Physical Review Letters",
+    "issued": {
+      "date-parts": [
+        [
+          2016,
+          2
+        ]
+      ]
+    },
+    "volume": "116",
+    "page": "061102",
+    "ISSN": "0031-9007",
+    "DOI": "10.1103/PhysRevLett.116.061102",
+    "keyword": "gravitational waves, black holes"
+  },
+  {
+    "id": "Knuth_1984",
+    "type": "book",
+    "title": "The TeXbook",
+    "author": [
+      {
+        "family": "Knuth",
+        "given": "Donald E."
+      }
+    ],
+    "issued": {
+      "date-parts": [
+        [
+          1984
+        ]
+      ]
+    },
+    "publisher": "Addison-Wesley",
+    "ISBN": "978-0-201-13447-6"
+  }
+]
+`
+
+func TestParseRIS(t *testing.T) {
+	assert := assert.New(t)
+	es, err := ParseRIS([]byte(testRIS))
+	if !assert.Nil(err) || !assert.Len(es, 2) {
+		return
+	}
+	assert.Equal(`@article{,
+	author = {Abbott, B. P. and {LIGO Scientific Collaboration}},
+	title = {Observation of gravitational waves from a binary black hole merger},
+	journal = {Physical Review Letters},
+	year = {2016},
+	month = feb,
+	volume = {116},
+	pages = {061102},
+	issn = {0031-9007},
+	doi = {10.1103/PhysRevLett.116.061102},
+	keywords = {gravitational waves, black holes},
+}
+`, es[0].BibTeX())
+	assert.Equal("Knuth_1984", es[1].Key)
+	assert.Equal("book", es[1].Type)
+	assert.Equal("978-0-201-13447-6", es[1].Get("isbn"))
+
+	_, err = ParseRIS([]byte("AU  - Doe, J.\r\n"))
+	assert.NotNil(err)
+}
+
+func TestFormats(t *testing.T) {
+	assert := assert.New(t)
+	es, err := ParseCSL([]byte(testCSL))
+	if !assert.Nil(err) || !assert.Len(es, 2) {
+		return
+	}
+	assert.Equal("Abbott_2016", es[0].Key)
+	assert.Equal("feb", es[0].Get("month"))
+	assert.Equal("Abbott, B. P. and {LIGO Scientific Collaboration}", es[0].Get("author"))
+
+	// CSL-JSON round trip
+	var b bytes.Buffer
+	assert.Nil(WriteCSL(&b, es))
+	assert.Equal(testCSL, b.String())
+
+	// RIS round trip, apart from the order of fields
+	b.Reset()
+	assert.Nil(WriteRIS(&b, es))
+	ris, err := ParseRIS(b.Bytes())
+	if assert.Nil(err) && assert.Len(ris, 2) {
+		for i := range es {
+			assert.Equal(es[i].Key, ris[i].Key)
+			assert.ElementsMatch(es[i].Fields, ris[i].Fields)
+		}
+	}
+
+	// CSL-JSON items with numbers and strings in place of each other
+	es, err = ParseCSL([]byte(`{"id": 1, "type": "book", "volume": 2, "issued": {"date-parts": [["2001", "3"]]}}`))
+	if assert.Nil(err) && assert.Len(es, 1) {
+		assert.Equal("1", es[0].Key)
+		assert.Equal("2", es[0].Get("volume"))
+		assert.Equal(2001, Year(&es[0]))
+		assert.Equal("mar", es[0].Get("month"))
+	}
+}
+
+func TestWriteHayagriva(t *testing.T) {
+	assert := assert.New(t)
+	es, err := ParseCSL([]byte(testCSL))
+	if !assert.Nil(err) {
+		return
+	}
+	es = append(es, Entry{Type: "inproceedings", Key: "Doe_2001", Fields: []Field{
+		{Name: "title", Value: `The {"}best{"} paper`},
+		{Name: "booktitle", Value: "Proc. Conf."},
+		{Name: "eprint", Value: "2101.01234"},
+		{Name: "archiveprefix", Value: "arXiv"},
+	}})
+	var b bytes.Buffer
+	assert.Nil(Write(&b, es, Hayagriva))
+	assert.Equal(`"Abbott_2016":
+  type: "article"
+  title: "Observation of gravitational waves from a binary black hole merger"
+  author:
+    - "Abbott, B. P."
+    - "LIGO Scientific Collaboration"
+  date: "2016-02"
+  page-range: "061102"
+  serial-number:
+    doi: "10.1103/PhysRevLett.116.061102"
+    issn: "0031-9007"
+  parent:
+    type: "periodical"
+    title: "Physical Review Letters"
+    volume: "116"
+
+"Knuth_1984":
+  type: "book"
+  title: "The TeXbook"
+  author:
+    - "Knuth, Donald E."
+  date: "1984"
+  serial-number:
+    isbn: "978-0-201-13447-6"
+  publisher: "Addison-Wesley"
+
+"Doe_2001":
+  type: "article"
+  title: "The \"best\" paper"
+  serial-number:
+    arxiv: "2101.01234"
+  parent:
+    type: "proceedings"
+    title: "Proc. Conf."
+`, b.String())
+
+	_, err = Parse(b.Bytes(), Hayagriva)
+	assert.NotNil(err)
+}
+
+func TestDetect(t *testing.T) {
+	assert := assert.New(t)
+	var tests = []struct {
+		Name string
+		Data string
+		Out  Format
+	}{
+		{"refs.bib", "", BibTeX},
+		{"refs.RIS", "", RIS},
+		{"refs.json", "", CSLJSON},
+		{"refs.yaml", "", Hayagriva},
+		{"refs.txt", "\ufeff\n[{}]", CSLJSON},
+		{"refs.txt", "TY  - JOUR\r\nER  - \r\n", RIS},
+		{"refs.txt", "% comment\n@misc{k}", BibTeX},
+	}
+	for _, tt := range tests {
+		f, err := Detect(tt.Name, []byte(tt.Data))
+		assert.Nil(err, tt.Name)
+		assert.Equal(tt.Out, f, tt.Name)
+	}
+	_, err := Detect("refs.txt", []byte("text"))
+	assert.NotNil(err)
+}
+
+func TestUniqueKeys(t *testing.T) {
+	assert := assert.New(t)
+	es := []Entry{
+		{Key: "a"},
+		{Fields: []Field{{Name: "author", Value: `M{\"u}ller, J.`}, {Name: "year", Value: "2001"}}},
+		{Key: "Müller_2001"},
+		{Key: "a"},
+		{Fields: []Field{{Name: "doi", Value: "10.1000/182"}}},
+	}
+	UniqueKeys(es)
+	var keys []string
+	for _, e := range es {
+		keys = append(keys, e.Key)
+	}
+	assert.Equal([]string{"aa", "Müller_2001a", "Müller_2001b", "ab", "10_1000_182"}, keys)
+}
@@ -0,0 +1,140 @@
+package bib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// hayagrivaTypes maps BibTeX entry types to Hayagriva entry types, and the
+// types of their parents, if any. Other entry types are mapped to 'misc'.
+var hayagrivaTypes = map[string][2]string{
+	"article":       {"article", "periodical"},
+	"inproceedings": {"article", "proceedings"},
+	"conference":    {"article", "proceedings"},
+	"incollection":  {"chapter", "anthology"},
+	"inbook":        {"chapter", "book"},
+	"book":          {"book", ""},
+	"proceedings":   {"proceedings", ""},
+	"phdthesis":     {"thesis", ""},
+	"mastersthesis": {"thesis", ""},
+	"thesis":        {"thesis", ""},
+	"techreport":    {"report", ""},
+	"report":        {"report", ""},
+	"unpublished":   {"manuscript", ""},
+	"online":        {"web", ""},
+}
+
+// WriteHayagriva writes the entries to w as a Hayagriva (YAML)
+// bibliography, as used by Typst. Only commonly used fields are written.
+func WriteHayagriva(w io.Writer, entries []Entry) error {
+	var b bytes.Buffer
+	for i := range entries {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		writeHayagriva(&b, &entries[i])
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// writeHayagriva writes the entry to b as a Hayagriva entry.
+func writeHayagriva(b *bytes.Buffer, e *Entry) {
+	get := func(name string) string {
+		return strings.TrimSpace(Plain(e.Get(name)))
+	}
+	field := func(indent, name, value string) {
+		if len(value) != 0 {
+			fmt.Fprintf(b, "%v%v: %v\n", indent, name, yamlString(value))
+		}
+	}
+	names := func(indent, name string, ns []Name) {
+		if len(ns) == 0 {
+			return
+		}
+		fmt.Fprintf(b, "%v%v:\n", indent, name)
+		for _, n := range ns {
+			s := n.Literal
+			if len(n.Family) != 0 {
+				s = n.Family
+				if len(n.Given) != 0 {
+					s += ", " + n.Given
+				}
+			}
+			fmt.Fprintf(b, "%v  - %v\n", indent, yamlString(s))
+		}
+	}
+
+	typ := hayagrivaTypes[e.Type]
+	if len(typ[0]) == 0 {
+		typ[0] = "misc"
+	}
+	fmt.Fprintf(b, "%v:\n", yamlString(e.Key))
+	field("  ", "type", typ[0])
+	field("  ", "title", get("title"))
+	names("  ", "author", ParseNames(e.Get("author")))
+	if y := Year(e); y > 0 {
+		date := fmt.Sprintf("%04d", y)
+		if m := month(e.Get("month")); m > 0 {
+			date += fmt.Sprintf("-%02d", m)
+		}
+		field("  ", "date", date)
+	}
+	field("  ", "page-range", strings.ReplaceAll(get("pages"), "–", "-"))
+	field("  ", "url", get("url"))
+	field("  ", "note", get("note"))
+
+	serials := [][2]string{
+		{"doi", get("doi")},
+		{"isbn", get("isbn")},
+		{"issn", get("issn")},
+		{"arxiv", e.ArXiv()},
+	}
+	n := 0
+	for _, s := range serials {
+		if len(s[1]) != 0 {
+			if n == 0 {
+				b.WriteString("  serial-number:\n")
+			}
+			field("    ", s[0], s[1])
+			n++
+		}
+	}
+
+	// the container (journal, proceedings or book), its editors and
+	// publisher are written as the parent, if the entry type has one
+	indent := "  "
+	if len(typ[1]) != 0 {
+		b.WriteString("  parent:\n")
+		indent = "    "
+		field(indent, "type", typ[1])
+		container := get("journal")
+		if len(container) == 0 {
+			container = get("booktitle")
+		}
+		field(indent, "title", container)
+	}
+	names(indent, "editor", ParseNames(e.Get("editor")))
+	field(indent, "volume", get("volume"))
+	field(indent, "issue", get("number"))
+	publisher := get("publisher")
+	for _, name := range []string{"school", "institution"} {
+		if len(publisher) == 0 {
+			publisher = get(name)
+		}
+	}
+	field(indent, "publisher", publisher)
+	field(indent, "location", get("address"))
+}
+
+// yamlString returns s as a double-quoted YAML string.
+func yamlString(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s) // strings are always encoded
+	return strings.TrimSuffix(b.String(), "\n")
+}
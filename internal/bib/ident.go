@@ -0,0 +1,55 @@
+package bib
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/Milover/fetchref/internal/arxiv"
+	"github.com/Milover/fetchref/internal/doi"
+	"github.com/Milover/fetchref/internal/isbn"
+)
+
+// DOI returns the DOI of the entry, from its 'doi' field, or its 'url'
+// field, if it is a DOI resolver URL, or an empty string.
+func (e *Entry) DOI() string {
+	for _, name := range []string{"doi", "url"} {
+		if d := doi.Clean(Plain(e.Get(name))); doi.IsValid(d) {
+			return d
+		}
+	}
+	return ""
+}
+
+// ISBN returns the first valid ISBN of the entry, or an empty string.
+func (e *Entry) ISBN() string {
+	for _, n := range strings.FieldsFunc(e.Get("isbn"), func(r rune) bool {
+		return r == ',' || r == ';' || r == ' '
+	}) {
+		if isbn.IsValid(n) {
+			return n
+		}
+	}
+	return ""
+}
+
+// ArXiv returns the arXiv identifier of the entry, from its 'eprint' field
+// (see the 'archiveprefix' field), or its 'url' field, if it is an arXiv
+// URL, or an empty string.
+func (e *Entry) ArXiv() string {
+	prefix := strings.ToLower(Plain(e.Get("archiveprefix") + e.Get("eprinttype")))
+	if id := arxiv.Clean(e.Get("eprint")); (len(prefix) == 0 || prefix == "arxiv") && arxiv.IsValid(id) {
+		return id
+	}
+	u, err := url.Parse(e.Get("url"))
+	if err != nil || !strings.HasSuffix(u.Hostname(), "arxiv.org") {
+		return ""
+	}
+	for _, p := range []string{"/abs/", "/pdf/"} {
+		if strings.HasPrefix(u.Path, p) {
+			if id := strings.TrimSuffix(u.Path[len(p):], ".pdf"); arxiv.IsValid(id) {
+				return id
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,169 @@
+package bib
+
+import (
+	"strings"
+	"unicode"
+)
+
+// accents maps (La)TeX accent commands to Unicode combining characters.
+var accents = map[string]rune{
+	"`": '̀', "'": '́', "^": '̂', "~": '̃',
+	"=": '̄', "u": '̆', ".": '̇', "\"": '̈',
+	"r": '̊', "H": '̋', "v": '̌', "c": '̧',
+	"k": '̨', "d": '̣', "b": '̱',
+}
+
+// symbols maps (La)TeX symbol commands to Unicode text.
+var symbols = map[string]string{
+	"ss": "ß", "o": "ø", "O": "Ø", "ae": "æ", "AE": "Æ", "oe": "œ",
+	"OE": "Œ", "aa": "å", "AA": "Å", "l": "ł", "L": "Ł", "i": "ı", "j": "ȷ",
+	"&": "&", "%": "%", "_": "_", "#": "#", "$": "$", "{": "{", "}": "}",
+	" ": " ", "textendash": "–", "textemdash": "—", "textquoteright": "’",
+	"textquoteleft": "‘", "ldots": "…", "dots": "…", "textdegree": "°",
+}
+
+// composed maps accent commands followed by a letter to the precomposed
+// accented letter, e.g., '"o' to 'ö'.
+var composed = map[string]rune{
+	"`A": 'À', "'A": 'Á', "^A": 'Â', "~A": 'Ã', "\"A": 'Ä', "rA": 'Å',
+	"cC": 'Ç', "`E": 'È', "'E": 'É', "^E": 'Ê', "\"E": 'Ë', "`I": 'Ì',
+	"'I": 'Í', "^I": 'Î', "\"I": 'Ï', "~N": 'Ñ', "`O": 'Ò', "'O": 'Ó',
+	"^O": 'Ô', "~O": 'Õ', "\"O": 'Ö', "`U": 'Ù', "'U": 'Ú', "^U": 'Û',
+	"\"U": 'Ü', "'Y": 'Ý', "`a": 'à', "'a": 'á', "^a": 'â', "~a": 'ã',
+	"\"a": 'ä', "ra": 'å', "cc": 'ç', "`e": 'è', "'e": 'é', "^e": 'ê',
+	"\"e": 'ë', "`i": 'ì', "'i": 'í', "^i": 'î', "\"i": 'ï', "~n": 'ñ',
+	"`o": 'ò', "'o": 'ó', "^o": 'ô', "~o": 'õ', "\"o": 'ö', "`u": 'ù',
+	"'u": 'ú', "^u": 'û', "\"u": 'ü', "'y": 'ý', "\"y": 'ÿ', "=A": 'Ā',
+	"=a": 'ā', "uA": 'Ă', "ua": 'ă', "kA": 'Ą', "ka": 'ą', "'C": 'Ć',
+	"'c": 'ć', "^C": 'Ĉ', "^c": 'ĉ', ".C": 'Ċ', ".c": 'ċ', "vC": 'Č',
+	"vc": 'č', "vD": 'Ď', "vd": 'ď', "=E": 'Ē', "=e": 'ē', "uE": 'Ĕ',
+	"ue": 'ĕ', ".E": 'Ė', ".e": 'ė', "kE": 'Ę', "ke": 'ę', "vE": 'Ě',
+	"ve": 'ě', "^G": 'Ĝ', "^g": 'ĝ', "uG": 'Ğ', "ug": 'ğ', ".G": 'Ġ',
+	".g": 'ġ', "cG": 'Ģ', "cg": 'ģ', "^H": 'Ĥ', "^h": 'ĥ', "~I": 'Ĩ',
+	"~i": 'ĩ', "=I": 'Ī', "=i": 'ī', "uI": 'Ĭ', "ui": 'ĭ', "kI": 'Į',
+	"ki": 'į', ".I": 'İ', "^J": 'Ĵ', "^j": 'ĵ', "cK": 'Ķ', "ck": 'ķ',
+	"'L": 'Ĺ', "'l": 'ĺ', "cL": 'Ļ', "cl": 'ļ', "vL": 'Ľ', "vl": 'ľ',
+	"'N": 'Ń', "'n": 'ń', "cN": 'Ņ', "cn": 'ņ', "vN": 'Ň', "vn": 'ň',
+	"=O": 'Ō', "=o": 'ō', "uO": 'Ŏ', "uo": 'ŏ', "HO": 'Ő', "Ho": 'ő',
+	"'R": 'Ŕ', "'r": 'ŕ', "cR": 'Ŗ', "cr": 'ŗ', "vR": 'Ř', "vr": 'ř',
+	"'S": 'Ś', "'s": 'ś', "^S": 'Ŝ', "^s": 'ŝ', "cS": 'Ş', "cs": 'ş',
+	"vS": 'Š', "vs": 'š', "cT": 'Ţ', "ct": 'ţ', "vT": 'Ť', "vt": 'ť',
+	"~U": 'Ũ', "~u": 'ũ', "=U": 'Ū', "=u": 'ū', "uU": 'Ŭ', "uu": 'ŭ',
+	"rU": 'Ů', "ru": 'ů', "HU": 'Ű', "Hu": 'ű', "kU": 'Ų', "ku": 'ų',
+	"^W": 'Ŵ', "^w": 'ŵ', "^Y": 'Ŷ', "^y": 'ŷ', "\"Y": 'Ÿ', "'Z": 'Ź',
+	"'z": 'ź', ".Z": 'Ż', ".z": 'ż', "vZ": 'Ž', "vz": 'ž', ".B": 'Ḃ',
+	".b": 'ḃ', "dB": 'Ḅ', "db": 'ḅ', "bB": 'Ḇ', "bb": 'ḇ', ".D": 'Ḋ',
+	".d": 'ḋ', "dD": 'Ḍ', "dd": 'ḍ', "bD": 'Ḏ', "bd": 'ḏ', "cD": 'Ḑ',
+	"cd": 'ḑ', ".F": 'Ḟ', ".f": 'ḟ', "=G": 'Ḡ', "=g": 'ḡ', ".H": 'Ḣ',
+	".h": 'ḣ', "dH": 'Ḥ', "dh": 'ḥ', "\"H": 'Ḧ', "\"h": 'ḧ', "cH": 'Ḩ',
+	"ch": 'ḩ', "'K": 'Ḱ', "'k": 'ḱ', "dK": 'Ḳ', "dk": 'ḳ', "bK": 'Ḵ',
+	"bk": 'ḵ', "dL": 'Ḷ', "dl": 'ḷ', "bL": 'Ḻ', "bl": 'ḻ', "'M": 'Ḿ',
+	"'m": 'ḿ', ".M": 'Ṁ', ".m": 'ṁ', "dM": 'Ṃ', "dm": 'ṃ', ".N": 'Ṅ',
+	".n": 'ṅ', "dN": 'Ṇ', "dn": 'ṇ', "bN": 'Ṉ', "bn": 'ṉ', "'P": 'Ṕ',
+	"'p": 'ṕ', ".P": 'Ṗ', ".p": 'ṗ', ".R": 'Ṙ', ".r": 'ṙ', "dR": 'Ṛ',
+	"dr": 'ṛ', "bR": 'Ṟ', "br": 'ṟ', ".S": 'Ṡ', ".s": 'ṡ', "dS": 'Ṣ',
+	"ds": 'ṣ', ".T": 'Ṫ', ".t": 'ṫ', "dT": 'Ṭ', "dt": 'ṭ', "bT": 'Ṯ',
+	"bt": 'ṯ', "~V": 'Ṽ', "~v": 'ṽ', "dV": 'Ṿ', "dv": 'ṿ', "`W": 'Ẁ',
+	"`w": 'ẁ', "'W": 'Ẃ', "'w": 'ẃ', "\"W": 'Ẅ', "\"w": 'ẅ', ".W": 'Ẇ',
+	".w": 'ẇ', "dW": 'Ẉ', "dw": 'ẉ', ".X": 'Ẋ', ".x": 'ẋ', "\"X": 'Ẍ',
+	"\"x": 'ẍ', ".Y": 'Ẏ', ".y": 'ẏ', "^Z": 'Ẑ', "^z": 'ẑ', "dZ": 'Ẓ',
+	"dz": 'ẓ', "bZ": 'Ẕ', "bz": 'ẕ', "bh": 'ẖ', "\"t": 'ẗ', "rw": 'ẘ',
+	"ry": 'ẙ', "dA": 'Ạ', "da": 'ạ', "dE": 'Ẹ', "de": 'ẹ', "~E": 'Ẽ',
+	"~e": 'ẽ', "dI": 'Ị', "di": 'ị', "dO": 'Ọ', "do": 'ọ', "dU": 'Ụ',
+	"du": 'ụ', "`Y": 'Ỳ', "`y": 'ỳ', "dY": 'Ỵ', "dy": 'ỵ', "~Y": 'Ỹ',
+	"~y": 'ỹ',
+}
+
+// Plain converts (La)TeX markup to plain (Unicode) text, i.e., accent
+// and symbol commands are replaced by the characters they represent,
+// other commands (e.g., '\textit') and braces are removed, and dashes
+// ('--', '---') and ties ('~') are replaced.
+func Plain(s string) string {
+	if !strings.ContainsAny(s, "\\{}~-") {
+		return s
+	}
+	var b strings.Builder
+	rs := []rune(s)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case r == '{' || r == '}':
+		case r == '~':
+			b.WriteRune(' ')
+		case r == '-' && i+2 < len(rs) && rs[i+1] == '-' && rs[i+2] == '-':
+			b.WriteRune('—')
+			i += 2
+		case r == '-' && i+1 < len(rs) && rs[i+1] == '-':
+			b.WriteRune('–')
+			i++
+		case r == '\\' && i+1 < len(rs):
+			i = command(&b, rs, i)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// command writes the plain text of the command starting at rs[i], and
+// returns the position of its last character.
+func command(b *strings.Builder, rs []rune, i int) int {
+	// the command name is either a single non-letter, or letters
+	j := i + 1
+	if unicode.IsLetter(rs[j]) {
+		for j < len(rs) && unicode.IsLetter(rs[j]) {
+			j++
+		}
+	} else {
+		j++
+	}
+	name := string(rs[i+1 : j])
+	if acc, found := accents[name]; found {
+		if k, ok := accented(b, rs, j, name, acc); ok {
+			return k
+		}
+	}
+	if sym, found := symbols[name]; found {
+		b.WriteString(sym)
+	}
+	// a space terminating a command name is not part of the text
+	if unicode.IsLetter(rs[j-1]) && j < len(rs) && rs[j] == ' ' {
+		return j
+	}
+	return j - 1
+}
+
+// accented writes the letter following the accent command, which ends
+// at rs[j], e.g., '\"o', '\"{o}', '\c c' or '\'{\i}', with the accent, and
+// returns the position of the last character of the letter.
+func accented(b *strings.Builder, rs []rune, j int, name string, acc rune) (int, bool) {
+	k := j
+	for k < len(rs) && rs[k] == ' ' {
+		k++
+	}
+	braced := k < len(rs) && rs[k] == '{'
+	if braced {
+		k++
+	}
+	var base rune
+	switch {
+	case k+1 < len(rs) && rs[k] == '\\' && (rs[k+1] == 'i' || rs[k+1] == 'j'):
+		base = rs[k+1] // dotless i or j
+		k += 2
+	case k < len(rs) && unicode.IsLetter(rs[k]):
+		base = rs[k]
+		k++
+	default:
+		return 0, false
+	}
+	if braced && k < len(rs) && rs[k] == '}' {
+		k++
+	}
+	if c, found := composed[name+string(base)]; found {
+		b.WriteRune(c)
+	} else {
+		b.WriteRune(base)
+		b.WriteRune(acc)
+	}
+	return k - 1, true
+}
@@ -0,0 +1,57 @@
+package bib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlain(t *testing.T) {
+	assert := assert.New(t)
+	var tests = []struct {
+		In  string
+		Out string
+	}{
+		{"plain", "plain"},
+		{`{\"O}zt{\"u}rk`, "Öztürk"},
+		{`\'{E}cole`, "École"},
+		{`Erd\H{o}s`, "Erdős"},
+		{`Pe\~na`, "Peña"},
+		{`\c c\'{\i}`, "çí"},
+		{`{\ss} and \o`, "ß and ø"},
+		{`\textit{In situ} 5\% x\_1`, "In situ 5% x_1"},
+		{`12--19 --- a~b`, "12–19 — a b"},
+		{`\AA ngstr\"om`, "Ångström"},
+		{`\v{z}\k{a}`, "žą"},
+	}
+	for _, tt := range tests {
+		assert.Equal(tt.Out, Plain(tt.In), tt.In)
+	}
+}
+
+func TestParseNames(t *testing.T) {
+	assert := assert.New(t)
+	var tests = []struct {
+		In  string
+		Out []Name
+	}{
+		{"Einstein, Albert", []Name{{Family: "Einstein", Given: "Albert"}}},
+		{"Albert Einstein", []Name{{Family: "Einstein", Given: "Albert"}}},
+		{"Ludwig van Beethoven", []Name{{Family: "van Beethoven", Given: "Ludwig"}}},
+		{"{LIGO Scientific Collaboration}", []Name{{Literal: "LIGO Scientific Collaboration"}}},
+		{"King, Jr, Martin Luther", []Name{{Family: "King", Given: "Martin Luther"}}},
+		{"{\\\"O}zt{\\\"u}rk, Ali AND Doe,\n  J.", []Name{
+			{Family: "Öztürk", Given: "Ali"},
+			{Family: "Doe", Given: "J."},
+		}},
+		{"{Barnes and Noble}", []Name{{Literal: "Barnes and Noble"}}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		assert.Equal(tt.Out, ParseNames(tt.In), tt.In)
+	}
+	assert.Equal("Einstein, Albert and {LIGO}", FormatNames([]Name{
+		{Family: "Einstein", Given: "Albert"},
+		{Literal: "LIGO"},
+	}))
+}
@@ -0,0 +1,363 @@
+package bib
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Milover/fetchref/internal/doi"
+	"github.com/Milover/fetchref/internal/isbn"
+)
+
+// Level is the severity level of a lint issue.
+type Level int
+
+const (
+	LevelNote Level = iota
+	LevelWarning
+	LevelError
+)
+
+var (
+	// levelNames are the user-friendly Level names, which are also
+	// SARIF result levels.
+	levelNames = [...]string{
+		"note",
+		"warning",
+		"error",
+	}
+)
+
+// Set sets the level from its name.
+func (l *Level) Set(name string) error {
+	for i, n := range levelNames {
+		if strings.EqualFold(name, n) {
+			*l = Level(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown level: %v, expected one of: %v",
+		name, strings.Join(levelNames[:], ", "))
+}
+
+// String returns the level name.
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// Type returns the level type name.
+func (l Level) Type() string {
+	return "string"
+}
+
+// MarshalText marshals the level as its name.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText unmarshals the level from its name.
+func (l *Level) UnmarshalText(text []byte) error {
+	return l.Set(string(text))
+}
+
+// Lint rules.
+const (
+	RuleDuplicateKey    = "duplicate-key"
+	RuleDuplicateDOI    = "duplicate-doi"
+	RuleMissingField    = "missing-field"
+	RuleUnprotectedCap  = "unprotected-caps"
+	RuleInvalidDOI      = "invalid-doi"
+	RuleInvalidISBN     = "invalid-isbn"
+	RulePageRange       = "page-range"
+	RuleJournalName     = "journal-name"
+	RuleRetracted       = "retracted"
+	RuleRetractionCheck = "retraction-check"
+)
+
+// Rules describes the lint rules.
+var Rules = map[string]string{
+	RuleDuplicateKey:    "Citation keys are unique.",
+	RuleDuplicateDOI:    "Each DOI is cited by a single entry.",
+	RuleMissingField:    "Entries have the fields required by their entry type.",
+	RuleUnprotectedCap:  "Capitals within title words, e.g., acronyms, are protected by braces.",
+	RuleInvalidDOI:      "DOIs are syntactically valid.",
+	RuleInvalidISBN:     "ISBNs have valid check digits.",
+	RulePageRange:       "Page ranges are well formed, i.e., 'first--last'.",
+	RuleJournalName:     "Journals are named consistently.",
+	RuleRetracted:       "Cited works have not been retracted.",
+	RuleRetractionCheck: "Cited works could be checked for retractions.",
+}
+
+// Issue is a lint issue of an entry.
+type Issue struct {
+	Rule    string `json:"rule"`
+	Level   Level  `json:"level"`
+	Key     string `json:"key"`
+	Field   string `json:"field,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// String formats the issue, e.g., '12: warning: Abbott_2016: missing
+// field journal [missing-field]'.
+func (is Issue) String() string {
+	return fmt.Sprintf("%v: %v: %v: %v [%v]", is.Line, is.Level, is.Key, is.Message, is.Rule)
+}
+
+// required are the fields required by BibTeX entry types. Alternatives are
+// separated by '|'.
+var required = map[string][]string{
+	"article":       {"author", "title", "journal", "year"},
+	"book":          {"author|editor", "title", "publisher", "year"},
+	"booklet":       {"title"},
+	"inbook":        {"author|editor", "title", "chapter|pages", "publisher", "year"},
+	"incollection":  {"author", "title", "booktitle", "publisher", "year"},
+	"inproceedings": {"author", "title", "booktitle", "year"},
+	"conference":    {"author", "title", "booktitle", "year"},
+	"manual":        {"title"},
+	"mastersthesis": {"author", "title", "school", "year"},
+	"phdthesis":     {"author", "title", "school", "year"},
+	"proceedings":   {"title", "year"},
+	"techreport":    {"author", "title", "institution", "year"},
+	"unpublished":   {"author", "title", "note"},
+}
+
+// pageRange matches a well formed page, or page range, e.g., '12',
+// '12--19', 'e1234', 'S12--S19', 'xi--xv' or '12+'.
+var pageRange = regexp.MustCompile(`^([A-Za-z]{0,2}[0-9]+[A-Za-z]?(--[A-Za-z]{0,2}[0-9]+[A-Za-z]?|\+)?|[ivxlcdm]+(--[ivxlcdm]+)?)$`)
+
+// Lint checks the entries for issues which can be found offline, i.e.,
+// all but retracted works, and returns the issues in order of the entries.
+func Lint(entries []*Entry) []Issue {
+	var issues []Issue
+	add := func(rule string, l Level, e *Entry, field string, format string, args ...interface{}) {
+		is := Issue{
+			Rule:    rule,
+			Level:   l,
+			Key:     e.Key,
+			Field:   field,
+			Line:    e.Line(),
+			Message: fmt.Sprintf(format, args...),
+		}
+		if f := e.Field(field); f != nil && f.Line() > 0 {
+			is.Line = f.Line()
+		}
+		issues = append(issues, is)
+	}
+
+	keys := make(map[string]*Entry)
+	dois := make(map[string]*Entry)
+	for _, e := range entries {
+		k := strings.ToLower(e.Key)
+		if first, found := keys[k]; found {
+			add(RuleDuplicateKey, LevelError, e, "",
+				"duplicate key, first used on line %v", first.Line())
+		} else {
+			keys[k] = e
+		}
+
+		if v := e.Get("doi"); len(v) != 0 {
+			d := doi.Clean(Plain(v))
+			if !doi.IsValid(d) {
+				add(RuleInvalidDOI, LevelError, e, "doi", "invalid DOI %q", v)
+			} else if first, found := dois[strings.ToLower(d)]; found {
+				add(RuleDuplicateDOI, LevelWarning, e, "doi",
+					"DOI %v is also cited by %v", d, first.Key)
+			} else {
+				dois[strings.ToLower(d)] = e
+			}
+		}
+		for _, n := range strings.FieldsFunc(e.Get("isbn"), func(r rune) bool {
+			return r == ',' || r == ';' || r == ' '
+		}) {
+			if !isbn.IsValid(n) {
+				add(RuleInvalidISBN, LevelError, e, "isbn", "invalid ISBN %q", n)
+			}
+		}
+
+		for _, req := range required[e.Type] {
+			if !hasAny(e, strings.Split(req, "|")) {
+				add(RuleMissingField, LevelWarning, e, "",
+					"missing field %v", strings.ReplaceAll(req, "|", " or "))
+			}
+		}
+
+		if v := e.Get("pages"); len(v) != 0 && !e.Field("pages").Macro {
+			for _, p := range strings.Split(v, ",") {
+				if p = strings.TrimSpace(p); !pageRange.MatchString(p) {
+					add(RulePageRange, LevelWarning, e, "pages",
+						"malformed page range %q, expected, e.g., %q", p, Pages(Plain(p)))
+					break
+				}
+				if first, last, found := strings.Cut(p, "--"); found {
+					a, errA := strconv.Atoi(first)
+					b, errB := strconv.Atoi(last)
+					if errA == nil && errB == nil && b < a {
+						add(RulePageRange, LevelWarning, e, "pages",
+							"page range %q ends before it starts", p)
+						break
+					}
+				}
+			}
+		}
+
+		for _, w := range unprotectedCaps(e.Get("title")) {
+			add(RuleUnprotectedCap, LevelNote, e, "title",
+				"capitalisation of %q is not protected, e.g., {%v}", w, w)
+		}
+	}
+	issues = append(issues, lintJournals(entries)...)
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Line < issues[j].Line
+	})
+	return issues
+}
+
+// hasAny reports whether the entry has any of the fields. The 'year'
+// field may also be given as 'date'.
+func hasAny(e *Entry, fields []string) bool {
+	for _, f := range fields {
+		if len(strings.TrimSpace(e.Get(f))) != 0 ||
+			(f == "year" && len(strings.TrimSpace(e.Get("date"))) != 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// unprotectedCaps returns the words of the title with capitals after their
+// first letter, e.g., 'DNA' or 'mRNA', which are not protected by braces.
+func unprotectedCaps(title string) []string {
+	var words []string
+	depth := 0
+	var word []rune
+	flush := func() {
+		for i, r := range word {
+			if i > 0 && unicode.IsUpper(r) {
+				words = append(words, string(word))
+				break
+			}
+		}
+		word = word[:0]
+	}
+	rs := []rune(title)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case r == '{':
+			flush()
+			depth++
+		case r == '}':
+			flush()
+			depth--
+		case r == '\\':
+			// skip command names, e.g., '\LaTeX'
+			flush()
+			for i+1 < len(rs) && unicode.IsLetter(rs[i+1]) {
+				i++
+			}
+		case depth == 0 && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return words
+}
+
+// journalStopWords are ignored when comparing journal names.
+var journalStopWords = map[string]bool{
+	"the": true, "of": true, "and": true, "in": true, "on": true, "for": true,
+	"a": true, "an": true, "&": true,
+}
+
+// journalWords returns the lower case words of a journal name, without
+// stop words.
+func journalWords(name string) []string {
+	var ws []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(Plain(name)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' && r != '&'
+	}) {
+		if w = strings.Trim(w, "."); len(w) != 0 && !journalStopWords[w] {
+			ws = append(ws, w)
+		}
+	}
+	return ws
+}
+
+// abbreviates reports whether the words of a journal name abbreviate the
+// words of another, i.e., each word is a prefix of the corresponding word,
+// e.g., 'Phys. Rev. Lett.' and 'Physical Review Letters'.
+func abbreviates(abbr, full []string) bool {
+	if len(abbr) != len(full) || len(abbr) == 0 {
+		return false
+	}
+	for i := range abbr {
+		if !strings.HasPrefix(full[i], abbr[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// lintJournals reports journals which are named differently by different
+// entries, i.e., names which differ by case or punctuation, or
+// abbreviations of other names.
+func lintJournals(entries []*Entry) []Issue {
+	type journal struct {
+		name  string
+		words []string
+		first *Entry
+		count int
+		index int
+	}
+	var journals []*journal
+	byName := make(map[string]*journal)
+	for _, e := range entries {
+		name := strings.Join(strings.Fields(e.Get("journal")), " ")
+		if len(name) == 0 {
+			continue
+		}
+		if j, found := byName[name]; found {
+			j.count++
+			continue
+		}
+		j := &journal{name: name, words: journalWords(name), first: e, count: 1, index: len(journals)}
+		byName[name] = j
+		journals = append(journals, j)
+	}
+	var issues []Issue
+	for _, e := range entries {
+		j := byName[strings.Join(strings.Fields(e.Get("journal")), " ")]
+		if j == nil {
+			continue
+		}
+		// report the less common name, and the first name, if equally common
+		for _, o := range journals {
+			if o == j || o.count < j.count || (o.count == j.count && o.index > j.index) {
+				continue
+			}
+			if strings.Join(o.words, " ") == strings.Join(j.words, " ") ||
+				abbreviates(j.words, o.words) || abbreviates(o.words, j.words) {
+				is := Issue{
+					Rule:    RuleJournalName,
+					Level:   LevelNote,
+					Key:     e.Key,
+					Field:   "journal",
+					Line:    e.Line(),
+					Message: fmt.Sprintf("journal %q is also named %q, e.g., by %v", j.name, o.name, o.first.Key),
+				}
+				if f := e.Field("journal"); f.Line() > 0 {
+					is.Line = f.Line()
+				}
+				issues = append(issues, is)
+				break
+			}
+		}
+	}
+	return issues
+}
@@ -0,0 +1,69 @@
+package bib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testLint = `@article{a,
+  title   = {{DNA} sequencing of mRNA in the iPhone era with \LaTeX},
+  author  = {Doe, J.},
+  journal = {Physical Review Letters},
+  year    = 2001,
+  pages   = {12-19},
+  doi     = {10.1000/182},
+}
+@article{b,
+  title   = {b},
+  author  = {Doe, J.},
+  journal = {Phys. Rev. Lett.},
+  year    = 2001,
+  pages   = {19--12},
+  doi     = {https://doi.org/10.1000/182},
+}
+@article{A,
+  title   = {c},
+  author  = {Doe, J.},
+  journal = {Physical Review Letters},
+  date    = {2001-02},
+  pages   = {e1234},
+  isbn    = {978-0-201-13447-7},
+  doi     = {182},
+}
+@book{d,
+  editor    = {Doe, J.},
+  title     = {d},
+  year      = {2001},
+  pages     = {xi--xv, 1--12},
+}
+`
+
+func TestLint(t *testing.T) {
+	assert := assert.New(t)
+	f, err := ParseBibTeX([]byte(testLint))
+	if !assert.Nil(err) {
+		return
+	}
+	var got []string
+	for _, is := range Lint(f.Entries()) {
+		got = append(got, is.String())
+	}
+	assert.Equal([]string{
+		`2: note: a: capitalisation of "mRNA" is not protected, e.g., {mRNA} [unprotected-caps]`,
+		`2: note: a: capitalisation of "iPhone" is not protected, e.g., {iPhone} [unprotected-caps]`,
+		`6: warning: a: malformed page range "12-19", expected, e.g., "12--19" [page-range]`,
+		`12: note: b: journal "Phys. Rev. Lett." is also named "Physical Review Letters", e.g., by a [journal-name]`,
+		`14: warning: b: page range "19--12" ends before it starts [page-range]`,
+		`15: warning: b: DOI 10.1000/182 is also cited by a [duplicate-doi]`,
+		`17: error: A: duplicate key, first used on line 1 [duplicate-key]`,
+		`23: error: A: invalid ISBN "978-0-201-13447-7" [invalid-isbn]`,
+		`24: error: A: invalid DOI "182" [invalid-doi]`,
+		`26: warning: d: missing field publisher [missing-field]`,
+	}, got)
+
+	var l Level
+	assert.Nil(l.Set("Warning"))
+	assert.Equal(LevelWarning, l)
+	assert.NotNil(l.Set("fatal"))
+}
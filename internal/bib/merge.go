@@ -0,0 +1,195 @@
+package bib
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Milover/fetchref/internal/doi"
+)
+
+// Policy is a policy for merging metadata into an entry.
+type Policy int
+
+const (
+	// Fill only adds fields which are missing from the entry.
+	Fill Policy = iota
+	// Overwrite also replaces the values of fields which diverge from
+	// the metadata.
+	Overwrite
+)
+
+var (
+	// policyNames are the user-friendly Policy names.
+	policyNames = [...]string{
+		"fill",
+		"overwrite",
+	}
+)
+
+// Set sets the policy from its name.
+func (p *Policy) Set(name string) error {
+	for i, n := range policyNames {
+		if strings.EqualFold(name, n) {
+			*p = Policy(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown merge policy: %v, expected one of: %v",
+		name, strings.Join(policyNames[:], ", "))
+}
+
+// String returns the policy name.
+func (p Policy) String() string {
+	return policyNames[p]
+}
+
+// Type returns the policy type name.
+func (p Policy) Type() string {
+	return "string"
+}
+
+// Change is a change of a field of an entry. Old is empty if the field
+// was added, and New is empty if the field was removed.
+type Change struct {
+	Field string
+	Old   Field
+	New   Field
+}
+
+// String formats the change as a diff, e.g., '+ pages = {12--19}'.
+func (c Change) String() string {
+	var ss []string
+	if len(c.Old.Value) != 0 {
+		ss = append(ss, "- "+c.Field+" = "+c.Old.format())
+	}
+	if len(c.New.Value) != 0 {
+		ss = append(ss, "+ "+c.Field+" = "+c.New.format())
+	}
+	return strings.Join(ss, "\n")
+}
+
+// Merge merges the fields of src into the entry according to the policy,
+// and returns the changes. Values are compared as plain text, ignoring
+// markup, white space and punctuation (see Same), so that only fields
+// whose content diverges are replaced. If fields are given, only these
+// fields (and 'type') are overwritten. The citation key of the entry is
+// not changed, while its type is only changed from 'misc', or when
+// overwriting.
+func (e *Entry) Merge(src Entry, p Policy, fields ...string) []Change {
+	overwrite := func(name string) bool {
+		if p != Overwrite {
+			return false
+		}
+		for _, f := range fields {
+			if strings.EqualFold(f, name) {
+				return true
+			}
+		}
+		return len(fields) == 0
+	}
+	var cs []Change
+	if len(src.Type) != 0 && e.Type != src.Type && src.Type != "misc" &&
+		(e.Type == "misc" || overwrite("type")) {
+		cs = append(cs, Change{
+			Field: "type",
+			Old:   Field{Value: e.Type, Macro: true},
+			New:   Field{Value: src.Type, Macro: true},
+		})
+		e.Type = src.Type
+	}
+	for _, sf := range src.Fields {
+		if len(strings.TrimSpace(sf.Value)) == 0 {
+			continue
+		}
+		f := e.Field(sf.Name)
+		switch {
+		case f == nil || len(strings.TrimSpace(f.Value)) == 0:
+			old := Field{}
+			if f != nil {
+				old = *f
+			}
+			e.set(sf.Name, sf.Value, sf.Macro)
+			cs = append(cs, Change{Field: sf.Name, Old: old, New: sf})
+		case overwrite(sf.Name) && !Same(sf.Name, f.Value, sf.Value):
+			old := *f
+			f.Value, f.Macro = sf.Value, sf.Macro
+			cs = append(cs, Change{Field: sf.Name, Old: old, New: sf})
+		}
+	}
+	return cs
+}
+
+// Same reports whether the values of the field are the same, i.e., equal
+// as plain text, ignoring white space and punctuation. Names are
+// compared by family names and initials, months by number and DOIs as
+// DOIs.
+func Same(name, a, b string) bool {
+	switch strings.ToLower(name) {
+	case "author", "editor":
+		na, nb := ParseNames(a), ParseNames(b)
+		if len(na) != len(nb) {
+			return false
+		}
+		for i := range na {
+			if normalize(na[i].Family+na[i].Literal) != normalize(nb[i].Family+nb[i].Literal) ||
+				initials(na[i].Given) != initials(nb[i].Given) {
+				return false
+			}
+		}
+		return true
+	case "month":
+		return month(a) == month(b)
+	case "doi":
+		return doi.Equal(a, b)
+	}
+	return normalize(Plain(a)) == normalize(Plain(b))
+}
+
+// normalize returns the letters and digits of s.
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// initials returns the initials of the given names, in lower case.
+func initials(given string) string {
+	var b strings.Builder
+	for _, w := range strings.FieldsFunc(given, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '.' || r == '-'
+	}) {
+		b.WriteRune(unicode.ToLower([]rune(w)[0]))
+	}
+	return b.String()
+}
+
+// Clean tidies up the entry, i.e., white space in values is collapsed,
+// empty fields are removed, DOIs are stripped of resolver prefixes, page
+// ranges are separated by an en dash and months are written as macros.
+func (e *Entry) Clean() {
+	fs := e.Fields[:0]
+	for _, f := range e.Fields {
+		f.Value = strings.Join(strings.Fields(f.Value), " ")
+		if len(f.Value) == 0 {
+			continue
+		}
+		switch f.Name {
+		case "doi":
+			f.Value = doi.Clean(f.Value)
+		case "pages":
+			if !f.Macro {
+				f.Value = Pages(Plain(f.Value))
+			}
+		case "month":
+			if m := month(f.Value); m > 0 {
+				f.Value, f.Macro = months[m-1], true
+			}
+		}
+		fs = append(fs, f)
+	}
+	e.Fields = fs
+}
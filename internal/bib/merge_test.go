@@ -0,0 +1,82 @@
+package bib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	assert := assert.New(t)
+	entry := func() Entry {
+		return Entry{Type: "misc", Key: "key", Fields: []Field{
+			{Name: "title", Value: "{O}bservation of gravitational waves"},
+			{Name: "author", Value: `Abbott, Benjamin P. and {LIGO Scientific Collaboration}`},
+			{Name: "journal", Value: "Phys. Rev. Lett."},
+			{Name: "month", Value: "February"},
+			{Name: "doi", Value: "https://doi.org/10.1103/PHYSREVLETT.116.061102"},
+		}}
+	}
+	src := Entry{Type: "article", Key: "Abbott_2016", Fields: []Field{
+		{Name: "title", Value: "Observation of Gravitational Waves"},
+		{Name: "author", Value: "Abbott, B. P. and {LIGO Scientific Collaboration}"},
+		{Name: "journal", Value: "Physical Review Letters"},
+		{Name: "month", Value: "feb", Macro: true},
+		{Name: "pages", Value: "061102"},
+		{Name: "doi", Value: "10.1103/physrevlett.116.061102"},
+	}}
+
+	e := entry()
+	cs := e.Merge(src, Fill)
+	assert.Equal("key", e.Key)
+	assert.Equal("article", e.Type)
+	if assert.Len(cs, 2) {
+		assert.Equal("- type = misc\n+ type = article", cs[0].String())
+		assert.Equal("+ pages = {061102}", cs[1].String())
+	}
+
+	e = entry()
+	cs = e.Merge(src, Overwrite)
+	var fields []string
+	for _, c := range cs {
+		fields = append(fields, c.Field)
+	}
+	assert.Equal([]string{"type", "title", "journal", "pages"}, fields)
+	assert.Equal("Observation of Gravitational Waves", e.Get("title"))
+	assert.Equal("February", e.Get("month"))
+
+	e = entry()
+	cs = e.Merge(src, Overwrite, "Journal")
+	fields = nil
+	for _, c := range cs {
+		fields = append(fields, c.Field)
+	}
+	assert.Equal([]string{"type", "journal", "pages"}, fields)
+	assert.Equal("{O}bservation of gravitational waves", e.Get("title"))
+
+	e = entry()
+	e.Set("pages", "12 - 19")
+	e.Set("note", "  ")
+	e.Clean()
+	assert.Equal("10.1103/PHYSREVLETT.116.061102", e.Get("doi"))
+	assert.Equal("12--19", e.Get("pages"))
+	assert.Equal("feb", e.Get("month"))
+	assert.False(e.Has("note"))
+}
+
+func TestIdentifiers(t *testing.T) {
+	assert := assert.New(t)
+	e := Entry{Fields: []Field{
+		{Name: "url", Value: "https://arxiv.org/abs/1602.03837v1"},
+		{Name: "isbn", Value: "0-201-13447-0, 978-0-201-13447-6"},
+	}}
+	assert.Equal("", e.DOI())
+	assert.Equal("1602.03837v1", e.ArXiv())
+	assert.Equal("0-201-13447-0", e.ISBN())
+
+	e.Set("url", "https://doi.org/10.1103/PhysRevLett.116.061102")
+	e.Set("eprint", "arXiv:1602.03837")
+	e.Set("archiveprefix", "arXiv")
+	assert.Equal("10.1103/PhysRevLett.116.061102", e.DOI())
+	assert.Equal("1602.03837", e.ArXiv())
+}
@@ -0,0 +1,127 @@
+package bib
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Name is a personal name, or the name of an organization (Literal).
+type Name struct {
+	Family  string
+	Given   string
+	Literal string
+}
+
+// String formats the name as in a BibTeX name list, e.g., 'Einstein,
+// Albert' or '{LIGO Scientific Collaboration}'.
+func (n Name) String() string {
+	switch {
+	case len(n.Literal) != 0:
+		return "{" + n.Literal + "}"
+	case len(n.Given) != 0:
+		return n.Family + ", " + n.Given
+	}
+	return n.Family
+}
+
+// ParseNames parses a BibTeX name list, e.g., 'Einstein, Albert and Max
+// Planck and {LIGO Scientific Collaboration}'. Names are returned as plain
+// text, see Plain.
+func ParseNames(s string) []Name {
+	var names []Name
+	s = strings.Join(strings.Fields(s), " ")
+	for _, n := range splitTop(s, " and ") {
+		if n = strings.TrimSpace(n); len(n) != 0 {
+			names = append(names, ParseName(n))
+		}
+	}
+	return names
+}
+
+// ParseName parses a single BibTeX name, i.e., 'Family, Given', 'Given
+// Family' or 'Given von Family', where 'von' is any number of lower case
+// words, or '{Organization}'.
+func ParseName(s string) Name {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "{") && matchingBrace(s) == len(s)-1 {
+		return Name{Literal: Plain(s[1 : len(s)-1])}
+	}
+	if parts := splitTop(s, ","); len(parts) > 1 {
+		// 'Family, Given' or 'Family, Jr, Given'
+		return Name{
+			Family: Plain(strings.TrimSpace(parts[0])),
+			Given:  Plain(strings.TrimSpace(parts[len(parts)-1])),
+		}
+	}
+	words := splitTop(s, " ")
+	var ws []string
+	for _, w := range words {
+		if len(w) != 0 {
+			ws = append(ws, w)
+		}
+	}
+	if len(ws) == 0 {
+		return Name{}
+	}
+	// the family name starts at the first lower case word ('von'),
+	// or is the last word
+	start := len(ws) - 1
+	for i, w := range ws[:len(ws)-1] {
+		if r := []rune(Plain(w)); len(r) != 0 && unicode.IsLower(r[0]) {
+			start = i
+			break
+		}
+	}
+	return Name{
+		Family: Plain(strings.Join(ws[start:], " ")),
+		Given:  Plain(strings.Join(ws[:start], " ")),
+	}
+}
+
+// matchingBrace returns the position of the brace matching the opening
+// brace at the start of s, or -1.
+func matchingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitTop splits s at the separator, outside of braces. The separator
+// is matched case-insensitively.
+func splitTop(s, sep string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		default:
+			if depth == 0 && i+len(sep) <= len(s) && strings.EqualFold(s[i:i+len(sep)], sep) {
+				parts = append(parts, s[start:i])
+				start = i + len(sep)
+				i += len(sep) - 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// FormatNames formats names as a BibTeX name list.
+func FormatNames(names []Name) string {
+	ss := make([]string, len(names))
+	for i, n := range names {
+		ss[i] = n.String()
+	}
+	return strings.Join(ss, " and ")
+}
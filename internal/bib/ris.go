@@ -0,0 +1,304 @@
+package bib
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Milover/fetchref/internal/isbn"
+	"github.com/Milover/fetchref/internal/issn"
+)
+
+// risTag matches a RIS tag line, e.g., 'TY  - JOUR'.
+var risTag = regexp.MustCompile(`^([A-Z][A-Z0-9])  -(?: (.*))?$`)
+
+// risTypes maps RIS reference types to BibTeX entry types. Other reference
+// types are mapped to 'misc'.
+var risTypes = map[string]string{
+	"JOUR":   "article",
+	"JFULL":  "article",
+	"MGZN":   "article",
+	"EJOUR":  "article",
+	"BOOK":   "book",
+	"EBOOK":  "book",
+	"EDBOOK": "book",
+	"CHAP":   "incollection",
+	"ECHAP":  "incollection",
+	"CONF":   "inproceedings",
+	"CPAPER": "inproceedings",
+	"THES":   "phdthesis",
+	"RPRT":   "techreport",
+	"UNPB":   "unpublished",
+}
+
+// bibTypesRIS maps BibTeX entry types to RIS reference types. Other entry
+// types are mapped to 'GEN'.
+var bibTypesRIS = map[string]string{
+	"article":       "JOUR",
+	"book":          "BOOK",
+	"incollection":  "CHAP",
+	"inbook":        "CHAP",
+	"inproceedings": "CPAPER",
+	"conference":    "CPAPER",
+	"proceedings":   "CONF",
+	"phdthesis":     "THES",
+	"mastersthesis": "THES",
+	"thesis":        "THES",
+	"techreport":    "RPRT",
+	"report":        "RPRT",
+	"unpublished":   "UNPB",
+}
+
+// ParseRIS parses RIS data.
+func ParseRIS(data []byte) ([]Entry, error) {
+	var entries []Entry
+	var e *Entry
+	var authors, editors []Name
+	var keywords []string
+	var last string // last tag, for continuation lines
+	var startPage, endPage string
+
+	end := func() {
+		if e == nil {
+			return
+		}
+		if len(authors) != 0 {
+			e.Set("author", FormatNames(authors))
+		}
+		if len(editors) != 0 {
+			e.Set("editor", FormatNames(editors))
+		}
+		if len(keywords) != 0 {
+			e.Set("keywords", Escape(strings.Join(keywords, ", ")))
+		}
+		switch {
+		case len(startPage) != 0 && len(endPage) != 0:
+			e.Set("pages", Escape(startPage)+"--"+Escape(endPage))
+		case len(startPage) != 0:
+			e.Set("pages", Escape(startPage))
+		}
+		for _, name := range []string{"author", "editor", "keywords", "pages"} {
+			if len(e.Get(name)) == 0 {
+				e.Del(name)
+			}
+		}
+		entries = append(entries, *e)
+		e, authors, editors, keywords = nil, nil, nil, nil
+		startPage, endPage = "", ""
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	sc.Buffer(nil, 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimRight(sc.Text(), " \r")
+		m := risTag.FindStringSubmatch(line)
+		if m == nil {
+			// continuation of the previous value
+			if e != nil && len(strings.TrimSpace(line)) != 0 && len(last) != 0 {
+				if f := e.Field(last); f != nil {
+					f.Value += " " + Escape(strings.TrimSpace(line))
+				}
+			}
+			continue
+		}
+		tag, value := m[1], strings.TrimSpace(m[2])
+		if tag == "TY" {
+			end()
+			e = &Entry{Type: "misc"}
+			if t, found := risTypes[value]; found {
+				e.Type = t
+			}
+			continue
+		}
+		if e == nil {
+			return nil, fmt.Errorf("ris: line %d: tag %v outside of a reference", n, tag)
+		}
+		last = ""
+		set := func(name, value string) {
+			if len(value) != 0 && !e.Has(name) {
+				if Verbatim(name) {
+					e.Set(name, value)
+				} else {
+					e.Set(name, Escape(value))
+				}
+				last = name
+			}
+		}
+		// placeholder adds an empty field, set once the reference ends,
+		// to retain the order of fields
+		placeholder := func(name string) {
+			if !e.Has(name) {
+				e.Set(name, "")
+			}
+		}
+		switch tag {
+		case "ER":
+			end()
+		case "ID":
+			e.Key = value
+		case "AU", "A1":
+			placeholder("author")
+			authors = append(authors, risName(value))
+		case "A2", "ED":
+			placeholder("editor")
+			editors = append(editors, risName(value))
+		case "TI", "T1":
+			set("title", value)
+		case "T2", "JO", "JF", "JA", "BT":
+			if e.Type == "article" {
+				set("journal", value)
+			} else if e.Type != "book" {
+				set("booktitle", value)
+			}
+		case "PY", "Y1", "DA":
+			date := strings.Split(value, "/")
+			if len(date[0]) >= 4 {
+				set("year", date[0][:4])
+			}
+			if m, err := strconv.Atoi(strings.TrimSpace(safeIndex(date, 1))); err == nil && m >= 1 && m <= 12 && !e.Has("month") {
+				e.SetMacro("month", months[m-1])
+			}
+		case "VL":
+			set("volume", value)
+		case "IS":
+			set("number", value)
+		case "SP":
+			placeholder("pages")
+			if i := strings.IndexAny(value, "-–"); i > 0 {
+				startPage, endPage = value[:i], strings.TrimLeft(value[i:], "-– ")
+			} else {
+				startPage = value
+			}
+		case "EP":
+			endPage = value
+		case "PB":
+			set("publisher", value)
+		case "CY":
+			set("address", value)
+		case "SN":
+			for _, v := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == ' ' }) {
+				switch {
+				case isbn.IsValid(v):
+					set("isbn", v)
+				case issn.IsValid(v):
+					set("issn", v)
+				}
+			}
+		case "DO":
+			set("doi", value)
+		case "UR":
+			set("url", value)
+		case "AB", "N2":
+			set("abstract", value)
+		case "N1":
+			set("note", value)
+		case "KW":
+			placeholder("keywords")
+			keywords = append(keywords, value)
+		case "LA":
+			set("language", value)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	end()
+	return entries, nil
+}
+
+// safeIndex returns ss[i], or an empty string if i is out of range.
+func safeIndex(ss []string, i int) string {
+	if i < len(ss) {
+		return ss[i]
+	}
+	return ""
+}
+
+// risName parses a RIS name, i.e., 'Family, Given[, Suffix]', or a name
+// without a comma, which is taken to be an organization's name.
+func risName(s string) Name {
+	parts := strings.Split(s, ",")
+	if len(parts) == 1 {
+		return Name{Literal: Escape(strings.TrimSpace(s))}
+	}
+	return Name{
+		Family: Escape(strings.TrimSpace(parts[0])),
+		Given:  Escape(strings.TrimSpace(parts[1])),
+	}
+}
+
+// WriteRIS writes the entries to w as RIS.
+func WriteRIS(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range entries {
+		tag := func(t, v string) {
+			if v = strings.TrimSpace(v); len(v) != 0 {
+				fmt.Fprintf(bw, "%v  - %v\r\n", t, v)
+			}
+		}
+		field := func(t, name string) {
+			tag(t, Plain(e.Get(name)))
+		}
+		ty, found := bibTypesRIS[e.Type]
+		if !found {
+			ty = "GEN"
+		}
+		tag("TY", ty)
+		tag("ID", e.Key)
+		for _, n := range ParseNames(e.Get("author")) {
+			tag("AU", risFormatName(n))
+		}
+		for _, n := range ParseNames(e.Get("editor")) {
+			tag("A2", risFormatName(n))
+		}
+		field("TI", "title")
+		field("T2", "journal")
+		field("T2", "booktitle")
+		if y := Year(&e); y > 0 {
+			tag("PY", strconv.Itoa(y))
+			if m := month(e.Get("month")); m > 0 {
+				tag("DA", fmt.Sprintf("%04d/%02d//", y, m))
+			}
+		}
+		field("VL", "volume")
+		field("IS", "number")
+		pages := Plain(e.Get("pages"))
+		if first, last, found := strings.Cut(pages, "–"); found {
+			tag("SP", first)
+			tag("EP", last)
+		} else {
+			tag("SP", pages)
+		}
+		field("PB", "publisher")
+		field("PB", "school")
+		field("PB", "institution")
+		field("CY", "address")
+		field("SN", "isbn")
+		field("SN", "issn")
+		field("DO", "doi")
+		field("UR", "url")
+		field("AB", "abstract")
+		field("N1", "note")
+		for _, kw := range strings.Split(Plain(e.Get("keywords")), ",") {
+			tag("KW", kw)
+		}
+		field("LA", "language")
+		bw.WriteString("ER  - \r\n\r\n")
+	}
+	return bw.Flush()
+}
+
+// risFormatName formats a name as a RIS name.
+func risFormatName(n Name) string {
+	switch {
+	case len(n.Literal) != 0:
+		return n.Literal
+	case len(n.Given) != 0:
+		return n.Family + ", " + n.Given
+	}
+	return n.Family
+}
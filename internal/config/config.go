@@ -0,0 +1,109 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Config holds the fetchref configuration, as read from a (JSON)
+// configuration file, e.g.:
+//
+//	{
+//		"sources": [
+//			{"name": "unpaywall"},
+//			{"name": "landing", "priority": 5},
+//			{"name": "scihub", "mirrors": ["sci-hub.se"]},
+//			{
+//				"name": "archive",
+//				"type": "template",
+//				"url": "https://docs.internal/{doi_urlencoded}.pdf",
+//				"token_env": "ARCHIVE_TOKEN"
+//			}
+//		]
+//	}
+type Config struct {
+	// Sources is the list of enabled download sources.
+	// If empty, the default download sources are enabled.
+	Sources []Source `json:"sources"`
+}
+
+// Source holds the configuration of a download source.
+type Source struct {
+	// Name is the unique name of the source.
+	Name string `json:"name"`
+	// Type is the source type. If empty, Name is used as the type,
+	// i.e., built-in sources can be enabled by name alone.
+	Type string `json:"type"`
+	// Priority determines the order in which sources are tried, sources
+	// with a lower priority are tried first. If unset, the priority is
+	// determined by the position of the source in the list, i.e.,
+	// 10, 20, 30... Sources with equal priorities are tried in the order
+	// in which they are listed.
+	Priority *int `json:"priority"`
+	// Mirrors overrides the default mirrors of mirror-based sources,
+	// e.g., Sci-Hub or Libgen.
+	Mirrors []string `json:"mirrors"`
+	// Disabled disables the source without removing it from the list.
+	Disabled bool `json:"disabled"`
+
+	// URL is the URL template of 'template' sources, e.g.,
+	// 'https://docs.internal/{doi_urlencoded}.pdf'.
+	URL string `json:"url"`
+	// JSONPath, if set, makes URL a JSON lookup endpoint of a 'template'
+	// source, and is the path to the file URL in the response,
+	// e.g., '$.data.url'.
+	JSONPath string `json:"json_path"`
+	// TokenEnv is the name of the environment variable holding the bearer
+	// token used to authenticate requests to a 'template' source.
+	TokenEnv string `json:"token_env"`
+}
+
+// SourceType returns the source type.
+func (s Source) SourceType() string {
+	if len(s.Type) != 0 {
+		return s.Type
+	}
+	return s.Name
+}
+
+// DefaultPath returns the default configuration file path, i.e.,
+// 'fetchref/config.json' in the user's configuration directory,
+// or an empty string if the directory cannot be determined.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "fetchref", "config.json")
+}
+
+// Load reads the configuration from a file. If the file does not exist,
+// an empty configuration is returned.
+func Load(filename string) (Config, error) {
+	var c Config
+	b, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+	if err = json.Unmarshal(b, &c); err != nil {
+		return c, fmt.Errorf("%v: %w", filename, err)
+	}
+	names := make(map[string]bool)
+	for _, s := range c.Sources {
+		if len(s.Name) == 0 {
+			return c, fmt.Errorf("%v: source name not set", filename)
+		}
+		if names[s.Name] {
+			return c, fmt.Errorf("%v: duplicate source: %v", filename, s.Name)
+		}
+		names[s.Name] = true
+	}
+	return c, nil
+}
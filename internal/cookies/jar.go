@@ -0,0 +1,257 @@
+package cookies
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Milover/fetchref/internal/outfile"
+)
+
+// httpOnlyPrefix is the domain prefix used by browsers to mark
+// HTTP-only cookies in Netscape cookies files.
+const httpOnlyPrefix = "#HttpOnly_"
+
+// entry is a single cookie stored in the Jar.
+type entry struct {
+	Domain            string
+	IncludeSubdomains bool
+	Path              string
+	Secure            bool
+	HttpOnly          bool
+	Expires           time.Time // zero for session cookies
+	Name              string
+	Value             string
+}
+
+// key uniquely identifies the entry within the Jar.
+func (e *entry) key() string {
+	return e.Domain + ";" + e.Path + ";" + e.Name
+}
+
+// expired reports whether the entry has expired at time t.
+func (e *entry) expired(t time.Time) bool {
+	return !e.Expires.IsZero() && !e.Expires.After(t)
+}
+
+// domainMatch reports whether the entry should be sent to host.
+func (e *entry) domainMatch(host string) bool {
+	if host == e.Domain {
+		return true
+	}
+	return e.IncludeSubdomains && strings.HasSuffix(host, "."+e.Domain)
+}
+
+// pathMatch reports whether the entry should be sent for path.
+func (e *entry) pathMatch(path string) bool {
+	if path == e.Path {
+		return true
+	}
+	if !strings.HasPrefix(path, e.Path) {
+		return false
+	}
+	return strings.HasSuffix(e.Path, "/") || path[len(e.Path)] == '/'
+}
+
+// Jar is an http.CookieJar which can be loaded from, and saved to,
+// a Netscape (cookies.txt) cookies file, as exported by most browsers.
+// It is safe for concurrent use.
+type Jar struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// New returns a new, empty Jar.
+func New() *Jar {
+	return &Jar{entries: make(map[string]*entry)}
+}
+
+// Load reads cookies from a Netscape cookies file into the Jar.
+// Expired cookies are skipped.
+func (j *Jar) Load(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return j.Read(f)
+}
+
+// Read reads cookies in the Netscape cookies file format into the Jar.
+// Expired cookies are skipped.
+func (j *Jar) Read(r io.Reader) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimRight(s.Text(), "\r")
+		var httpOnly bool
+		if strings.HasPrefix(line, httpOnlyPrefix) {
+			line = line[len(httpOnlyPrefix):]
+			httpOnly = true
+		}
+		if len(strings.TrimSpace(line)) == 0 || line[0] == '#' {
+			continue
+		}
+		f := strings.Split(line, "\t")
+		if len(f) != 7 {
+			return fmt.Errorf("cookies: line %v: expected 7 fields, got %v", n, len(f))
+		}
+		exp, err := strconv.ParseInt(f[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("cookies: line %v: bad expiry: %v", n, f[4])
+		}
+		e := &entry{
+			Domain:            strings.TrimPrefix(strings.ToLower(f[0]), "."),
+			IncludeSubdomains: strings.EqualFold(f[1], "TRUE"),
+			Path:              f[2],
+			Secure:            strings.EqualFold(f[3], "TRUE"),
+			HttpOnly:          httpOnly,
+			Name:              f[5],
+			Value:             f[6],
+		}
+		if exp != 0 {
+			e.Expires = time.Unix(exp, 0)
+		}
+		if e.expired(now) {
+			continue
+		}
+		j.entries[e.key()] = e
+	}
+	return s.Err()
+}
+
+// Save writes all persistent (non-session), unexpired cookies in the Jar
+// to a Netscape cookies file.
+// The file is written atomically.
+func (j *Jar) Save(filename string) error {
+	var b bytes.Buffer
+	if err := j.Write(&b); err != nil {
+		return err
+	}
+	return outfile.WriteFile(filename, b.Bytes(), 0600)
+}
+
+// Write writes all persistent (non-session), unexpired cookies in the Jar
+// in the Netscape cookies file format.
+func (j *Jar) Write(w io.Writer) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# Netscape HTTP Cookie File")
+	now := time.Now()
+	for _, e := range j.entries {
+		if e.Expires.IsZero() || e.expired(now) {
+			continue
+		}
+		domain := e.Domain
+		if e.IncludeSubdomains {
+			domain = "." + domain
+		}
+		if e.HttpOnly {
+			domain = httpOnlyPrefix + domain
+		}
+		fmt.Fprintf(bw, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			domain,
+			strings.ToUpper(strconv.FormatBool(e.IncludeSubdomains)),
+			e.Path,
+			strings.ToUpper(strconv.FormatBool(e.Secure)),
+			e.Expires.Unix(),
+			e.Name,
+			e.Value)
+	}
+	return bw.Flush()
+}
+
+// SetCookies implements the http.CookieJar interface.
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	host := strings.ToLower(u.Hostname())
+	for _, c := range cookies {
+		e := &entry{
+			Domain:   host,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+			Name:     c.Name,
+			Value:    c.Value,
+		}
+		if len(c.Domain) != 0 {
+			d := strings.TrimPrefix(strings.ToLower(c.Domain), ".")
+			// reject cookies for unrelated domains
+			if host != d && !strings.HasSuffix(host, "."+d) {
+				continue
+			}
+			e.Domain = d
+			e.IncludeSubdomains = true
+		}
+		if len(e.Path) == 0 || e.Path[0] != '/' {
+			e.Path = defaultPath(u.Path)
+		}
+		switch {
+		case c.MaxAge < 0:
+			e.Expires = now
+		case c.MaxAge > 0:
+			e.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		case !c.Expires.IsZero():
+			e.Expires = c.Expires
+		}
+		if e.expired(now) {
+			delete(j.entries, e.key())
+			continue
+		}
+		j.entries[e.key()] = e
+	}
+}
+
+// Cookies implements the http.CookieJar interface.
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	host := strings.ToLower(u.Hostname())
+	path := u.Path
+	if len(path) == 0 {
+		path = "/"
+	}
+	var cookies []*http.Cookie
+	for k, e := range j.entries {
+		if e.expired(now) {
+			delete(j.entries, k)
+			continue
+		}
+		if !e.domainMatch(host) || !e.pathMatch(path) {
+			continue
+		}
+		if e.Secure && u.Scheme != "https" {
+			continue
+		}
+		cookies = append(cookies, &http.Cookie{Name: e.Name, Value: e.Value})
+	}
+	return cookies
+}
+
+// defaultPath returns the default cookie path for a request path,
+// as defined by RFC 6265, section 5.1.4.
+func defaultPath(path string) string {
+	i := strings.LastIndex(path, "/")
+	if len(path) == 0 || path[0] != '/' || i == 0 {
+		return "/"
+	}
+	return path[:i]
+}
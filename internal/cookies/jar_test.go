@@ -0,0 +1,98 @@
+package cookies
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testCookies = `# Netscape HTTP Cookie File
+# comment
+
+.example.edu	TRUE	/	TRUE	4102444800	ezproxy	abc123
+#HttpOnly_www.publisher.com	FALSE	/journals	FALSE	4102444800	session	def456
+old.example.edu	FALSE	/	FALSE	1	expired	ghi789
+`
+
+type jarTest struct {
+	Name    string
+	URL     string
+	Cookies []string
+}
+
+var jarTests = []jarTest{
+	{
+		Name:    "subdomain-secure",
+		URL:     "https://login.example.edu/",
+		Cookies: []string{"ezproxy=abc123"},
+	},
+	{
+		Name:    "subdomain-insecure",
+		URL:     "http://login.example.edu/",
+		Cookies: nil,
+	},
+	{
+		Name:    "host-only-path",
+		URL:     "https://www.publisher.com/journals/jfm",
+		Cookies: []string{"session=def456"},
+	},
+	{
+		Name:    "host-only-subdomain",
+		URL:     "https://a.www.publisher.com/journals",
+		Cookies: nil,
+	},
+	{
+		Name:    "host-only-bad-path",
+		URL:     "https://www.publisher.com/journalsfoo",
+		Cookies: nil,
+	},
+	{
+		Name:    "expired",
+		URL:     "https://old.example.edu/",
+		Cookies: []string{"ezproxy=abc123"},
+	},
+}
+
+func cookieStrings(cs []*http.Cookie) []string {
+	var s []string
+	for _, c := range cs {
+		s = append(s, c.String())
+	}
+	return s
+}
+
+func TestJar(t *testing.T) {
+	j := New()
+	assert.Nil(t, j.Read(strings.NewReader(testCookies)))
+
+	for _, tt := range jarTests {
+		t.Run(tt.Name, func(t *testing.T) {
+			u, _ := url.Parse(tt.URL)
+			assert.Equal(t, tt.Cookies, cookieStrings(j.Cookies(u)))
+		})
+	}
+}
+
+func TestJarRoundTrip(t *testing.T) {
+	j := New()
+	assert.Nil(t, j.Read(strings.NewReader(testCookies)))
+
+	u, _ := url.Parse("https://www.publisher.com/journals/jfm/article")
+	j.SetCookies(u, []*http.Cookie{
+		{Name: "session", Value: "new", Path: "/journals", MaxAge: 3600},
+		{Name: "gone", Value: "x", MaxAge: -1},
+		{Name: "evil", Value: "x", Domain: "other.com", MaxAge: 3600},
+	})
+	assert.Equal(t, []string{"session=new"}, cookieStrings(j.Cookies(u)))
+
+	var b bytes.Buffer
+	assert.Nil(t, j.Write(&b))
+	k := New()
+	assert.Nil(t, k.Read(&b))
+	assert.Equal(t, []string{"session=new"}, cookieStrings(k.Cookies(u)))
+	assert.Len(t, k.entries, 2)
+}
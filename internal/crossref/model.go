@@ -20,10 +20,32 @@ const (
 	QueryKeyFilter         string = "filter"
 	QueryValFilterISBN     string = "isbn:"
 	QueryValFilterTypeBook string = "type:book"
+	QueryValFilterUpdates  string = "updates:"
 	QueryKeyRows           string = "rows"
 	QueryValRows           string = "1"
+	QueryKeySort           string = "sort"
+	QueryValSortPublished  string = "published"
+	QueryKeyOrder          string = "order"
+	QueryValOrderDesc      string = "desc"
 )
 
+// Crossref's full-text link and license attribute values.
+const (
+	ContentTypePDF                string = "application/pdf"
+	ContentTypeUnspecified        string = "unspecified"
+	ContentVersionVoR             string = "vor"
+	ContentVersionAM              string = "am"
+	ContentVersionTDM             string = "tdm"
+	ContentVersionUnspecified     string = "unspecified"
+	ApplicationTextMining         string = "text-mining"
+	ApplicationSimilarityChecking string = "similarity-checking"
+	ApplicationUnspecified        string = "unspecified"
+)
+
+// HeaderClickThroughToken is the HTTP header used to supply a Crossref
+// text and data mining (TDM) click-through token to publishers.
+const HeaderClickThroughToken string = "CR-Clickthrough-Client-Token"
+
 // Crossref's REST API endpoints.
 const (
 	APIFunders  string = "funders"
@@ -69,6 +91,78 @@ type DateParts struct {
 	DateParts [][]int `json:"date-parts"`
 }
 
+// Year returns the year of the date, or 0 if it is not set.
+func (d DateParts) Year() int {
+	if len(d.DateParts) == 0 || len(d.DateParts[0]) == 0 {
+		return 0
+	}
+	return d.DateParts[0][0]
+}
+
+// Month returns the month of the date, or 0 if it is not set.
+func (d DateParts) Month() int {
+	if len(d.DateParts) == 0 || len(d.DateParts[0]) < 2 {
+		return 0
+	}
+	return d.DateParts[0][1]
+}
+
+// Year returns the year in which the work was published, i.e., the year
+// it was issued, published in print or online, or posted, or 0 if none
+// of these dates are set.
+func (w Work) Year() int {
+	for _, d := range []DateParts{
+		w.Issued, w.PublishedPrint, w.PublishedOnline, w.Posted,
+	} {
+		if y := d.Year(); y > 0 {
+			return y
+		}
+	}
+	return 0
+}
+
+// Journal holds metadata about a journal (serial), as returned by the
+// '/journals/{issn}' endpoint.
+type Journal struct {
+	LastStatusCheckTime int                `json:"last-status-check-time"`
+	Counts              JournalCounts      `json:"counts"`
+	Breakdowns          JournalBreakdowns  `json:"breakdowns"`
+	Publisher           string             `json:"publisher"`
+	Coverage            map[string]float64 `json:"coverage"`
+	Title               string             `json:"title"`
+	Subjects            []JournalSubject   `json:"subjects"`
+	Flags               map[string]bool    `json:"flags"`
+	ISSN                []string           `json:"ISSN"`
+	ISSNType            []WorkISSNType     `json:"issn-type"`
+}
+
+// JournalBreakdowns holds statistical breakdowns of the journal's works.
+type JournalBreakdowns struct {
+	// DOIsByIssuedYear is a list of (year, number of DOIs) pairs.
+	DOIsByIssuedYear [][]int `json:"dois-by-issued-year"`
+}
+
+// JournalCounts holds the number of DOIs registered for the journal.
+type JournalCounts struct {
+	CurrentDOIs  int `json:"current-dois"`
+	BackfileDOIs int `json:"backfile-dois"`
+	TotalDOIs    int `json:"total-dois"`
+}
+
+// JournalMessage is the return type of the '/journals/{issn}' endpoint.
+type JournalMessage struct {
+	Status         string  `json:"status"`
+	MessageType    string  `json:"message-type"`
+	MessageVersion string  `json:"message-version"`
+	Message        Journal `json:"message"`
+}
+
+// JournalSubject holds a subject classification of the journal.
+type JournalSubject struct {
+	Name string `json:"name"`
+	ASJC int    `json:"ASJC"`
+}
+
 // Query holds information about the endpoint query.
 type Query struct {
 	StartIndex  int    `json:"start-index"`
@@ -107,6 +201,7 @@ type Work struct {
 	Posted              DateParts           `json:"posted"`
 	PublisherLocation   string              `json:"publisher-location"`
 	UpdateTo            []WorkUpdate        `json:"update-to"`
+	UpdatedBy           []WorkUpdate        `json:"updated-by"`
 	StandardsBody       []WorkStandardsBody `json:"standards-body"`
 	EditionNumber       string              `json:"edition-number"`
 	GroupTitle          []string            `json:"group-title"`
@@ -118,7 +213,7 @@ type Work struct {
 	Founder             []WorkFunder        `json:"founder"`
 	ContentDomain       WorkDomain          `json:"content-domain"`
 	Chair               []Author            `json:"chair"`
-	ShortContainerTitle string              `json:"shortcontainer-title"`
+	ShortContainerTitle []string            `json:"short-container-title"`
 	Accepted            DateParts           `json:"accepted"`
 	ContentUpdated      DateParts           `json:"content-updated"`
 	PublishedPrint      DateParts           `json:"published-print"`
@@ -296,12 +391,18 @@ type WorkStandardsBody struct {
 type WorkUpdate struct {
 	// Label is a display-friendly label for the update type.
 	Label string `json:"label"`
-	// DOI is the DOI of the updated work.
+	// DOI is the DOI of the updated work, or, in 'updated-by',
+	// the DOI of the notice which updates the work.
 	DOI string `json:"DOI"`
 	// Type is the type of update, e.g., 'retraction' or 'correction'.
 	Type string `json:"type"`
 	// Updated is the date on which the update was published.
 	Updated Date `json:"updated"`
+	// Source is the source of the update record, i.e., 'publisher' or
+	// 'retraction-watch'.
+	Source string `json:"source"`
+	// RecordID is the Retraction Watch record ID, if any.
+	RecordID int `json:"record-id"`
 }
 
 // Works holds information about a list of works resulting from querying an
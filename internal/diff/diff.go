@@ -0,0 +1,187 @@
+// Package diff implements line-based unified diffs.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Context is the number of unchanged lines surrounding changes in hunks.
+const Context = 3
+
+// op is an edit operation.
+type op byte
+
+const (
+	equal  op = ' '
+	del    op = '-'
+	insert op = '+'
+)
+
+// edit is an edit of a line, at position a of the old, and b of the new
+// lines.
+type edit struct {
+	op   op
+	a, b int
+}
+
+// Unified returns the unified diff of the old and new text, named oldName
+// and newName, or an empty string if they are equal.
+func Unified(oldName, newName string, old, new []byte) string {
+	a, b := lines(string(old)), lines(string(new))
+	es := edits(a, b)
+	var sb strings.Builder
+	for i := 0; i < len(es); {
+		// skip to the next change
+		for i < len(es) && es[i].op == equal {
+			i++
+		}
+		if i == len(es) {
+			break
+		}
+		// extend the hunk while changes are no more than 2*Context
+		// lines apart
+		start := i - Context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(es); j++ {
+			if es[j].op != equal {
+				end = j + 1
+			} else if j-end >= 2*Context {
+				break
+			}
+		}
+		end += Context
+		if end > len(es) {
+			end = len(es)
+		}
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %v\n+++ %v\n", oldName, newName)
+		}
+		writeHunk(&sb, a, b, es[start:end])
+		i = end
+	}
+	return sb.String()
+}
+
+// writeHunk writes the hunk of edits.
+func writeHunk(sb *strings.Builder, a, b []string, es []edit) {
+	var na, nb int
+	for _, e := range es {
+		if e.op != insert {
+			na++
+		}
+		if e.op != del {
+			nb++
+		}
+	}
+	fmt.Fprintf(sb, "@@ -%v +%v @@\n", hunkRange(es[0].a, na), hunkRange(es[0].b, nb))
+	for _, e := range es {
+		line := ""
+		switch e.op {
+		case insert:
+			line = b[e.b]
+		default:
+			line = a[e.a]
+		}
+		sb.WriteByte(byte(e.op))
+		sb.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats the (0-based) start and length of a hunk range.
+func hunkRange(start, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%v,0", start)
+	case 1:
+		return fmt.Sprintf("%v", start+1)
+	}
+	return fmt.Sprintf("%v,%v", start+1, n)
+}
+
+// lines splits s into lines, retaining line endings.
+func lines(s string) []string {
+	var ls []string
+	for len(s) != 0 {
+		i := strings.IndexByte(s, '\n') + 1
+		if i == 0 {
+			i = len(s)
+		}
+		ls = append(ls, s[:i])
+		s = s[i:]
+	}
+	return ls
+}
+
+// edits returns the shortest edit script transforming a into b, computed
+// by Myers' algorithm. Positions of inserted lines in a, and of deleted
+// lines in b, are the positions at which they are inserted or deleted.
+func edits(a, b []string) []edit {
+	n, m := len(a), len(b)
+	max := n + m
+	off := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, off, n, m)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack recovers the edit script from the trace of Myers' algorithm.
+func backtrack(trace [][]int, off, x, y int) []edit {
+	var es []edit
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[off+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			es = append(es, edit{op: equal, a: x, b: y})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				es = append(es, edit{op: insert, a: x, b: y})
+			} else {
+				x--
+				es = append(es, edit{op: del, a: x, b: y})
+			}
+		}
+	}
+	for i, j := 0, len(es)-1; i < j; i, j = i+1, j-1 {
+		es[i], es[j] = es[j], es[i]
+	}
+	return es
+}
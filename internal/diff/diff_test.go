@@ -0,0 +1,36 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnified(t *testing.T) {
+	assert := assert.New(t)
+	var tests = []struct {
+		Old string
+		New string
+		Out string
+	}{
+		{"a\nb\n", "a\nb\n", ""},
+		{
+			"a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n",
+			"a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn",
+			"--- old\n+++ new\n" +
+				"@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n" +
+				"@@ -11,3 +11,4 @@\n k\n l\n m\n+n\n\\ No newline at end of file\n",
+		},
+		{
+			"a\nb\nc\nd\ne\nf\ng\nh\n",
+			"a\nB\nc\nd\ne\nf\nG\nh\n",
+			"--- old\n+++ new\n" +
+				"@@ -1,8 +1,8 @@\n a\n-b\n+B\n c\n d\n e\n f\n-g\n+G\n h\n",
+		},
+		{"x\n", "", "--- old\n+++ new\n@@ -1 +0,0 @@\n-x\n"},
+		{"", "x\n", "--- old\n+++ new\n@@ -0,0 +1 @@\n+x\n"},
+	}
+	for _, tt := range tests {
+		assert.Equal(tt.Out, Unified("old", "new", []byte(tt.Old), []byte(tt.New)), tt.New)
+	}
+}
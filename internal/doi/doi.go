@@ -0,0 +1,86 @@
+package doi
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// valid matches a syntactically valid DOI, i.e., a '10.' directory
+	// indicator, a registrant code and a (non-empty) suffix.
+	valid = regexp.MustCompile(`^10\.[0-9]{4,9}(\.[0-9]+)*/\S+$`)
+
+	// embedded matches a DOI embedded in text.
+	embedded = regexp.MustCompile(`10\.[0-9]{4,9}(\.[0-9]+)*/[^\s"<>]+`)
+
+	// prefixes are the prefixes which are stripped by Clean, in lower case.
+	prefixes = []string{
+		"https://doi.org/",
+		"http://doi.org/",
+		"https://dx.doi.org/",
+		"http://dx.doi.org/",
+		"doi.org/",
+		"doi:",
+	}
+)
+
+// Clean returns the DOI without a 'doi:' or resolver URL
+// ('https://doi.org/') prefix, and surrounding white space.
+func Clean(d string) string {
+	d = strings.TrimSpace(d)
+	for _, p := range prefixes {
+		if len(d) >= len(p) && strings.EqualFold(d[:len(p)], p) {
+			return strings.TrimSpace(d[len(p):])
+		}
+	}
+	return d
+}
+
+// IsValid reports whether d is a syntactically valid DOI, e.g.,
+// '10.1000/182'. The DOI is not required to be registered.
+func IsValid(d string) bool {
+	return valid.MatchString(d)
+}
+
+// Equal reports whether DOIs a and b are equal, i.e., equal under
+// (ASCII) case folding, once cleaned.
+func Equal(a, b string) bool {
+	return strings.EqualFold(Clean(a), Clean(b))
+}
+
+// Find returns the DOIs embedded in the text, in order of appearance and
+// without duplicates. Trailing punctuation, e.g., a full stop ending a
+// sentence, is not considered part of a DOI.
+func Find(text string) []string {
+	var dois []string
+	seen := make(map[string]bool)
+	for _, d := range embedded.FindAllString(text, -1) {
+		d = trimPunct(d)
+		if k := strings.ToLower(d); !seen[k] && IsValid(d) {
+			seen[k] = true
+			dois = append(dois, d)
+		}
+	}
+	return dois
+}
+
+// trimPunct trims trailing punctuation from an embedded DOI, along with
+// closing brackets, unless they are matched within the DOI, e.g.,
+// '10.1016/0370-2693(82)90087-1'.
+func trimPunct(d string) string {
+	for len(d) > 0 {
+		c := d[len(d)-1]
+		switch c {
+		case '.', ',', ';', ':', '\'', '!', '?':
+		case ')', ']', '}':
+			open := map[byte]byte{')': '(', ']': '[', '}': '{'}[c]
+			if strings.Count(d, string(open)) >= strings.Count(d, string(c)) {
+				return d
+			}
+		default:
+			return d
+		}
+		d = d[:len(d)-1]
+	}
+	return d
+}
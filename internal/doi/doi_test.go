@@ -0,0 +1,54 @@
+package doi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValid(t *testing.T) {
+	assert := assert.New(t)
+	var tests = []struct {
+		Name   string
+		Input  string
+		Output bool
+	}{
+		{"good-doi", "10.1000/182", true},
+		{"good-doi-sub", "10.1000.10/182", true},
+		{"good-doi-parens", "10.1016/0370-2693(82)90087-1", true},
+		{"bad-doi-prefix", "11.1000/182", false},
+		{"bad-doi-registrant", "10.100/182", false},
+		{"bad-doi-suffix", "10.1000/", false},
+		{"bad-doi-space", "10.1000/1 82", false},
+		{"bad-doi-url", "https://doi.org/10.1000/182", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(tt.Output, IsValid(tt.Input), tt.Name)
+	}
+}
+
+func TestClean(t *testing.T) {
+	assert := assert.New(t)
+	for _, in := range []string{
+		"10.1000/182",
+		" doi:10.1000/182",
+		"DOI: 10.1000/182",
+		"https://doi.org/10.1000/182",
+		"http://dx.doi.org/10.1000/182",
+	} {
+		assert.Equal("10.1000/182", Clean(in), in)
+	}
+	assert.True(Equal("10.1103/PhysRevLett.116.061102", "doi:10.1103/physrevlett.116.061102"))
+}
+
+func TestFind(t *testing.T) {
+	assert := assert.New(t)
+	text := `Phys. Lett. B (doi:10.1016/0370-2693(82)90087-1), see
+https://doi.org/10.1103/PhysRevLett.116.061102. Also 10.1103/physrevlett.116.061102;
+and [10.1000/182]. Not 10.12/3.`
+	assert.Equal([]string{
+		"10.1016/0370-2693(82)90087-1",
+		"10.1103/PhysRevLett.116.061102",
+		"10.1000/182",
+	}, Find(text))
+}
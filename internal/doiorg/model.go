@@ -14,8 +14,32 @@ const (
 
 // doi.org's REST API query parameters.
 const (
-	QueryKeyType   string = "type"
-	QueryValType   string = "none"
-	QueryKeyPretty string = "pretty"
-	QueryValPretty string = "true"
+	QueryKeyType    string = "type"
+	QueryValType    string = "none"
+	QueryValTypeURL string = "URL"
+	QueryKeyPretty  string = "pretty"
+	QueryValPretty  string = "true"
 )
+
+// HandleMessage is the response from querying the handles endpoint.
+type HandleMessage struct {
+	// ResponseCode is 1 on success, 100 if the handle was not found and
+	// 200 if the handle has no values of the requested type.
+	ResponseCode int           `json:"responseCode"`
+	Handle       string        `json:"handle"`
+	Values       []HandleValue `json:"values"`
+}
+
+// HandleValue holds a single value of a handle (DOI) record, e.g.,
+// the URL to which the DOI resolves.
+type HandleValue struct {
+	Index int             `json:"index"`
+	Type  string          `json:"type"`
+	Data  HandleValueData `json:"data"`
+}
+
+// HandleValueData holds the data of a handle value.
+type HandleValueData struct {
+	Format string `json:"format"`
+	Value  string `json:"value"`
+}
@@ -0,0 +1,112 @@
+package fetch
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/arxiv"
+	"github.com/Milover/fetchref/internal/crossref"
+)
+
+// reqArXivEntry requests the article metadata from arXiv's API.
+func reqArXivEntry(id string) (arxiv.Entry, error) {
+	u := &url.URL{
+		Scheme: "https",
+		Host:   arxiv.URL,
+		Path:   arxiv.API,
+	}
+	query := url.Values{}
+	query.Add(arxiv.QueryKeyIDList, id)
+	u.RawQuery = query.Encode()
+
+	ctx, cncl := context.WithTimeout(context.Background(), GlobalReqTimeout)
+	defer cncl()
+
+	res, err := sendGetRequest(ctx, u.String())
+	if err != nil {
+		return arxiv.Entry{}, err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return arxiv.Entry{}, err
+	}
+	var feed arxiv.Feed
+	if err = xml.Unmarshal(b, &feed); err != nil {
+		return arxiv.Entry{}, err
+	}
+	if len(feed.Entries) == 0 {
+		return arxiv.Entry{}, fmt.Errorf("arxiv: no query results")
+	}
+	// errors are reported as feed entries
+	e := feed.Entries[0]
+	if strings.Contains(e.ID, "/api/errors") {
+		return arxiv.Entry{}, fmt.Errorf("arxiv: %v", arxiv.NormalizeSpace(e.Summary))
+	}
+	return e, nil
+}
+
+// reqArXivMeta requests the article metadata from arXiv, and sets the
+// article title and DOI. If arXiv reports the DOI of the published version
+// of the article, it is used, otherwise the DOI registered by arXiv is used.
+// The arXiv metadata is set as the article metadata.
+func reqArXivMeta(a *article.Article) error {
+	e, err := reqArXivEntry(a.Handle.Value)
+	if err != nil {
+		return err
+	}
+	a.Title = arxiv.NormalizeSpace(e.Title)
+	if len(e.DOI) != 0 {
+		a.DOI = e.DOI
+		log.Printf("%v: published version DOI: %v", a.Handle.Value, a.DOI)
+	} else {
+		a.DOI = arxiv.DOI(a.Handle.Value)
+	}
+	w := e.Work(a.DOI)
+	a.Meta = &w
+	a.ArXiv = &e
+	return nil
+}
+
+// reqArXivMirrorInfo sets the article download URL to the PDF
+// hosted by an arXiv mirror.
+func reqArXivMirrorInfo(_ context.Context, a *article.Article, mirror string) error {
+	a.Url = &url.URL{
+		Scheme: "https",
+		Host:   mirror,
+		Path:   arxiv.PDF,
+	}
+	a.Url = a.Url.JoinPath(a.Handle.Value)
+	return nil
+}
+
+// reqArXivCitation formats the article citation from its arXiv metadata,
+// which is requested from arXiv, unless already set by reqArXivMeta.
+// Only BibTeX and CSL-JSON citations are supported.
+func reqArXivCitation(a *article.Article) error {
+	if a.ArXiv == nil {
+		e, err := reqArXivEntry(a.Handle.Value)
+		if err != nil {
+			return err
+		}
+		a.ArXiv = &e
+	}
+	e := *a.ArXiv
+	var err error
+	switch CiteFormat {
+	case crossref.BibTeX:
+		a.Citation = arxiv.BibTeX(e, a.Handle.Value, a.DOI)
+	case crossref.CiteprocJSON:
+		a.Citation, err = arxiv.CiteprocJSON(e, a.Handle.Value, a.DOI)
+	default:
+		err = fmt.Errorf("citation format not supported for arXiv: %v", CiteFormat)
+	}
+	return err
+}
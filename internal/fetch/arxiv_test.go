@@ -0,0 +1,41 @@
+package fetch
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/arxiv"
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/stretchr/testify/assert"
+)
+
+const testArXivFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:arxiv="http://arxiv.org/schemas/atom">
+  <entry>
+    <id>http://arxiv.org/abs/2101.01234v2</id>
+    <published>2021-01-04T18:59:59Z</published>
+    <title>Black Holes &amp; Revelations</title>
+    <author><name>Jane Doe</name></author>
+    <arxiv:primary_category term="gr-qc"/>
+  </entry>
+</feed>`
+
+func TestArXivCitation(t *testing.T) {
+	assert := assert.New(t)
+	var n int
+	standInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Host == arxiv.URL {
+			n++
+		}
+		w.Write([]byte(testArXivFeed))
+	})
+	CiteFormat = crossref.BibTeX
+
+	a := &article.Article{Handle: article.Handle{Type: article.ArXiv, Value: "2101.01234"}}
+	assert.Nil(reqArXivMeta(a))
+	assert.Nil(reqArXivCitation(a))
+	// the entry requested for the metadata is reused
+	assert.Equal(1, n)
+	assert.Contains(string(a.Citation), `title = {Black Holes \& Revelations},`)
+}
@@ -0,0 +1,69 @@
+package fetch
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+
+	"github.com/Milover/fetchref/internal/config"
+	"github.com/Milover/fetchref/internal/cookies"
+	"github.com/Milover/fetchref/internal/proxy"
+)
+
+var (
+	// ConfigFile is the configuration file from which download sources
+	// are configured.
+	ConfigFile = config.DefaultPath()
+
+	// CookieFile is the Netscape cookies file from which cookies are
+	// loaded before, and to which they are saved after, a run.
+	CookieFile string
+
+	// Proxy is the institutional proxy through which publisher
+	// content is requested.
+	Proxy proxy.Proxy
+
+	// client is the HTTP client used for all requests.
+	client = &http.Client{}
+)
+
+// Setup prepares a run, i.e., loads the configuration file and sets up
+// the download sources, and loads cookies from the cookie file,
+// if one is set.
+func Setup() error {
+	var c config.Config
+	if len(ConfigFile) != 0 {
+		var err error
+		if c, err = config.Load(ConfigFile); err != nil {
+			return err
+		}
+	}
+	if err := setupSources(c.Sources); err != nil {
+		return err
+	}
+
+	if len(CookieFile) == 0 {
+		return nil
+	}
+	jar := cookies.New()
+	if err := jar.Load(CookieFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	client.Jar = jar
+	return nil
+}
+
+// Teardown persists the HTTP client state after a run, i.e., saves
+// cookies to the cookie file, if one is set.
+func Teardown() error {
+	if jar, ok := client.Jar.(*cookies.Jar); ok {
+		return jar.Save(CookieFile)
+	}
+	return nil
+}
+
+// usesInstitutionalAccess reports whether publisher content should be
+// requested through an institutional proxy and/or with cookies.
+func usesInstitutionalAccess() bool {
+	return Proxy.IsSet() || len(CookieFile) != 0
+}
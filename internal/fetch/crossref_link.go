@@ -0,0 +1,133 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/crossref"
+)
+
+var (
+	// TDMToken is the Crossref text and data mining click-through token,
+	// supplied to publishers when downloading full texts linked from
+	// Crossref metadata.
+	TDMToken string
+)
+
+// linkCandidate is a full-text link from Crossref metadata, along with
+// the license under which it can be used.
+type linkCandidate struct {
+	link    crossref.WorkLink
+	license *crossref.WorkLicense
+}
+
+// rank returns the rank of the candidate, lower is better.
+// PDFs are preferred over unspecified content types, versions of record
+// over accepted manuscripts, and links with a matching license over
+// those without one.
+func (c linkCandidate) rank() int {
+	var r int
+	if c.link.ContentType != crossref.ContentTypePDF {
+		r += 4
+	}
+	if c.link.ContentVersion != crossref.ContentVersionVoR {
+		r += 2
+	}
+	if c.license == nil {
+		r += 1
+	}
+	return r
+}
+
+// isOpenLicense reports whether the license URL points to an open
+// (Creative Commons) license.
+func isOpenLicense(l *crossref.WorkLicense) bool {
+	return l != nil && strings.Contains(l.URL, "creativecommons.org")
+}
+
+// matchLicense returns the license of the work which applies to the link,
+// or nil if there is none. Licenses which are not yet in effect are
+// ignored.
+func matchLicense(w *crossref.Work, l crossref.WorkLink) *crossref.WorkLicense {
+	for i := range w.License {
+		lic := &w.License[i]
+		if len(lic.Start.DateTime) != 0 {
+			if t, err := time.Parse(time.RFC3339, lic.Start.DateTime); err == nil &&
+				t.After(time.Now()) {
+				continue
+			}
+		}
+		switch lic.ContentVersion {
+		case l.ContentVersion, crossref.ContentVersionUnspecified:
+			return lic
+		case crossref.ContentVersionTDM:
+			if l.IntendedApplication == crossref.ApplicationTextMining {
+				return lic
+			}
+		}
+	}
+	return nil
+}
+
+// linkCandidates returns the full-text links of the work which can be
+// downloaded, ordered by rank. Links are usable if they are licensed under
+// an open license, or if a TDM click-through token is supplied.
+func linkCandidates(w *crossref.Work) []linkCandidate {
+	var cs []linkCandidate
+	for _, l := range w.Link {
+		if l.ContentType != crossref.ContentTypePDF &&
+			l.ContentType != crossref.ContentTypeUnspecified {
+			continue
+		}
+		// intended for Crossref's Similarity Check crawler only
+		if l.IntendedApplication == crossref.ApplicationSimilarityChecking {
+			continue
+		}
+		c := linkCandidate{link: l, license: matchLicense(w, l)}
+		if len(TDMToken) == 0 && !isOpenLicense(c.license) {
+			continue
+		}
+		cs = append(cs, c)
+	}
+	sort.SliceStable(cs, func(i, j int) bool {
+		return cs[i].rank() < cs[j].rank()
+	})
+	return cs
+}
+
+// crossrefLinkCandidates returns the usable full-text links from the
+// article's Crossref metadata, in order of rank (see linkCandidates),
+// along with the licenses under which they are downloaded.
+func crossrefLinkCandidates(_ context.Context, a *article.Article) ([]Candidate, error) {
+	if a.Meta == nil {
+		return nil, fmt.Errorf("cannot retrieve full-text links, metadata not set")
+	}
+	var header http.Header
+	if len(TDMToken) != 0 {
+		header = http.Header{}
+		header.Set(crossref.HeaderClickThroughToken, TDMToken)
+	}
+	var cands []Candidate
+	for _, c := range linkCandidates(a.Meta) {
+		u, err := url.Parse(c.link.URL)
+		if err != nil {
+			logErr(a.Handle.Value, err)
+			continue
+		}
+		cand := Candidate{URL: u, Header: header}
+		if c.license != nil {
+			cand.License = c.license.URL
+		}
+		cands = append(cands, cand)
+	}
+	if len(cands) == 0 {
+		return nil, fmt.Errorf("crossref: no usable full-text links")
+	}
+	return cands, nil
+}
@@ -0,0 +1,67 @@
+package fetch
+
+import (
+	"testing"
+
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkCandidates(t *testing.T) {
+	const cc = "https://creativecommons.org/licenses/by/4.0/"
+	const tdm = "https://www.elsevier.com/tdm/userlicense/1.0/"
+	links := []crossref.WorkLink{
+		{URL: "am.pdf", ContentType: crossref.ContentTypePDF, ContentVersion: crossref.ContentVersionAM},
+		{URL: "vor.xml", ContentType: "text/xml", ContentVersion: crossref.ContentVersionVoR},
+		{URL: "vor.unspecified", ContentType: crossref.ContentTypeUnspecified, ContentVersion: crossref.ContentVersionVoR},
+		{URL: "vor.pdf", ContentType: crossref.ContentTypePDF, ContentVersion: crossref.ContentVersionVoR},
+		{URL: "similarity.pdf", ContentType: crossref.ContentTypePDF, ContentVersion: crossref.ContentVersionVoR,
+			IntendedApplication: crossref.ApplicationSimilarityChecking},
+		{URL: "tdm.pdf", ContentType: crossref.ContentTypePDF, ContentVersion: crossref.ContentVersionUnspecified,
+			IntendedApplication: crossref.ApplicationTextMining},
+	}
+	var tests = []struct {
+		Name     string
+		Token    string
+		Licenses []crossref.WorkLicense
+		URLs     []string
+	}{
+		{
+			Name:     "open-vor",
+			Licenses: []crossref.WorkLicense{{URL: cc, ContentVersion: crossref.ContentVersionVoR}},
+			URLs:     []string{"vor.pdf", "vor.unspecified"},
+		},
+		{
+			Name:     "open-unspecified",
+			Licenses: []crossref.WorkLicense{{URL: cc, ContentVersion: crossref.ContentVersionUnspecified}},
+			URLs:     []string{"vor.pdf", "am.pdf", "tdm.pdf", "vor.unspecified"},
+		},
+		{
+			Name: "open-future",
+			Licenses: []crossref.WorkLicense{{URL: cc, ContentVersion: crossref.ContentVersionUnspecified,
+				Start: crossref.Date{DateTime: "2999-01-01T00:00:00Z"}}},
+		},
+		{
+			Name:     "closed",
+			Licenses: []crossref.WorkLicense{{URL: tdm, ContentVersion: crossref.ContentVersionTDM}},
+		},
+		{
+			Name:     "closed-token",
+			Token:    "token",
+			Licenses: []crossref.WorkLicense{{URL: tdm, ContentVersion: crossref.ContentVersionTDM}},
+			URLs:     []string{"vor.pdf", "tdm.pdf", "am.pdf", "vor.unspecified"},
+		},
+	}
+	defer func() { TDMToken = "" }()
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			TDMToken = tt.Token
+			w := &crossref.Work{Link: links, License: tt.Licenses}
+			var urls []string
+			for _, c := range linkCandidates(w) {
+				urls = append(urls, c.link.URL)
+			}
+			assert.Equal(t, tt.URLs, urls)
+		})
+	}
+}
@@ -0,0 +1,472 @@
+package fetch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/filetype"
+	"github.com/Milover/fetchref/internal/metainfo"
+	"github.com/Milover/fetchref/internal/outfile"
+	"github.com/Milover/fetchref/internal/pdf"
+	"github.com/Milover/fetchref/internal/progress"
+	"github.com/Milover/fetchref/internal/sidecar"
+)
+
+var (
+	// MinDownloadSize is the minimum size (in bytes) of a downloaded file,
+	// smaller files are rejected.
+	MinDownloadSize int64 = 4096
+
+	// VerifyPDF controls whether downloaded PDFs are validated structurally,
+	// i.e., whether their cross-reference sections and trailer are parsed.
+	VerifyPDF = false
+
+	// TransferTimeout is the timeout for transferring a downloaded file,
+	// once the response headers have been received. Zero means no timeout.
+	TransferTimeout time.Duration = 0
+
+	// StallTimeout is the time after which a download is aborted if no
+	// data has been received. Zero means no timeout.
+	StallTimeout = 30 * time.Second
+
+	// EmbedMetadata controls whether the article metadata is embedded
+	// into downloaded PDFs.
+	EmbedMetadata = false
+
+	// WriteSidecar controls whether a sidecar file, holding the article
+	// metadata, is written next to each downloaded article.
+	WriteSidecar = false
+
+	// NoProgress controls whether to omit download progress bars.
+	NoProgress = false
+
+	// bars draws the download progress bars, it is nil if they are omitted.
+	bars *progress.Pool
+)
+
+var (
+	errHeaderTimeout   = errors.New("timed out waiting for response headers")
+	errTransferTimeout = errors.New("timed out transferring file")
+	errStalled         = errors.New("transfer stalled")
+)
+
+// startProgress starts drawing download progress bars, if stderr is
+// a terminal, and returns a function which stops drawing them.
+// Log output is routed through the progress bars while they are drawn.
+func startProgress() func() {
+	if NoProgress || !progress.IsTerminal(os.Stderr) {
+		return func() {}
+	}
+	bars = progress.New(os.Stderr)
+	w := log.Writer()
+	log.SetOutput(bars)
+	bars.Start()
+	return func() {
+		bars.Stop()
+		log.SetOutput(w)
+		bars = nil
+	}
+}
+
+// stallReader resets a stall timer whenever data is read.
+type stallReader struct {
+	r io.Reader
+	t *time.Timer
+	d time.Duration
+}
+
+func (s *stallReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if n > 0 {
+		s.t.Reset(s.d)
+	}
+	return n, err
+}
+
+// reqDownload downloads the article file and writes it to disc. The download
+// URL and the file name are retrieved from the Article.
+// The file is written to a '.part' file first, which is resumed if it
+// exists, was downloaded from the same URL, and the file has not changed
+// since (see partInfo), otherwise it is overwritten. The '.part' file is
+// renamed once the download is complete and the file has been checked.
+// The file name extension is chosen from the detected file type,
+// and conflicts with existing files are resolved according to OnConflict.
+func reqDownload(a *article.Article) error {
+	if a.Url == nil {
+		return fmt.Errorf("could not download article, URL empty")
+	}
+	name := pdfPath(a.GenerateFileName())
+	part := partName(a)
+	var off int64
+	var validator string
+	if fi, err := os.Stat(part); err == nil && fi.Size() > 0 {
+		info, err := readPartInfo(part)
+		if err == nil && info.URL == a.Url.String() {
+			if validator = info.validator(); len(validator) != 0 {
+				off = fi.Size()
+			}
+		}
+	}
+
+	ctx, cncl := context.WithCancelCause(context.Background())
+	defer cncl(nil)
+
+	res, err := reqDownloadResponse(ctx, cncl, a, off, validator)
+	if err != nil && off > 0 && res != nil &&
+		res.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		// the partial file is stale, start over
+		res.Body.Close()
+		off = 0
+		res, err = reqDownloadResponse(ctx, cncl, a, off, "")
+	}
+	if err != nil {
+		if res != nil {
+			res.Body.Close()
+		}
+		return cancelCause(ctx, err)
+	}
+	defer res.Body.Close()
+
+	total := res.ContentLength
+	if res.StatusCode == http.StatusPartialContent && off > 0 {
+		first, length, err := parseContentRange(res.Header.Get("Content-Range"))
+		if err != nil {
+			return err
+		}
+		if first != off {
+			return fmt.Errorf("could not resume download, bad range: %q",
+				res.Header.Get("Content-Range"))
+		}
+		total = length
+	} else {
+		off = 0
+	}
+
+	// the headers have arrived, time the transfer from here on
+	if TransferTimeout > 0 {
+		t := time.AfterFunc(TransferTimeout, func() { cncl(errTransferTimeout) })
+		defer t.Stop()
+	}
+	var r io.Reader = res.Body
+	if StallTimeout > 0 {
+		t := time.AfterFunc(StallTimeout, func() { cncl(errStalled) })
+		defer t.Stop()
+		r = &stallReader{r: r, t: t, d: StallTimeout}
+	}
+
+	// sniff the file type, servers often send HTML error pages
+	// or captchas in place of the article
+	body := bufio.NewReaderSize(r, filetype.SniffLen)
+	head, _ := body.Peek(filetype.SniffLen)
+	if off > 0 {
+		h, err := readHead(part)
+		if err != nil {
+			return err
+		}
+		head = append(h, head...)
+	}
+	typ := filetype.Detect(res.Header.Get("Content-Type"), head)
+	switch typ {
+	case filetype.HTML:
+		return fmt.Errorf("could not download article, got an HTML page")
+	case filetype.Unknown:
+		return fmt.Errorf("could not download article, unknown file type: %q",
+			res.Header.Get("Content-Type"))
+	}
+	dst, err := OnConflict.Reserve(name, typ.Extension(), articleKey(a))
+	if err != nil {
+		return err
+	}
+	defer outfile.Release(dst)
+
+	if err := os.MkdirAll(filepath.Dir(part), 0777); err != nil {
+		return err
+	}
+	if off == 0 {
+		// identify the file, so the download can be resumed safely
+		info := partInfo{
+			URL:          a.Url.String(),
+			ETag:         res.Header.Get("ETag"),
+			LastModified: res.Header.Get("Last-Modified"),
+		}
+		if err := writePartInfo(part, info); err != nil {
+			return err
+		}
+	}
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if off > 0 {
+		flag = os.O_WRONLY | os.O_APPEND
+	}
+	out, err := os.OpenFile(part, flag, 0666)
+	if err != nil {
+		return err
+	}
+	bar := bars.Add(filepath.Base(dst), total, off)
+	n, err := io.Copy(out, bar.Reader(body))
+	bars.Done(bar)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		// keep the partial file, so the download can be resumed
+		return cancelCause(ctx, err)
+	}
+
+	if off+n < MinDownloadSize {
+		removePart(part)
+		return fmt.Errorf("could not download article, file too small: %d B", off+n)
+	}
+	if typ == filetype.PDF && VerifyPDF {
+		if err := verifyPDF(part); err != nil {
+			removePart(part)
+			return fmt.Errorf("could not download article, %w", err)
+		}
+	}
+	if typ == filetype.PDF && EmbedMetadata {
+		// the article is kept as is, if the metadata cannot be embedded
+		if err := embedMetadata(part, a); err != nil {
+			log.Printf("%v: could not embed metadata: %v", a.Handle.Value, err)
+		}
+	}
+	if err := os.Rename(part, dst); err != nil {
+		return err
+	}
+	os.Remove(partInfoName(part))
+	a.File = dst
+
+	// record the license alongside the article, the name of which is
+	// resolved already, so OnConflict does not apply, and an existing
+	// license file, left behind by a replaced article, is overwritten
+	if len(a.License) != 0 {
+		err := outfile.WriteFile(
+			strings.TrimSuffix(dst, typ.Extension())+".license",
+			[]byte(a.License+"\n"),
+			0666)
+		if err != nil {
+			return err
+		}
+	}
+	if WriteSidecar {
+		return writeSidecar(a)
+	}
+	return nil
+}
+
+// embedMetadata embeds the article metadata into the PDF file, which is
+// rewritten atomically.
+func embedMetadata(name string, a *article.Article) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	m := pdf.Metadata{
+		Title:    a.Title,
+		DOI:      a.DOI,
+		Modified: time.Now(),
+	}
+	if a.Meta != nil {
+		for _, au := range a.Meta.Author {
+			m.Authors = append(m.Authors, strings.TrimSpace(au.Given+" "+au.Family+" "+au.Name))
+		}
+		if len(a.Meta.ContainerTitle) != 0 {
+			m.Journal = a.Meta.ContainerTitle[0]
+		}
+		m.Year = a.Meta.Year()
+		m.Keywords = a.Meta.Subject
+	}
+	if data, err = pdf.SetMetadata(data, m); err != nil {
+		return err
+	}
+	// an interrupted rewrite must not leave a truncated file behind,
+	// which would be resumed by the next run
+	return outfile.WriteFile(name, data, 0666)
+}
+
+// writeSidecar writes the sidecar file of the downloaded article, next to
+// the article file (see sidecar.Path).
+func writeSidecar(a *article.Article) error {
+	sum, size, err := sidecar.HashFile(a.File)
+	if err != nil {
+		return err
+	}
+	s := sidecar.Sidecar{
+		Handle:     a.Handle.Value,
+		HandleType: a.Handle.Type.String(),
+		DOI:        a.DOI,
+		Title:      a.Title,
+		File:       filepath.Base(a.File),
+		Size:       size,
+		SHA256:     sum,
+		Source:     a.Source,
+		License:    a.License,
+		Retrieved:  time.Now().UTC().Truncate(time.Second),
+		Version:    metainfo.Version,
+		Meta:       a.Meta,
+	}
+	if a.Url != nil {
+		s.SourceURL = a.Url.String()
+	}
+	// the document name is resolved already, an existing sidecar
+	// belongs to a replaced document, and is overwritten
+	return sidecar.Write(sidecar.Path(a.File), s)
+}
+
+// partName returns the name of the partial download file of the article.
+// The name includes the article key hash, so that articles with the same
+// file name do not share partial downloads.
+func partName(a *article.Article) string {
+	return pdfPath(a.GenerateFileName()) + "." + outfile.HashKey(articleKey(a)) + ".part"
+}
+
+// partInfo identifies the file of which a '.part' file is a part, i.e.,
+// the URL from which it is downloaded, and the file's entity tag and last
+// modification time, as reported by the server. It is stored next to the
+// '.part' file.
+type partInfo struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// validator returns the If-Range validator of the file, i.e., its strong
+// entity tag, or its last modification time, or an empty string if it has
+// neither, in which case the download cannot be resumed.
+func (p partInfo) validator() string {
+	if len(p.ETag) != 0 && !strings.HasPrefix(p.ETag, "W/") {
+		return p.ETag
+	}
+	return p.LastModified
+}
+
+// partInfoName returns the name of the file holding the partInfo of
+// the '.part' file.
+func partInfoName(part string) string {
+	return part + ".info"
+}
+
+// readPartInfo reads the partInfo of the '.part' file.
+func readPartInfo(part string) (partInfo, error) {
+	var p partInfo
+	data, err := os.ReadFile(partInfoName(part))
+	if err != nil {
+		return p, err
+	}
+	err = json.Unmarshal(data, &p)
+	return p, err
+}
+
+// writePartInfo writes the partInfo of the '.part' file, or removes it,
+// if the file has no validator.
+func writePartInfo(part string, p partInfo) error {
+	if len(p.validator()) == 0 {
+		if err := os.Remove(partInfoName(part)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(partInfoName(part), data, 0666)
+}
+
+// removePart removes the '.part' file and its partInfo.
+func removePart(part string) {
+	os.Remove(part)
+	os.Remove(partInfoName(part))
+}
+
+// reqDownloadResponse sends the download request, asking for the file
+// from offset off on, if it is positive, provided that the file still
+// matches the validator (see partInfo.validator), and waits for the response
+// headers for at most GlobalReqTimeout, after which ctx is cancelled.
+func reqDownloadResponse(
+	ctx context.Context,
+	cncl context.CancelCauseFunc,
+	a *article.Article,
+	off int64,
+	validator string,
+) (*http.Response, error) {
+	header := a.Header.Clone()
+	if off > 0 {
+		if header == nil {
+			header = make(http.Header)
+		}
+		header.Set("Range", fmt.Sprintf("bytes=%d-", off))
+		header.Set("If-Range", validator)
+	}
+	t := time.AfterFunc(GlobalReqTimeout, func() { cncl(errHeaderTimeout) })
+	defer t.Stop()
+	return sendGetRequestHeader(ctx, a.Url.String(), header)
+}
+
+// cancelCause returns the cause of the cancellation of ctx in place of err,
+// if ctx was cancelled.
+func cancelCause(ctx context.Context, err error) error {
+	if c := context.Cause(ctx); c != nil {
+		return c
+	}
+	return err
+}
+
+// parseContentRange parses a 'bytes first-last/length' Content-Range header
+// value and returns the first byte position and the complete length, which
+// is negative if unknown.
+func parseContentRange(s string) (int64, int64, error) {
+	rng, length, ok := strings.Cut(strings.TrimPrefix(s, "bytes "), "/")
+	first, _, ok2 := strings.Cut(rng, "-")
+	if !strings.HasPrefix(s, "bytes ") || !ok || !ok2 {
+		return 0, 0, fmt.Errorf("bad Content-Range: %q", s)
+	}
+	f, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("bad Content-Range: %q", s)
+	}
+	if length == "*" {
+		return f, -1, nil
+	}
+	l, err := strconv.ParseInt(length, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("bad Content-Range: %q", s)
+	}
+	return f, l, nil
+}
+
+// readHead reads the leading bytes of a file, needed for sniffing
+// its type.
+func readHead(name string) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	head := make([]byte, filetype.SniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return head[:n], nil
+}
+
+// verifyPDF validates the structure of the PDF file.
+func verifyPDF(name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	return pdf.Validate(data)
+}
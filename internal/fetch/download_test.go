@@ -0,0 +1,255 @@
+package fetch
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/outfile"
+	"github.com/Milover/fetchref/internal/sidecar"
+	"github.com/stretchr/testify/assert"
+)
+
+// testPDF is a (fake) PDF file, large enough to be accepted.
+var testPDF = append([]byte("%PDF-1.4\n"), bytes.Repeat([]byte("0123456789\n"), 1000)...)
+
+// testETag is the entity tag of testPDF.
+const testETag = `"v1"`
+
+// newDownloadStandIn starts a local file server serving testPDF, with
+// support for Range requests, and an HTML page.
+func newDownloadStandIn(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/article.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("ETag", testETag)
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(testPDF))
+	})
+	mux.HandleFunc("/captcha", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("<!DOCTYPE html><html>" + strings.Repeat(" ", 8192) + "</html>"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestReqDownload(t *testing.T) {
+	assert := assert.New(t)
+	srv := newDownloadStandIn(t)
+	dir := t.TempDir()
+
+	// a partial download which differs from testPDF, so that resuming
+	// it, rather than starting over, can be told apart
+	marked := append([]byte("%PDF-1.4\n"), bytes.Repeat([]byte("x"), 4991)...)
+	spliced := append(append([]byte(nil), marked...), testPDF[len(marked):]...)
+
+	var tests = []struct {
+		Name  string
+		Path  string
+		Part  []byte    // existing partial download
+		Info  *partInfo // of the partial download, the URL is the path
+		Data  []byte    // expected file data, if not testPDF
+		Error bool
+	}{
+		{
+			Name: "fresh",
+			Path: "/article.pdf",
+		},
+		{
+			Name: "resume",
+			Path: "/article.pdf",
+			Part: testPDF[:5000],
+			Info: &partInfo{URL: "/article.pdf", ETag: testETag},
+		},
+		{
+			Name: "resume-marked",
+			Path: "/article.pdf",
+			Part: marked,
+			Info: &partInfo{URL: "/article.pdf", ETag: testETag},
+			Data: spliced,
+		},
+		{
+			Name: "other-url",
+			Path: "/article.pdf",
+			Part: marked,
+			Info: &partInfo{URL: "/other.pdf", ETag: testETag},
+		},
+		{
+			Name: "changed-file",
+			Path: "/article.pdf",
+			Part: marked,
+			Info: &partInfo{URL: "/article.pdf", ETag: `"v0"`},
+		},
+		{
+			Name: "weak-etag",
+			Path: "/article.pdf",
+			Part: marked,
+			Info: &partInfo{URL: "/article.pdf", ETag: "W/" + testETag},
+		},
+		{
+			Name: "no-info",
+			Path: "/article.pdf",
+			Part: marked,
+		},
+		{
+			Name: "stale-part",
+			Path: "/article.pdf",
+			Part: append(append([]byte(nil), testPDF...), "junk"...),
+			Info: &partInfo{URL: "/article.pdf", ETag: testETag},
+		},
+		{
+			Name:  "html",
+			Path:  "/captcha",
+			Error: true,
+		},
+	}
+	for _, tt := range tests {
+		name := filepath.Join(dir, tt.Name)
+		u, _ := url.Parse(srv.URL + tt.Path)
+		a := article.Article{Url: u}
+		a.GeneratorFunc(func(*article.Article) string { return name })
+		if tt.Part != nil {
+			assert.Nil(os.WriteFile(partName(&a), tt.Part, 0666), tt.Name)
+		}
+		if tt.Info != nil {
+			info := *tt.Info
+			info.URL = srv.URL + info.URL
+			assert.Nil(writePartInfo(partName(&a), info), tt.Name)
+		}
+
+		err := reqDownload(&a)
+		if tt.Error {
+			assert.NotNil(err, tt.Name)
+			assert.NoFileExists(name+".pdf", tt.Name)
+			continue
+		}
+		assert.Nil(err, tt.Name)
+		assert.Equal(name+".pdf", a.File, tt.Name)
+		data, _ := os.ReadFile(a.File)
+		if tt.Data == nil {
+			tt.Data = testPDF
+		}
+		assert.Equal(tt.Data, data, tt.Name)
+		assert.NoFileExists(partName(&a), tt.Name)
+		assert.NoFileExists(partInfoName(partName(&a)), tt.Name)
+	}
+}
+
+func TestWriteSidecar(t *testing.T) {
+	assert := assert.New(t)
+	srv := newDownloadStandIn(t)
+	WriteSidecar = true
+	defer func() { WriteSidecar = false }()
+
+	name := filepath.Join(t.TempDir(), "article")
+	u, _ := url.Parse(srv.URL + "/article.pdf")
+	a := article.Article{
+		Handle:  article.Handle{Type: article.DOI, Value: "10.1000/182"},
+		DOI:     "10.1000/182",
+		Url:     u,
+		Source:  "test",
+		License: "https://creativecommons.org/licenses/by/4.0/",
+	}
+	a.GeneratorFunc(func(*article.Article) string { return name })
+	// a separate CSL-JSON citation file of the article
+	assert.Nil(os.WriteFile(name+".json", []byte("[]\n"), 0666))
+	assert.Nil(reqDownload(&a))
+
+	data, err := os.ReadFile(name + ".json")
+	assert.Nil(err)
+	assert.Equal("[]\n", string(data))
+	s, err := sidecar.Read(name + sidecar.Extension)
+	assert.Nil(err)
+	assert.Equal("article.pdf", s.File)
+	assert.Equal(int64(len(testPDF)), s.Size)
+	assert.Equal(fmt.Sprintf("%x", sha256.Sum256(testPDF)), s.SHA256)
+	assert.Equal("test", s.Source)
+	assert.Equal(u.String(), s.SourceURL)
+	assert.Equal(a.License, s.License)
+	assert.Equal("doi", s.HandleType)
+}
+
+func TestParseContentRange(t *testing.T) {
+	assert := assert.New(t)
+	var tests = []struct {
+		Input  string
+		First  int64
+		Length int64
+		Error  bool
+	}{
+		{Input: "bytes 100-199/200", First: 100, Length: 200},
+		{Input: "bytes 100-199/*", First: 100, Length: -1},
+		{Input: "bytes */200", Error: true},
+		{Input: "100-199/200", Error: true},
+	}
+	for _, tt := range tests {
+		first, length, err := parseContentRange(tt.Input)
+		assert.Equal(tt.Error, err != nil, tt.Input)
+		if !tt.Error {
+			assert.Equal(tt.First, first, tt.Input)
+			assert.Equal(tt.Length, length, tt.Input)
+		}
+	}
+}
+
+func TestEmbedMetadata(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	name := filepath.Join(dir, "article.pdf.part")
+	orig := textPDF("Observation of Gravitational Waves")
+	assert.Nil(os.WriteFile(name, orig, 0666))
+
+	a := &article.Article{Title: "Observation of Gravitational Waves", DOI: "10.1103/PhysRevLett.116.061102"}
+	assert.Nil(embedMetadata(name, a))
+	data, err := os.ReadFile(name)
+	assert.Nil(err)
+	assert.True(bytes.HasPrefix(data, orig))
+	assert.Contains(string(data), "10.1103/PhysRevLett.116.061102")
+
+	// the file is replaced, i.e., no temporary files are left behind
+	es, err := os.ReadDir(dir)
+	assert.Nil(err)
+	assert.Len(es, 1)
+}
+
+func TestWriteSidecarConflict(t *testing.T) {
+	srv := newDownloadStandIn(t)
+	WriteSidecar = true
+	defer func() { WriteSidecar, OnConflict = false, outfile.Overwrite }()
+
+	for _, p := range []outfile.Policy{outfile.Suffix, outfile.Skip} {
+		t.Run(p.String(), func(t *testing.T) {
+			assert := assert.New(t)
+			OnConflict = p
+			dir := t.TempDir()
+			name := filepath.Join(dir, "article")
+			u, _ := url.Parse(srv.URL + "/article.pdf")
+			a := article.Article{
+				Handle: article.Handle{Type: article.DOI, Value: "10.1000/182"},
+				Url:    u,
+			}
+			a.GeneratorFunc(func(*article.Article) string { return name })
+			// a stale sidecar, its document is gone
+			assert.Nil(os.WriteFile(name+sidecar.Extension, []byte("{}\n"), 0666))
+			assert.Nil(reqDownload(&a))
+
+			assert.Equal(name+".pdf", a.File)
+			s, err := sidecar.Read(sidecar.Path(a.File))
+			if assert.Nil(err) {
+				assert.Equal("article.pdf", s.File)
+			}
+			matches, _ := filepath.Glob(filepath.Join(dir, "*"+sidecar.Extension))
+			assert.Equal([]string{name + sidecar.Extension}, matches)
+		})
+	}
+}
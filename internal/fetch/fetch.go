@@ -9,16 +9,20 @@ import (
 	"log"
 	"net/http"
 	"net/url"
-	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
 	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/arxiv"
 	"github.com/Milover/fetchref/internal/crossref"
 	"github.com/Milover/fetchref/internal/doiorg"
 	"github.com/Milover/fetchref/internal/isbn"
+	"github.com/Milover/fetchref/internal/issn"
 	"github.com/Milover/fetchref/internal/libgen"
 	"github.com/Milover/fetchref/internal/metainfo"
+	"github.com/Milover/fetchref/internal/outfile"
+	"github.com/Milover/fetchref/internal/pubmed"
 	"go.uber.org/ratelimit"
 	"golang.org/x/net/html"
 	"golang.org/x/sync/errgroup"
@@ -49,6 +53,29 @@ var (
 	// HTTP requests.
 	NoUserAgent = false
 
+	// NameTemplate generates the (base) names of article and separate
+	// citation files.
+	NameTemplate = article.DefaultNameTemplate
+
+	// Layout is the directory layout preset, which files articles and
+	// separate citations into subdirectories.
+	Layout = article.Flat
+
+	// OutputDir is the directory into which all files are written.
+	OutputDir string
+
+	// PDFDir is the directory into which articles are written,
+	// it overrides OutputDir.
+	PDFDir string
+
+	// CiteDir is the directory into which citations are written,
+	// it overrides OutputDir.
+	CiteDir string
+
+	// OnConflict is the policy for resolving conflicts with existing
+	// output files.
+	OnConflict = outfile.Overwrite
+
 	// A list of Sci-Hub mirrors.
 	mirrors = []string{
 		"sci-hub.se",
@@ -67,19 +94,31 @@ const (
 	CiteMode
 )
 
-// Fetch downloads articles from Sci-Hub and/or citations from Crossref,
-// from a list of supplied handles (DOIs and/or ISBNs).
+// Fetch downloads articles from the enabled download sources and/or
+// citations from Crossref, from a list of supplied handles (DOIs, ISBNs...).
+// Works which have been retracted, corrected or are subject to an expression
+// of concern are reported after the run, and their citations are annotated.
+// If References is set, the works referenced by the articles are fetched
+// as well (see expandReferences).
 func Fetch(mode FetchMode, handles []string) error {
 	if len(handles) == 0 {
 		return nil
 	}
+	tmpl := NameTemplate.WithLayout(Layout)
+	if err := tmpl.Validate(); err != nil {
+		return err
+	}
 	valid := validHandles(handles)
 	articles := make([]article.Article, 0, len(valid))
 	for i := range valid {
+		if valid[i].Type == article.ISSN {
+			log.Printf("%v: ISSNs are only supported by meta and search",
+				valid[i].Value)
+			continue
+		}
 		articles = append(articles, article.Article{Handle: valid[i]})
-		a := &articles[i]
-		// FIXME: the generator should be configurable
-		a.GeneratorFunc(article.SnakeCaseGenerator)
+		a := &articles[len(articles)-1]
+		a.GeneratorFunc(tmpl.Generate)
 	}
 	// fetch article metadata
 	// FIXME: this could be done better:
@@ -97,6 +136,10 @@ func Fetch(mode FetchMode, handles []string) error {
 	if err := fetchMetas(articles); err != nil {
 		log.Println("errors occurred during metadata fetch")
 	}
+	if References > 0 {
+		articles = expandReferences(articles, tmpl.Generate)
+	}
+	fetchUpdates(articles)
 
 	// fetch articles and citations
 	g := new(errgroup.Group)
@@ -110,11 +153,16 @@ func Fetch(mode FetchMode, handles []string) error {
 			return fetchCitations(articles)
 		})
 	}
-	return g.Wait()
+	err := g.Wait()
+	err = errors.Join(err, recordArticles(articles))
+	if logUpdates(articles) && FailOnRetraction {
+		err = errors.Join(err, ErrRetracted)
+	}
+	return err
 }
 
-// validHandles selects valid handles (DOI, ISBN...) from a list of handles
-// and returns a list of properly typed, valid handles.
+// validHandles selects valid handles (DOI, ISBN, ISSN, arXiv, PMID...) from
+// a list of handles and returns a list of properly typed, valid handles.
 func validHandles(handles []string) []article.Handle {
 	var wg sync.WaitGroup
 	ch := make(chan article.Handle, len(handles))
@@ -125,6 +173,22 @@ func validHandles(handles []string) []article.Handle {
 			valid = append(valid, article.Handle{
 				Value: isbn.Clean(h),
 				Type:  article.ISBN})
+		} else if arxiv.IsValid(h) {
+			valid = append(valid, article.Handle{
+				Value: arxiv.Clean(h),
+				Type:  article.ArXiv})
+		} else if pubmed.IsPMCID(h) {
+			valid = append(valid, article.Handle{
+				Value: pubmed.CleanPMCID(h),
+				Type:  article.PMCID})
+		} else if pubmed.IsPMID(h) {
+			valid = append(valid, article.Handle{
+				Value: pubmed.CleanPMID(h),
+				Type:  article.PMID})
+		} else if issn.IsHandle(h) {
+			valid = append(valid, article.Handle{
+				Value: issn.Clean(h),
+				Type:  article.ISSN})
 		} else {
 			wg.Add(1)
 			go func() {
@@ -184,10 +248,24 @@ func fetchMetas(articles []article.Article) error {
 					log.Printf("%v: could not set DOI", a.Handle.Value)
 				}
 			}()
+			// GET metadata from arXiv, and set the article title
+			if a.Handle.Type == article.ArXiv {
+				return logErr(a.Handle.Value, reqArXivMeta(a))
+			}
+			// GET metadata from PubMed, and set the article title
+			if a.Handle.Type == article.PMID || a.Handle.Type == article.PMCID {
+				return logErr(a.Handle.Value, reqPubMedMeta(a))
+			}
 			// GET metadata from Crossref, and set the article title
 			meta, err := reqCrossrefMeta(a)
 			//fmt.Printf("meta:\n%+v\n", meta)
 			if err != nil {
+				// fall back to the publisher's landing page
+				if a.Handle.Type == article.DOI && reqLandingMeta(a) == nil {
+					log.Printf("%v: metadata from publisher landing page",
+						a.Handle.Value)
+					return nil
+				}
 				return logErr(a.Handle.Value, err)
 			}
 			// XXX: is it ok to assume that the first item is the one we want?
@@ -195,6 +273,7 @@ func fetchMetas(articles []article.Article) error {
 				a.Title = meta.Title[0]
 			}
 			a.DOI = meta.DOI
+			a.Meta = &meta
 			return nil
 		})
 	}
@@ -203,19 +282,18 @@ func fetchMetas(articles []article.Article) error {
 
 // WARNING: assumes that articles have DOIs and generator functions set.
 func fetchArticles(articles []article.Article) error {
+	stop := startProgress()
+	defer stop()
+
 	g := new(errgroup.Group)
 
 	for i := range articles {
 		a := &articles[i]
 
-		// GET article info
+		// GET article from the first source which has it
 		g.Go(func() error {
-			// GET PDF download link from Sci-Hub
-			if err := logErr(a.Handle.Value, reqArticleInfo(a)); err != nil {
-				return err
-			}
-			// GET article PDF
-			return logErr(a.Handle.Value, reqDownload(a))
+			return logErr(a.Handle.Value,
+				downloadFromSources(context.Background(), a))
 		})
 	}
 	return g.Wait()
@@ -229,7 +307,16 @@ func fetchCitations(articles []article.Article) error {
 		a := &articles[i]
 
 		g.Go(func() error {
-			return logErr(a.Handle.Value, reqCrossrefCitation(a))
+			var err error
+			if a.Handle.Type == article.ArXiv {
+				err = reqArXivCitation(a)
+			} else {
+				err = reqCrossrefCitation(a)
+			}
+			if err != nil {
+				return logErr(a.Handle.Value, err)
+			}
+			return logErr(a.Handle.Value, annotateCitation(a))
 		})
 	}
 	err := g.Wait()
@@ -246,21 +333,13 @@ func logErr(doi string, err error) error {
 	return err
 }
 
-func openCiteFile(filename string) (*os.File, error) {
-	flag := os.O_RDWR | os.O_CREATE
-	if CiteAppend {
-		flag |= os.O_APPEND
-	} else {
-		flag |= os.O_TRUNC
-	}
-	return os.OpenFile(filename, flag, 0666)
-}
-
-// writeCitations writes all citations to a file.
+// writeCitations writes all citations to a file, or to separate files.
+// Files are written atomically, and conflicts with existing files are
+// resolved according to OnConflict, unless citations are appended.
 func writeCitations(articles []article.Article) error {
-	var out *os.File
-	var err error
-	for i, a := range articles {
+	var all []byte
+	for i := range articles {
+		a := &articles[i]
 		if len(a.Citation) == 0 {
 			continue
 		}
@@ -268,34 +347,117 @@ func writeCitations(articles []article.Article) error {
 		if a.Citation[len(a.Citation)-1] != '\n' {
 			a.Citation = append(a.Citation, '\n')
 		}
-
-		if CiteSeparate {
-			out, err = openCiteFile(a.GenerateFileName() + CiteFormat.Extension())
-		} else if i == 0 { // open/close only once
-			out, err = openCiteFile(CiteFileName + CiteFormat.Extension())
-			defer out.Close()
+		if !CiteSeparate {
+			all = append(all, a.Citation...)
+			continue
 		}
-		if err != nil {
-			return err
+		out, err := writeOutput(citePath(a.GenerateFileName()), CiteFormat.Extension(),
+			articleKey(a), a.Citation)
+		a.CiteFile = out
+		if errors.Is(err, outfile.ErrExists) {
+			log.Printf("%v: %v, skipped", a.Handle.Value, err)
+			continue
 		}
-
-		if _, err := out.Write(a.Citation); err != nil {
+		if err != nil {
 			return err
 		}
-		if CiteSeparate {
-			out.Close()
+	}
+	if CiteSeparate || len(all) == 0 {
+		return nil
+	}
+	var out string
+	var err error
+	if CiteAppend {
+		out = citePath(CiteFileName) + CiteFormat.Extension()
+		err = outfile.AppendFile(out, all, 0666)
+	} else {
+		out, err = writeOutput(citePath(CiteFileName), CiteFormat.Extension(), string(all), all)
+	}
+	if errors.Is(err, outfile.ErrExists) {
+		log.Printf("%v, skipped", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for i := range articles {
+		if len(articles[i].Citation) != 0 {
+			articles[i].CiteFile = out
 		}
 	}
 	return nil
 }
 
+// writeOutput atomically writes data to the file name+ext, or to another
+// file chosen according to OnConflict, if the file exists, and returns
+// the name of the written file.
+// The key identifies the data, see outfile.Policy.Reserve.
+func writeOutput(name, ext, key string, data []byte) (string, error) {
+	out, err := OnConflict.Reserve(name, ext, key)
+	if err != nil {
+		return "", err
+	}
+	defer outfile.Release(out)
+	if err := outfile.WriteFile(out, data, 0666); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// pdfPath returns the path of the article file name, in the article
+// output directory, unless the file name is absolute.
+func pdfPath(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	if len(PDFDir) != 0 {
+		return filepath.Join(PDFDir, name)
+	}
+	return filepath.Join(OutputDir, name)
+}
+
+// citePath returns the path of the citation file name, in the citation
+// output directory, unless the file name is absolute.
+func citePath(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	if len(CiteDir) != 0 {
+		return filepath.Join(CiteDir, name)
+	}
+	return filepath.Join(OutputDir, name)
+}
+
+// articleKey returns the key identifying the article, i.e., its DOI,
+// or its handle if the DOI is not known.
+func articleKey(a *article.Article) string {
+	if len(a.DOI) != 0 {
+		return a.DOI
+	}
+	return a.Handle.Value
+}
+
 // sendGetRequest sends a GET request to the specified URL.
 // An error is returned if a valid response cannot be obtained.
 func sendGetRequest(ctx context.Context, url string) (*http.Response, error) {
+	return sendGetRequestHeader(ctx, url, nil)
+}
+
+// sendGetRequestHeader sends a GET request, with additional headers,
+// to the specified URL.
+// An error is returned if a valid response cannot be obtained.
+func sendGetRequestHeader(
+	ctx context.Context,
+	url string,
+	header http.Header,
+) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
 	if !NoUserAgent {
 		req.Header.Set("User-Agent", metainfo.HTTPUserAgent)
 	}
@@ -303,7 +465,7 @@ func sendGetRequest(ctx context.Context, url string) (*http.Response, error) {
 	// sendGetRequest can be called from multiple threads
 	GlobalRateLimiter.Take()
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := client.Do(req)
 	if err != nil {
 		return res, err
 	}
@@ -317,7 +479,7 @@ func sendGetRequest(ctx context.Context, url string) (*http.Response, error) {
 
 // reqSciHubMirrorInfo requests article info from a Sci-Hub mirror
 // and parses the article title and download URL from the response HTML.
-func reqSciHubMirrorInfo(a *article.Article, mirror string) error {
+func reqSciHubMirrorInfo(ctx context.Context, a *article.Article, mirror string) error {
 	if len(a.DOI) == 0 {
 		return fmt.Errorf("cannot retrieve article info, DOI not set")
 	}
@@ -327,7 +489,7 @@ func reqSciHubMirrorInfo(a *article.Article, mirror string) error {
 		Path:   a.DOI,
 	}
 
-	ctx, cncl := context.WithTimeout(context.Background(), GlobalReqTimeout)
+	ctx, cncl := context.WithTimeout(ctx, GlobalReqTimeout)
 	defer cncl()
 
 	res, err := sendGetRequest(ctx, u.String())
@@ -362,7 +524,7 @@ func reqSciHubMirrorInfo(a *article.Article, mirror string) error {
 
 // reqLibgenMirrorInfo requests article info from a Sci-Hub mirror
 // and parses the article title and download URL from the response HTML.
-func reqLibgenMirrorInfo(a *article.Article, mirror string) error {
+func reqLibgenMirrorInfo(ctx context.Context, a *article.Article, mirror string) error {
 	u := &url.URL{
 		Scheme: "https",
 		Host:   mirror,
@@ -374,7 +536,7 @@ func reqLibgenMirrorInfo(a *article.Article, mirror string) error {
 	query.Add(libgen.QueryKeyISBN, a.Handle.Value)
 	u.RawQuery = query.Encode()
 
-	ctx, cncl := context.WithTimeout(context.Background(), GlobalReqTimeout)
+	ctx, cncl := context.WithTimeout(ctx, GlobalReqTimeout)
 	defer cncl()
 
 	res, err := sendGetRequest(ctx, u.String())
@@ -409,67 +571,6 @@ func reqLibgenMirrorInfo(a *article.Article, mirror string) error {
 	return nil
 }
 
-// reqArticleInfo requests article info from Sci-Hub, and updates the article
-// if successful. On a failed request, another Sci-Hub mirror is chosen,
-// until all mirrors have been exhausted.
-func reqArticleInfo(a *article.Article) error {
-	var mrs []string
-	var reqFn func(*article.Article, string) error
-	switch a.Handle.Type {
-	case article.ISBN:
-		mrs = libgen.Mirrors
-		reqFn = reqLibgenMirrorInfo
-	case article.DOI:
-		mrs = mirrors
-		reqFn = reqSciHubMirrorInfo
-	default:
-		return fmt.Errorf("unknown article handle type: %v", a.Handle.Type)
-	}
-	for i, m := range mrs {
-		if err := reqFn(a, m); err != nil {
-			log.Printf("%v: %v", a.Handle.Value, err)
-			// fail if there are no more mirrors to try
-			if i == len(mirrors)-1 {
-				return fmt.Errorf("could not get article info")
-			}
-		} else {
-			break
-		}
-	}
-	return nil
-}
-
-// downloadArticle downloads the article and writes a PDF to disc. The download
-// URL and the file name are retrieved from the Article.
-func reqDownload(a *article.Article) error {
-	ctx, cncl := context.WithTimeout(context.Background(), GlobalReqTimeout)
-	defer cncl()
-
-	if a.Url == nil {
-		return fmt.Errorf("could not download article, URL empty")
-	}
-	res, err := sendGetRequest(ctx, a.Url.String())
-	if err != nil {
-		return err
-	}
-	defer res.Body.Close()
-
-	// FIXME: we don't know if it will be a PDF
-	//fmt.Printf("article:\n %+v\n", *a)
-	out, err := os.Create(a.GenerateFileName() + ".pdf")
-	if err != nil {
-		panic(err)
-	}
-	defer out.Close()
-
-	if _, err := io.Copy(out, res.Body); err != nil {
-		os.Remove(out.Name())
-		return err
-	}
-
-	return nil
-}
-
 // reqCrossrefCitation requests the article citation from Crossref
 // FIXME: probably doesn't work for ISBNs
 func reqCrossrefCitation(a *article.Article) error {
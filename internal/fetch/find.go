@@ -0,0 +1,346 @@
+package fetch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/bib"
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/Milover/fetchref/internal/doi"
+	"github.com/Milover/fetchref/internal/index"
+	"github.com/Milover/fetchref/internal/library"
+	"github.com/Milover/fetchref/internal/pdf"
+	"github.com/Milover/fetchref/internal/sidecar"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	// FindFmt is the output format of the search results of Find.
+	FindFmt = FindTable
+
+	// FindLimit is the maximum number of search results, 0 for unlimited.
+	FindLimit = 20
+
+	// FindReindex controls whether all documents are reindexed, rather than
+	// only new and modified documents.
+	FindReindex = false
+
+	// FindNoMeta controls whether to omit requesting Crossref metadata
+	// of indexed documents.
+	FindNoMeta = false
+
+	// findWorkers is the number of documents indexed concurrently.
+	findWorkers = 8
+)
+
+// FindFormat is the output format of search results.
+type FindFormat int
+
+const (
+	FindTable FindFormat = iota
+	FindJSON
+	FindBibTeX
+)
+
+var (
+	// findFormatNames are the user-friendly FindFormat names.
+	findFormatNames = [...]string{
+		"table",
+		"json",
+		"bibtex",
+	}
+)
+
+// Set sets the format from its name.
+func (f *FindFormat) Set(name string) error {
+	for i, n := range findFormatNames {
+		if strings.EqualFold(name, n) {
+			*f = FindFormat(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format: %v, expected one of: %v",
+		name, strings.Join(findFormatNames[:], ", "))
+}
+
+// String returns the format name.
+func (f FindFormat) String() string {
+	return findFormatNames[f]
+}
+
+// Type returns the format type name.
+func (f FindFormat) Type() string {
+	return "string"
+}
+
+// findHit is a search result, as written by Find in JSON format.
+type findHit struct {
+	Path  string         `json:"path"`
+	Score float64        `json:"score"`
+	DOI   string         `json:"doi,omitempty"`
+	Title string         `json:"title,omitempty"`
+	Year  int            `json:"year,omitempty"`
+	Meta  *crossref.Work `json:"metadata,omitempty"`
+}
+
+// Find updates the index of the documents (PDFs) in the directory tree dir,
+// and writes the documents matching the query to w, as a table, JSON Lines
+// or BibTeX, depending on FindFmt. See index.ParseQuery for the query
+// syntax.
+func Find(w io.Writer, dir string, query []string) error {
+	q, err := index.ParseQuery(strings.Join(query, " "))
+	if err != nil {
+		return err
+	}
+	ix, err := updateIndex(dir)
+	if err != nil {
+		return err
+	}
+	hits := ix.Search(q)
+	if FindLimit > 0 && len(hits) > FindLimit {
+		hits = hits[:FindLimit]
+	}
+
+	switch FindFmt {
+	case FindJSON:
+		enc := json.NewEncoder(w)
+		for _, h := range hits {
+			err := enc.Encode(findHit{
+				Path:  filepath.Join(dir, h.Doc.Path),
+				Score: h.Score,
+				DOI:   h.Doc.DOI,
+				Title: h.Doc.Title,
+				Year:  h.Doc.Year,
+				Meta:  h.Doc.Meta,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case FindBibTeX:
+		var entries []bib.Entry
+		for _, h := range hits {
+			entries = append(entries, docEntry(dir, h.Doc))
+		}
+		return bib.WriteBibTeX(w, entries)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SCORE\tYEAR\tDOI\tTITLE\tPATH")
+	for _, h := range hits {
+		year := ""
+		if h.Doc.Year > 0 {
+			year = strconv.Itoa(h.Doc.Year)
+		}
+		title := []rune(h.Doc.Title)
+		if len(title) > LibTitleWidth {
+			title = append(title[:LibTitleWidth-1], '…')
+		}
+		fmt.Fprintf(tw, "%.2f\t%v\t%v\t%v\t%v\n",
+			h.Score, year, h.Doc.DOI, string(title), filepath.Join(dir, h.Doc.Path))
+	}
+	return tw.Flush()
+}
+
+// docEntry returns the bibliography entry of an indexed document, with
+// the document file recorded in the 'file' field.
+func docEntry(dir string, d *index.Doc) bib.Entry {
+	var e bib.Entry
+	if d.Meta != nil {
+		e = bib.FromWork(*d.Meta)
+	} else {
+		w := crossref.Work{DOI: d.DOI, Title: []string{d.Title}}
+		for _, a := range d.Authors {
+			w.Author = append(w.Author, crossref.Author{Name: a})
+		}
+		if d.Year > 0 {
+			w.Issued.DateParts = [][]int{{d.Year}}
+		}
+		e = bib.FromWork(w)
+		if len(e.Key) == 0 {
+			e.Key = bib.KeyFromDOI(d.Path)
+		}
+	}
+	e.Set("file", filepath.Join(dir, d.Path))
+	return e
+}
+
+// updateIndex loads the index of the directory tree dir, and (re)indexes
+// new and modified documents, and documents whose metadata could not be
+// requested previously. Documents which no longer exist are removed from
+// the index. The index is saved if it was modified.
+func updateIndex(dir string) (*index.Index, error) {
+	ix, err := index.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	lib := findLibrary()
+
+	// docs are put into the index once all workers are done, because
+	// the walk reads the index concurrently
+	var mu sync.Mutex
+	var docs []*index.Doc
+	keep := make(map[string]bool)
+	g := new(errgroup.Group)
+	g.SetLimit(findWorkers)
+	err = filepath.WalkDir(dir, func(path string, de fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if de.IsDir() {
+			if path != dir && strings.HasPrefix(de.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(path), ".pdf") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		keep[rel] = true
+		fi, err := de.Info()
+		if err != nil {
+			return err
+		}
+		old := ix.Docs[rel]
+		stale := FindReindex || ix.Stale(rel, fi)
+		retryMeta := !stale && !FindNoMeta && old.Meta == nil && len(old.DOI) != 0
+		if !stale && !retryMeta {
+			return nil
+		}
+		g.Go(func() error {
+			var d *index.Doc
+			if stale {
+				d = indexDoc(path, rel, fi, lib)
+			} else {
+				cp := *old
+				d = &cp
+			}
+			if d.Meta == nil && len(d.DOI) != 0 && !FindNoMeta {
+				a := article.Article{
+					Handle: article.Handle{Type: article.DOI, Value: d.DOI},
+					DOI:    d.DOI,
+				}
+				if w, err := reqCrossrefMeta(&a); err != nil {
+					log.Printf("%v: could not get metadata: %v", path, err)
+				} else {
+					d.SetMeta(&w)
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			docs = append(docs, d)
+			return nil
+		})
+		return nil
+	})
+	if err = errors.Join(err, g.Wait()); err != nil {
+		return nil, err
+	}
+	for _, d := range docs {
+		ix.Put(d)
+	}
+	if ix.Prune(keep) != 0 || len(docs) != 0 {
+		if err := ix.Save(); err != nil {
+			return nil, err
+		}
+	}
+	return ix, nil
+}
+
+// findLibrary loads the library manifest, from which the metadata of
+// fetched documents is looked up, or returns nil if it cannot be loaded.
+func findLibrary() *library.Manifest {
+	if NoLibrary || len(LibraryFile) == 0 {
+		return nil
+	}
+	m, err := library.Load(LibraryFile)
+	if err != nil {
+		log.Printf("could not load library: %v", err)
+		return nil
+	}
+	return m
+}
+
+// indexDoc indexes the document (PDF) file at the path, relative path rel
+// within the indexed directory. The DOI and metadata of the document are
+// looked up, in order of preference, from its sidecar file, the library
+// manifest, its document information dictionary and its first page.
+// Errors are logged, and the document is indexed with whatever could be
+// extracted from it.
+func indexDoc(path, rel string, fi fs.FileInfo, lib *library.Manifest) *index.Doc {
+	d := &index.Doc{Path: rel, Size: fi.Size(), ModTime: fi.ModTime()}
+	if s, err := sidecar.Read(sidecar.Path(path)); err == nil {
+		d.DOI, d.Title = s.DOI, s.Title
+		d.SetMeta(s.Meta)
+	}
+	if lib != nil && d.Meta == nil {
+		if abs, err := filepath.Abs(path); err == nil {
+			if r, err := lib.Find(abs); err == nil {
+				if len(d.DOI) == 0 {
+					d.DOI, d.Title = r.DOI, r.Title
+				}
+				d.SetMeta(r.Meta)
+			}
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("%v: %v", path, err)
+		return d
+	}
+	f, err := pdf.Open(data)
+	if err != nil {
+		log.Printf("%v: %v", path, err)
+		return d
+	}
+	if info, err := f.ResolveDict(f.Trailer["Info"]); err == nil && info != nil {
+		text := func(key pdf.Name) string {
+			o, _ := f.Resolve(info[key])
+			s, _ := o.(pdf.String)
+			return strings.TrimSpace(s.Text())
+		}
+		if v := doi.Clean(text("doi")); len(d.DOI) == 0 && doi.IsValid(v) {
+			d.DOI = v
+		}
+		if v := text("Title"); len(d.Title) == 0 {
+			d.Title = v
+		}
+		if v := text("Author"); len(d.Authors) == 0 && len(v) != 0 {
+			for _, a := range strings.Split(v, ";") {
+				if a = strings.TrimSpace(a); len(a) != 0 {
+					d.Authors = append(d.Authors, a)
+				}
+			}
+		}
+	}
+	text, err := f.Text()
+	if err != nil {
+		log.Printf("%v: could not extract text: %v", path, err)
+	}
+	d.SetText(text)
+	if len(d.DOI) == 0 {
+		// DOIs on later pages are most likely those of cited works
+		page, _, _ := strings.Cut(text, "\f")
+		if dois := doi.Find(page); len(dois) != 0 {
+			d.DOI = dois[0]
+		}
+	}
+	return d
+}
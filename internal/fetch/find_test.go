@@ -0,0 +1,144 @@
+package fetch
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/Milover/fetchref/internal/index"
+	"github.com/Milover/fetchref/internal/sidecar"
+	"github.com/stretchr/testify/assert"
+)
+
+// textPDF returns a single page PDF file showing the lines of text.
+func textPDF(lines ...string) []byte {
+	var content bytes.Buffer
+	content.WriteString("BT /F1 12 Tf 72 720 Td\n")
+	for _, l := range lines {
+		fmt.Fprintf(&content, "(%s) Tj T*\n", l)
+	}
+	content.WriteString("ET")
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offs := make([]int, len(objs))
+	for i, o := range objs {
+		offs[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offs {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(objs)+1, xref)
+	return b.Bytes()
+}
+
+func TestFind(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	FindNoMeta, NoLibrary = true, true
+	defer func() { FindNoMeta, NoLibrary, FindFmt = false, false, FindTable }()
+
+	ligo := filepath.Join(dir, "ligo.pdf")
+	assert.Nil(os.WriteFile(ligo, textPDF(
+		"Observation of Gravitational Waves",
+		"doi:10.1103/PhysRevLett.116.061102.",
+	), 0666))
+	assert.Nil(sidecar.Write(sidecar.Path(ligo), sidecar.Sidecar{
+		DOI: "10.1103/PhysRevLett.116.061102",
+		Meta: &crossref.Work{
+			DOI:    "10.1103/PhysRevLett.116.061102",
+			Type:   "journal-article",
+			Title:  []string{"Observation of Gravitational Waves from a Binary Black Hole Merger"},
+			Author: []crossref.Author{{Given: "B. P.", Family: "Abbott"}},
+			Issued: crossref.DateParts{DateParts: [][]int{{2016}}},
+		},
+	}))
+	sub := filepath.Join(dir, "sub")
+	assert.Nil(os.Mkdir(sub, 0777))
+	assert.Nil(os.WriteFile(filepath.Join(sub, "lensing.pdf"), textPDF(
+		"Notes on gravitational lensing",
+		"https://doi.org/10.1000/182",
+	), 0666))
+	assert.Nil(os.WriteFile(filepath.Join(sub, "notes.txt"), []byte("gravitational"), 0666))
+
+	var b bytes.Buffer
+	assert.Nil(Find(&b, dir, []string{"gravitational"}))
+	assert.Contains(b.String(), "10.1103/PhysRevLett.116.061102")
+	assert.Contains(b.String(), "10.1000/182")
+	assert.NotContains(b.String(), "notes.txt")
+
+	ix, err := index.Load(dir)
+	assert.Nil(err)
+	assert.Len(ix.Docs, 2)
+	assert.Equal("10.1000/182", ix.Docs["sub/lensing.pdf"].DOI)
+
+	FindFmt = FindBibTeX
+	b.Reset()
+	assert.Nil(Find(&b, dir, []string{"author:abbott", "year:2016"}))
+	assert.Equal(`@article{Abbott_2016,
+	title = {Observation of Gravitational Waves from a Binary Black Hole Merger},
+	author = {Abbott, B. P.},
+	year = {2016},
+	doi = {10.1103/PhysRevLett.116.061102},
+	file = {`+ligo+`},
+}
+`, b.String())
+
+	// removed documents are dropped from the index
+	assert.Nil(os.Remove(ligo))
+	FindFmt = FindJSON
+	b.Reset()
+	assert.Nil(Find(&b, dir, []string{"lensing"}))
+	assert.Contains(b.String(), `"doi":"10.1000/182"`)
+	ix, err = index.Load(dir)
+	assert.Nil(err)
+	assert.Len(ix.Docs, 1)
+
+	assert.NotNil(Find(&b, dir, []string{"year:soon"}))
+}
+
+// TestFindMany indexes many documents concurrently, run with -race.
+func TestFindMany(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	FindNoMeta, NoLibrary = true, true
+	defer func() { FindNoMeta, NoLibrary = false, false }()
+
+	const n = 64
+	for i := 0; i < n; i++ {
+		name := filepath.Join(dir, fmt.Sprintf("doc%02d.pdf", i))
+		assert.Nil(os.WriteFile(name, textPDF(
+			fmt.Sprintf("Document number %d", i),
+			fmt.Sprintf("doi:10.1000/%d", i),
+		), 0666))
+	}
+	var b bytes.Buffer
+	assert.Nil(Find(&b, dir, []string{"document"}))
+	ix, err := index.Load(dir)
+	if assert.Nil(err) && assert.Len(ix.Docs, n) {
+		assert.Equal("10.1000/7", ix.Docs["doc07.pdf"].DOI)
+	}
+
+	// modified documents are reindexed, while others are kept
+	assert.Nil(os.WriteFile(filepath.Join(dir, "doc03.pdf"), textPDF("Document modified"), 0666))
+	b.Reset()
+	assert.Nil(Find(&b, dir, []string{"modified"}))
+	assert.Contains(b.String(), "doc03.pdf")
+	ix, err = index.Load(dir)
+	if assert.Nil(err) {
+		assert.Len(ix.Docs, n)
+	}
+}
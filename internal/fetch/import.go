@@ -0,0 +1,173 @@
+package fetch
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/arxiv"
+	"github.com/Milover/fetchref/internal/bib"
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/Milover/fetchref/internal/isbn"
+	"github.com/Milover/fetchref/internal/outfile"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	// ImportFmt is the output format of imported bibliographies.
+	ImportFmt = bib.BibTeX
+
+	// ImportPolicy is the policy by which Crossref metadata is merged
+	// into imported entries.
+	ImportPolicy = bib.Fill
+
+	// ImportOutput is the name of the file to which an imported
+	// bibliography is written, instead of the standard output.
+	ImportOutput string
+
+	// ImportNoMeta controls whether to omit requesting Crossref metadata
+	// of imported entries.
+	ImportNoMeta = false
+
+	// importWorkers is the number of entries enriched concurrently.
+	importWorkers = 8
+)
+
+// Import reads the bibliography (BibTeX, RIS or CSL-JSON) from the named
+// file, enriches its entries with Crossref (or arXiv) metadata, if they
+// have a DOI, an ISBN or an arXiv identifier, and writes the cleaned
+// bibliography, in ImportFmt format, to w, or ImportOutput, if set.
+// The changes to each entry are written to diff.
+func Import(w, diff io.Writer, name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	f, err := bib.Detect(name, data)
+	if err != nil {
+		return err
+	}
+	entries, err := bib.Parse(data, f)
+	if err != nil {
+		return fmt.Errorf("%v: %w", name, err)
+	}
+	for i := range entries {
+		e := &entries[i]
+		e.Clean()
+		if d := e.DOI(); len(d) != 0 && !e.Has("doi") {
+			e.Set("doi", d)
+		}
+	}
+	bib.UniqueKeys(entries)
+
+	if !ImportNoMeta {
+		ptrs := make([]*bib.Entry, len(entries))
+		for i := range entries {
+			ptrs[i] = &entries[i]
+		}
+		changes := enrichEntries(ptrs, ImportPolicy)
+		for i, cs := range changes {
+			if err := writeChanges(diff, &entries[i], cs); err != nil {
+				return err
+			}
+		}
+		logChanges(changes)
+	}
+
+	var b bytes.Buffer
+	if err := bib.Write(&b, entries, ImportFmt); err != nil {
+		return err
+	}
+	if len(ImportOutput) != 0 {
+		return outfile.WriteFile(ImportOutput, b.Bytes(), 0666)
+	}
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+// enrichEntries merges Crossref (or arXiv) metadata into the entries
+// according to the policy, overwriting only the fields given, if any, and
+// returns the changes to each entry. Entries without an identifier, and
+// entries whose metadata cannot be retrieved, are left as is.
+func enrichEntries(entries []*bib.Entry, p bib.Policy, fields ...string) [][]bib.Change {
+	changes := make([][]bib.Change, len(entries))
+	g := new(errgroup.Group)
+	g.SetLimit(importWorkers)
+	for i := range entries {
+		i, e := i, entries[i]
+		g.Go(func() error {
+			w, err := reqEntryWork(e)
+			if err != nil {
+				return logErr(e.Key, err)
+			}
+			if len(w.DOI) == 0 && len(w.Title) == 0 {
+				return nil
+			}
+			changes[i] = e.Merge(bib.FromWork(w), p, fields...)
+			return nil
+		})
+	}
+	_ = g.Wait() // errors are logged
+	return changes
+}
+
+// reqEntryWork requests the metadata of the entry from Crossref, by its
+// DOI or ISBN, or from arXiv, by its arXiv identifier (or the DOI registered
+// by arXiv). If arXiv reports the DOI of the published version of
+// a preprint, its metadata is requested from Crossref instead. An empty work
+// is returned if the entry has no identifier.
+func reqEntryWork(e *bib.Entry) (crossref.Work, error) {
+	d, id := e.DOI(), e.ArXiv()
+	if strings.HasPrefix(strings.ToLower(d), strings.ToLower(arxiv.DOIPrefix)) {
+		id, d = d[len(arxiv.DOIPrefix):], ""
+	}
+	a := &article.Article{}
+	switch {
+	case len(d) != 0:
+		a.Handle = article.Handle{Value: d, Type: article.DOI}
+	case len(e.ISBN()) != 0:
+		a.Handle = article.Handle{Value: isbn.Clean(e.ISBN()), Type: article.ISBN}
+	case len(id) != 0:
+		entry, err := reqArXivEntry(id)
+		if err != nil {
+			return crossref.Work{}, err
+		}
+		if len(entry.DOI) == 0 {
+			return entry.Work(arxiv.DOI(id)), nil
+		}
+		log.Printf("%v: published version DOI: %v", e.Key, entry.DOI)
+		a.Handle = article.Handle{Value: entry.DOI, Type: article.DOI}
+	default:
+		return crossref.Work{}, nil
+	}
+	return reqCrossrefMeta(a)
+}
+
+// writeChanges writes the changes to the entry as a diff, if there are any.
+func writeChanges(w io.Writer, e *bib.Entry, cs []bib.Change) error {
+	if len(cs) == 0 {
+		return nil
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "@%v{%v}\n", e.Type, e.Key)
+	for _, c := range cs {
+		b.WriteString(c.String() + "\n")
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// logChanges logs the number of changed entries.
+func logChanges(changes [][]bib.Change) {
+	n := 0
+	for _, cs := range changes {
+		if len(cs) != 0 {
+			n++
+		}
+	}
+	log.Printf("%v of %v entries changed", n, len(changes))
+}
@@ -0,0 +1,66 @@
+package fetch
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Milover/fetchref/internal/bib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImport(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	ImportNoMeta = true
+	defer func() { ImportNoMeta, ImportFmt, ImportOutput = false, bib.BibTeX, "" }()
+
+	name := filepath.Join(dir, "refs.ris")
+	assert.Nil(os.WriteFile(name, []byte("TY  - JOUR\r\n"+
+		"AU  - Abbott, B. P.\r\n"+
+		"TI  - Observation of   gravitational waves\r\n"+
+		"PY  - 2016\r\n"+
+		"SP  - 061102-061110\r\n"+
+		"UR  - https://doi.org/10.1103/PhysRevLett.116.061102\r\n"+
+		"ER  - \r\n"+
+		"TY  - JOUR\r\n"+
+		"AU  - Abbott, B. P.\r\n"+
+		"TI  - GW151226\r\n"+
+		"PY  - 2016\r\n"+
+		"ER  - \r\n"), 0666))
+
+	var b, diff bytes.Buffer
+	assert.Nil(Import(&b, &diff, name))
+	assert.Equal(`@article{Abbott_2016a,
+	author = {Abbott, B. P.},
+	title = {Observation of gravitational waves},
+	year = {2016},
+	pages = {061102--061110},
+	url = {https://doi.org/10.1103/PhysRevLett.116.061102},
+	doi = {10.1103/PhysRevLett.116.061102},
+}
+
+@article{Abbott_2016b,
+	author = {Abbott, B. P.},
+	title = {GW151226},
+	year = {2016},
+}
+`, b.String())
+	assert.Empty(diff.String())
+
+	ImportFmt = bib.CSLJSON
+	ImportOutput = filepath.Join(dir, "refs.json")
+	b.Reset()
+	assert.Nil(Import(&b, &diff, name))
+	assert.Empty(b.String())
+	data, err := os.ReadFile(ImportOutput)
+	assert.Nil(err)
+	es, err := bib.ParseCSL(data)
+	if assert.Nil(err) && assert.Len(es, 2) {
+		assert.Equal("Abbott_2016a", es[0].Key)
+		assert.Equal("10.1103/PhysRevLett.116.061102", es[0].Get("doi"))
+	}
+
+	assert.NotNil(Import(&b, &diff, filepath.Join(dir, "missing.bib")))
+}
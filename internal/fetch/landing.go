@@ -0,0 +1,305 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/Milover/fetchref/internal/doiorg"
+	"golang.org/x/net/html"
+)
+
+var (
+	// doiPattern matches a DOI embedded in a string, e.g., 'doi:10.1000/1'.
+	doiPattern = regexp.MustCompile(`10\.[0-9]{4,9}/\S+`)
+
+	// datePattern matches the year, month and day of a date, separated
+	// by any non-digits, e.g., '2013/05/01' or '2013-05'.
+	datePattern = regexp.MustCompile(`^([0-9]{4})(?:\D+([0-9]{1,2}))?(?:\D+([0-9]{1,2}))?`)
+)
+
+// Landing page meta tag names, in order of preference. Highwire Press
+// (citation_*) tags are preferred over Dublin Core (DC.*) tags, which
+// are preferred over Open Graph (og:*) tags.
+var (
+	metaTitle     = []string{"citation_title", "dc.title", "og:title"}
+	metaDOI       = []string{"citation_doi", "dc.identifier", "prism.doi"}
+	metaAuthor    = []string{"citation_author", "dc.creator"}
+	metaJournal   = []string{"citation_journal_title", "citation_conference_title", "prism.publicationname", "og:site_name"}
+	metaPublisher = []string{"citation_publisher", "dc.publisher"}
+	metaDate      = []string{"citation_publication_date", "citation_date", "citation_online_date", "dc.date", "prism.publicationdate"}
+	metaVolume    = []string{"citation_volume", "prism.volume"}
+	metaIssue     = []string{"citation_issue", "prism.number"}
+	metaFirstPage = []string{"citation_firstpage", "prism.startingpage"}
+	metaLastPage  = []string{"citation_lastpage", "prism.endingpage"}
+	metaISSN      = []string{"citation_issn", "prism.issn"}
+	metaISBN      = []string{"citation_isbn"}
+	metaType      = []string{"dc.type", "og:type"}
+	metaPDF       = []string{"citation_pdf_url"}
+)
+
+// landingMeta holds bibliographic metadata extracted from the meta tags of
+// a publisher's landing page, and a PDF link candidate.
+type landingMeta struct {
+	URL       *url.URL // landing page URL
+	PDF       *url.URL // PDF link candidate, if any
+	Title     string
+	DOI       string
+	Authors   []string
+	Journal   string
+	Publisher string
+	Date      string
+	Volume    string
+	Issue     string
+	FirstPage string
+	LastPage  string
+	ISSN      []string
+	ISBN      []string
+	Type      string
+}
+
+// first returns the first value of the first meta tag found.
+func first(meta map[string][]string, names []string) string {
+	for _, n := range names {
+		if v, found := meta[n]; found {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+// all returns all values of the first meta tag found.
+func all(meta map[string][]string, names []string) []string {
+	for _, n := range names {
+		if v, found := meta[n]; found {
+			return v
+		}
+	}
+	return nil
+}
+
+// parseLandingMeta extracts bibliographic metadata from the meta tags of
+// a landing page. Relative links are resolved against the page URL.
+func parseLandingMeta(root *html.Node, page *url.URL) landingMeta {
+	meta := collectMeta(root)
+	m := landingMeta{
+		URL:       page,
+		Title:     first(meta, metaTitle),
+		Authors:   all(meta, metaAuthor),
+		Journal:   first(meta, metaJournal),
+		Publisher: first(meta, metaPublisher),
+		Date:      first(meta, metaDate),
+		Volume:    first(meta, metaVolume),
+		Issue:     first(meta, metaIssue),
+		FirstPage: first(meta, metaFirstPage),
+		LastPage:  first(meta, metaLastPage),
+		ISSN:      all(meta, metaISSN),
+		ISBN:      all(meta, metaISBN),
+		Type:      first(meta, metaType),
+	}
+	// identifiers may hold non-DOI values, e.g., DC.identifier
+	for _, v := range append(all(meta, metaDOI), first(meta, []string{"og:url"})) {
+		if m.DOI = doiPattern.FindString(v); len(m.DOI) != 0 {
+			break
+		}
+	}
+	if pdf := first(meta, metaPDF); len(pdf) != 0 {
+		if u, err := url.Parse(pdf); err == nil {
+			m.PDF = page.ResolveReference(u)
+		}
+	}
+	return m
+}
+
+// Work returns the landing page metadata as a Crossref work.
+func (m landingMeta) Work() crossref.Work {
+	w := crossref.Work{
+		DOI:       m.DOI,
+		Publisher: m.Publisher,
+		Volume:    m.Volume,
+		Issue:     m.Issue,
+		ISSN:      m.ISSN,
+		ISBN:      m.ISBN,
+		Type:      m.Type,
+		Source:    "landing-page",
+	}
+	if m.URL != nil {
+		w.URL = m.URL.String()
+	}
+	if len(m.Title) != 0 {
+		w.Title = []string{m.Title}
+	}
+	if len(m.Journal) != 0 {
+		w.ContainerTitle = []string{m.Journal}
+	}
+	w.Page = m.FirstPage
+	if len(m.LastPage) != 0 && m.LastPage != m.FirstPage {
+		w.Page += "-" + m.LastPage
+	}
+	for _, name := range m.Authors {
+		w.Author = append(w.Author, parseAuthorName(name))
+	}
+	if match := datePattern.FindStringSubmatch(m.Date); match != nil {
+		var parts []int
+		for _, p := range match[1:] {
+			if n, err := strconv.Atoi(p); err == nil {
+				parts = append(parts, n)
+			}
+		}
+		w.Issued.DateParts = [][]int{parts}
+	}
+	return w
+}
+
+// parseAuthorName parses an author name in either the 'Family, Given' or
+// the 'Given Family' form.
+func parseAuthorName(name string) crossref.Author {
+	if i := strings.Index(name, ","); i != -1 {
+		return crossref.Author{
+			Family: strings.TrimSpace(name[:i]),
+			Given:  strings.TrimSpace(name[i+1:]),
+		}
+	}
+	f := strings.Fields(name)
+	if len(f) == 0 {
+		return crossref.Author{}
+	}
+	return crossref.Author{
+		Family: f[len(f)-1],
+		Given:  strings.Join(f[:len(f)-1], " "),
+	}
+}
+
+// resolveDOI returns the URL to which the DOI resolves, i.e., the URL
+// of the publisher's landing page, by querying doi.org.
+func resolveDOI(ctx context.Context, doi string) (*url.URL, error) {
+	u := &url.URL{
+		Scheme: "https",
+		Host:   doiorg.URL,
+		Path:   doiorg.API,
+	}
+	query := url.Values{}
+	query.Add(doiorg.QueryKeyType, doiorg.QueryValTypeURL)
+	u.RawQuery = query.Encode()
+
+	u = u.JoinPath(url.PathEscape(doi))
+
+	ctx, cncl := context.WithTimeout(ctx, GlobalReqTimeout)
+	defer cncl()
+
+	res, err := sendGetRequest(ctx, u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	var msg doiorg.HandleMessage
+	if err = json.Unmarshal(b, &msg); err != nil {
+		return nil, err
+	}
+	for _, v := range msg.Values {
+		if v.Type == doiorg.QueryValTypeURL {
+			return url.Parse(v.Data.Value)
+		}
+	}
+	return nil, fmt.Errorf("doi.org: DOI does not resolve to a URL")
+}
+
+// reqLandingPage requests a landing page, and extracts metadata from its
+// meta tags. Publisher landing pages (proxied) are requested through the
+// institutional proxy if one is configured, in which case links in the
+// returned metadata point to the proxy as well.
+func reqLandingPage(ctx context.Context, landing *url.URL, proxied bool) (landingMeta, error) {
+	var err error
+	if proxied {
+		if landing, err = Proxy.Rewrite(landing); err != nil {
+			return landingMeta{}, err
+		}
+	}
+
+	ctx, cncl := context.WithTimeout(ctx, GlobalReqTimeout)
+	defer cncl()
+
+	res, err := sendGetRequest(ctx, landing.String())
+	if err != nil {
+		return landingMeta{}, err
+	}
+	defer res.Body.Close()
+
+	body, err := html.Parse(res.Body)
+	if err != nil {
+		return landingMeta{}, err
+	}
+
+	// relative to the (possibly redirected) landing page
+	m := parseLandingMeta(body, res.Request.URL)
+	if proxied && m.PDF != nil {
+		if m.PDF, err = Proxy.Rewrite(m.PDF); err != nil {
+			return landingMeta{}, err
+		}
+	}
+	return m, nil
+}
+
+// reqDOILandingPage follows the DOI to the publisher's landing page,
+// and extracts metadata from its meta tags.
+func reqDOILandingPage(ctx context.Context, doi string) (landingMeta, error) {
+	landing, err := resolveDOI(ctx, doi)
+	if err != nil {
+		return landingMeta{}, err
+	}
+	return reqLandingPage(ctx, landing, true)
+}
+
+// reqLandingInfo follows the article DOI to the publisher's landing page,
+// through the institutional proxy if one is configured, and sets the
+// article download URL to the page's PDF link candidate.
+func reqLandingInfo(ctx context.Context, a *article.Article) error {
+	if len(a.DOI) == 0 {
+		return fmt.Errorf("cannot retrieve landing page, DOI not set")
+	}
+	m, err := reqDOILandingPage(ctx, a.DOI)
+	if err != nil {
+		return err
+	}
+	if m.PDF == nil {
+		return fmt.Errorf("could not extract PDF URL from landing page")
+	}
+	a.Url = m.PDF
+	log.Printf("%v: full text from publisher landing page: %v",
+		a.Handle.Value, a.Url)
+	return nil
+}
+
+// reqLandingMeta follows the article DOI to the publisher's landing page,
+// and sets the article title, DOI and metadata from the page's meta tags.
+// It is used as a fallback for DOIs without Crossref metadata.
+func reqLandingMeta(a *article.Article) error {
+	m, err := reqDOILandingPage(context.Background(), a.Handle.Value)
+	if err != nil {
+		return err
+	}
+	if len(m.Title) == 0 {
+		return fmt.Errorf("could not extract metadata from landing page")
+	}
+	work := m.Work()
+	if len(work.DOI) == 0 {
+		work.DOI = a.Handle.Value
+	}
+	a.Title = m.Title
+	a.DOI = work.DOI
+	a.Meta = &work
+	return nil
+}
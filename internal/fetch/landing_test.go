@@ -0,0 +1,49 @@
+package fetch
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+const testLandingPage = `<!DOCTYPE html>
+<html><head>
+<meta property="og:title" content="Open Graph Title">
+<meta name="citation_title" content="Turbulent flow over a cake">
+<meta name="citation_author" content="Doe, Jane Q.">
+<meta name="citation_author" content="John Smith">
+<meta name="citation_journal_title" content="Journal of Fluid Mechanics">
+<meta name="citation_publication_date" content="2013/05/01">
+<meta name="citation_volume" content="724">
+<meta name="citation_firstpage" content="1">
+<meta name="citation_lastpage" content="24">
+<meta name="citation_issn" content="0022-1120">
+<meta name="citation_issn" content="1469-7645">
+<meta name="DC.identifier" content="info:doi/10.1017/jfm.2013.1">
+<meta name="citation_pdf_url" content="/content/pdf/jfm.2013.1.pdf">
+</head><body></body></html>`
+
+func TestParseLandingMeta(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(testLandingPage))
+	assert.Nil(t, err)
+	page, _ := url.Parse("https://www.publisher.com/article/jfm.2013.1")
+
+	m := parseLandingMeta(root, page)
+	assert.Equal(t, "Turbulent flow over a cake", m.Title)
+	assert.Equal(t, "10.1017/jfm.2013.1", m.DOI)
+	assert.Equal(t, "https://www.publisher.com/content/pdf/jfm.2013.1.pdf", m.PDF.String())
+
+	w := m.Work()
+	assert.Equal(t, []crossref.Author{
+		{Family: "Doe", Given: "Jane Q."},
+		{Family: "Smith", Given: "John"},
+	}, w.Author)
+	assert.Equal(t, []string{"Journal of Fluid Mechanics"}, w.ContainerTitle)
+	assert.Equal(t, [][]int{{2013, 5, 1}}, w.Issued.DateParts)
+	assert.Equal(t, "1-24", w.Page)
+	assert.Equal(t, []string{"0022-1120", "1469-7645"}, w.ISSN)
+}
@@ -0,0 +1,167 @@
+package fetch
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Milover/fetchref/internal/arxiv"
+	"github.com/Milover/fetchref/internal/bib"
+	"github.com/Milover/fetchref/internal/doi"
+	"github.com/Milover/fetchref/internal/isbn"
+	"github.com/Milover/fetchref/internal/latex"
+	"github.com/Milover/fetchref/internal/outfile"
+)
+
+var (
+	// LatexBibs are the BibTeX files, in addition to those of the document,
+	// against which the citations of LaTeX documents are checked.
+	LatexBibs []string
+
+	// LatexOutput is the BibTeX file to which fetched entries are
+	// appended, instead of the first bibliography file of the document.
+	LatexOutput string
+)
+
+// Latex resolves the citations of the named LaTeX document, or auxiliary
+// file (see latex.Scan). Cited keys which are not found in the bibliography
+// files of the document, LatexBibs or LatexOutput, and which are DOIs, ISBNs
+// or arXiv identifiers, or are noted as such in comments, are fetched from
+// Crossref (or arXiv), and appended, with the key as is, to LatexOutput, if
+// set, or to the first bibliography file, or written to w, if there is
+// none. An error is returned if any cited key cannot be resolved.
+func Latex(w io.Writer, name string) error {
+	doc, err := latex.Scan(name)
+	if err != nil {
+		return err
+	}
+	var bibs []string
+	for _, b := range append(append(doc.Bibs, LatexBibs...), LatexOutput) {
+		if len(b) != 0 && !contains(bibs, b) {
+			bibs = append(bibs, b)
+		}
+	}
+	files := make(map[string]*bib.File)
+	known := make(map[string]bool)
+	for _, b := range bibs {
+		data, err := os.ReadFile(b)
+		if errors.Is(err, fs.ErrNotExist) {
+			if b != LatexOutput {
+				log.Printf("%v: bibliography file not found", b)
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		f, err := bib.ParseBibTeX(data)
+		if err != nil {
+			return fmt.Errorf("%v: %w", b, err)
+		}
+		files[b] = f
+		for _, e := range f.Entries() {
+			known[strings.ToLower(e.Key)] = true
+		}
+	}
+
+	var seeds []*bib.Entry
+	var unresolved []string
+	for _, k := range doc.Keys {
+		if known[strings.ToLower(k)] {
+			continue
+		}
+		e, ok := identEntry(k, k)
+		if n, found := doc.Notes[k]; !ok && found {
+			e, ok = identEntry(k, n)
+		}
+		if !ok {
+			log.Printf("%v: not found in the bibliography", k)
+			unresolved = append(unresolved, k)
+			continue
+		}
+		seeds = append(seeds, e)
+	}
+	log.Printf("%v of %v cited keys not found in the bibliography",
+		len(seeds)+len(unresolved), len(doc.Keys))
+
+	var fetched []bib.Entry
+	for i, cs := range enrichEntries(seeds, bib.Fill) {
+		if len(cs) == 0 {
+			unresolved = append(unresolved, seeds[i].Key)
+			continue
+		}
+		fetched = append(fetched, *seeds[i])
+	}
+	if err := appendEntries(w, bibs, files, fetched); err != nil {
+		return err
+	}
+	if len(unresolved) != 0 {
+		return fmt.Errorf("%v cited key(s) could not be resolved: %v",
+			len(unresolved), strings.Join(unresolved, ", "))
+	}
+	return nil
+}
+
+// appendEntries appends the entries to LatexOutput, if set, or to the first
+// of the bibliography files, or writes them to w, if there are none.
+func appendEntries(w io.Writer, bibs []string, files map[string]*bib.File, entries []bib.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	out := LatexOutput
+	if len(out) == 0 && len(bibs) != 0 {
+		out = bibs[0]
+	}
+	if len(out) == 0 {
+		return bib.WriteBibTeX(w, entries)
+	}
+	f := files[out]
+	if f == nil {
+		f = &bib.File{}
+	}
+	for _, e := range entries {
+		f.Add(e)
+	}
+	if err := outfile.WriteFile(out, f.Bytes(), 0666); err != nil {
+		return err
+	}
+	log.Printf("%v entries appended to %v", len(entries), out)
+	return nil
+}
+
+// identEntry returns a 'misc' entry with the key, and the identifier id,
+// i.e., a DOI, an ISBN or an arXiv identifier, optionally prefixed by
+// 'doi:', 'isbn:' or 'arXiv:', or false if id is not an identifier.
+func identEntry(key, id string) (*bib.Entry, bool) {
+	e := &bib.Entry{Type: "misc", Key: key}
+	n := strings.TrimSpace(id)
+	if len(n) > 5 && strings.EqualFold(n[:5], "isbn:") {
+		n = n[5:]
+	}
+	switch {
+	case doi.IsValid(doi.Clean(n)):
+		e.Set("doi", doi.Clean(n))
+	case arxiv.IsValid(n):
+		e.Set("eprint", arxiv.Clean(n))
+		e.Set("archiveprefix", "arXiv")
+	case isbn.IsValid(n):
+		e.Set("isbn", isbn.Clean(n))
+	default:
+		return nil, false
+	}
+	return e, true
+}
+
+// contains reports whether ss contains s.
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
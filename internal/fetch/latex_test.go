@@ -0,0 +1,75 @@
+package fetch
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatex(t *testing.T) {
+	assert := assert.New(t)
+	standInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/10.1103%2FPhysRevLett.116.061102") &&
+			!strings.HasSuffix(r.URL.Path, "/10.1103/PhysRevLett.116.061102") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testRefreshWork))
+	})
+	defer func() { LatexBibs, LatexOutput = nil, "" }()
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "main.tex")
+	assert.Nil(os.WriteFile(name, []byte(`\addbibresource{refs.bib}
+% ligo: 10.1103/PhysRevLett.116.061102
+\cite{notes, 10.1103/PhysRevLett.116.061102, ligo, unknown}
+`), 0666))
+	bibName := filepath.Join(dir, "refs.bib")
+	assert.Nil(os.WriteFile(bibName, []byte("@misc{notes, title = {Notes}}\n"), 0666))
+
+	var b bytes.Buffer
+	err := Latex(&b, name)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "1 cited key(s) could not be resolved: unknown")
+	}
+	assert.Empty(b.String())
+	data, err := os.ReadFile(bibName)
+	assert.Nil(err)
+	assert.Equal(`@misc{notes, title = {Notes}}
+
+@article{10.1103/PhysRevLett.116.061102,
+	doi = {10.1103/PhysRevLett.116.061102},
+	title = {Observation of Gravitational Waves},
+	author = {Abbott, B. P.},
+	journal = {Physical Review Letters},
+	year = {2016},
+	month = feb,
+	volume = {116},
+	pages = {061102},
+}
+
+@article{ligo,
+	doi = {10.1103/PhysRevLett.116.061102},
+	title = {Observation of Gravitational Waves},
+	author = {Abbott, B. P.},
+	journal = {Physical Review Letters},
+	year = {2016},
+	month = feb,
+	volume = {116},
+	pages = {061102},
+}
+`, string(data))
+
+	// all keys are known, or written to the output
+	LatexOutput = filepath.Join(dir, "new.bib")
+	assert.Nil(os.WriteFile(name, []byte(`\cite{notes,ligo}`), 0666))
+	LatexBibs = []string{bibName}
+	assert.Nil(Latex(&b, name))
+	_, err = os.Stat(LatexOutput)
+	assert.True(os.IsNotExist(err))
+}
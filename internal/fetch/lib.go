@@ -0,0 +1,368 @@
+package fetch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/library"
+	"github.com/Milover/fetchref/internal/sidecar"
+)
+
+var (
+	// LibraryFile is the library manifest file, which records fetched
+	// articles and citations.
+	LibraryFile = library.DefaultPath()
+
+	// NoLibrary controls whether to omit recording fetched articles and
+	// citations in the library manifest.
+	NoLibrary = false
+
+	// LibTitleWidth is the maximum width of titles listed by LibList.
+	LibTitleWidth = 60
+)
+
+// recordArticles records the fetched articles and citations in the library
+// manifest. Articles for which nothing was written are not recorded.
+func recordArticles(articles []article.Article) (err error) {
+	if NoLibrary || len(LibraryFile) == 0 {
+		return nil
+	}
+	m, unlock, err := lockLibrary()
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, unlock()) }()
+	var n int
+	for i := range articles {
+		a := &articles[i]
+		if len(a.File) == 0 && len(a.CiteFile) == 0 {
+			continue
+		}
+		r := library.Record{
+			ID:         library.RecordID(a.Handle.Type.String(), a.Handle.Value, a.DOI),
+			Handle:     a.Handle.Value,
+			HandleType: a.Handle.Type.String(),
+			DOI:        a.DOI,
+			Title:      a.Title,
+			Meta:       a.Meta,
+		}
+		if len(a.File) != 0 {
+			f, err := libraryFile(a)
+			if err != nil {
+				return err
+			}
+			r.Files = append(r.Files, f)
+		}
+		if len(a.CiteFile) != 0 {
+			path, err := filepath.Abs(a.CiteFile)
+			if err != nil {
+				return err
+			}
+			r.Citations = append(r.Citations, path)
+		}
+		m.Put(r)
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	return m.Save()
+}
+
+// libraryFile returns the library file record of the downloaded article.
+func libraryFile(a *article.Article) (library.File, error) {
+	path, err := filepath.Abs(a.File)
+	if err != nil {
+		return library.File{}, err
+	}
+	sum, size, err := sidecar.HashFile(path)
+	if err != nil {
+		return library.File{}, err
+	}
+	f := library.File{
+		Path:    path,
+		SHA256:  sum,
+		Size:    size,
+		Source:  a.Source,
+		License: a.License,
+	}
+	if a.Url != nil {
+		f.URL = a.Url.String()
+	}
+	return f, nil
+}
+
+// loadLibrary loads the library manifest.
+func loadLibrary() (*library.Manifest, error) {
+	if len(LibraryFile) == 0 {
+		return nil, fmt.Errorf("library manifest file not set")
+	}
+	return library.Load(LibraryFile)
+}
+
+// lockLibrary locks the library manifest, so that concurrent updates are
+// not lost, and loads it. The returned function releases the lock.
+func lockLibrary() (*library.Manifest, func() error, error) {
+	if len(LibraryFile) == 0 {
+		return nil, nil, fmt.Errorf("library manifest file not set")
+	}
+	unlock, err := library.Lock(LibraryFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := library.Load(LibraryFile)
+	if err != nil {
+		return nil, nil, errors.Join(err, unlock())
+	}
+	return m, unlock, nil
+}
+
+// LibList writes a list of library records, optionally only those tagged
+// with the tag, as a table, or as JSON Lines if asJSON is set.
+func LibList(w io.Writer, tag string, asJSON bool) error {
+	m, err := loadLibrary()
+	if err != nil {
+		return err
+	}
+	var rs []*library.Record
+	for _, r := range m.Records() {
+		if len(tag) == 0 || r.HasTag(tag) {
+			rs = append(rs, r)
+		}
+	}
+	if asJSON {
+		enc := json.NewEncoder(w)
+		for _, r := range rs {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tYEAR\tFILES\tTAGS\tTITLE")
+	for _, r := range rs {
+		year := ""
+		if y := r.Year(); y > 0 {
+			year = strconv.Itoa(y)
+		}
+		title := []rune(r.Title)
+		if len(title) > LibTitleWidth {
+			title = append(title[:LibTitleWidth-1], '…')
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%d\t%v\t%v\n",
+			r.ID, year, len(r.Files), strings.Join(r.Tags, ","), string(title))
+	}
+	return tw.Flush()
+}
+
+// LibShow writes the library records matching the references, i.e.,
+// record IDs, DOIs, handles or file paths, as JSON.
+func LibShow(w io.Writer, refs []string) error {
+	m, err := loadLibrary()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	var errs error
+	for _, ref := range refs {
+		r, err := m.Find(ref)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return errs
+}
+
+// LibRemove removes the library records matching the references, and,
+// if files is set, their files, along with sidecar and license files.
+func LibRemove(refs []string, files bool) (err error) {
+	m, unlock, err := lockLibrary()
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, unlock()) }()
+	var errs error
+	for _, ref := range refs {
+		r, err := m.Find(ref)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		if files {
+			for _, f := range r.Files {
+				for _, name := range companions(f.Path) {
+					if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+						errs = errors.Join(errs, err)
+					}
+				}
+			}
+		}
+		m.Remove(r)
+		log.Printf("%v: removed", r.ID)
+	}
+	return errors.Join(errs, m.Save())
+}
+
+// LibMove moves the files of the library record matching the reference
+// into the directory dst, if it is an existing directory, or renames
+// the record's only file to dst. Sidecar and license files are moved
+// along with the files.
+func LibMove(ref, dst string) (err error) {
+	m, unlock, err := lockLibrary()
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, unlock()) }()
+	r, err := m.Find(ref)
+	if err != nil {
+		return err
+	}
+	if len(r.Files) == 0 {
+		return fmt.Errorf("%v: record has no files", r.ID)
+	}
+	if dst, err = filepath.Abs(dst); err != nil {
+		return err
+	}
+	fi, err := os.Stat(dst)
+	isDir := err == nil && fi.IsDir()
+	if !isDir && len(r.Files) > 1 {
+		return fmt.Errorf("%v: record has %d files, destination must be a directory",
+			r.ID, len(r.Files))
+	}
+	for i := range r.Files {
+		f := &r.Files[i]
+		to := dst
+		if isDir {
+			to = filepath.Join(dst, filepath.Base(f.Path))
+		}
+		if err := moveWithCompanions(f.Path, to); err != nil {
+			return errors.Join(err, m.Save())
+		}
+		f.Path = to
+	}
+	return m.Save()
+}
+
+// LibTag adds the tags to, or, if remove is set, removes the tags from
+// the library record matching the reference.
+func LibTag(ref string, tags []string, remove bool) (err error) {
+	m, unlock, err := lockLibrary()
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, unlock()) }()
+	r, err := m.Find(ref)
+	if err != nil {
+		return err
+	}
+	if remove {
+		r.Untag(tags...)
+	} else {
+		r.Tag(tags...)
+	}
+	return m.Save()
+}
+
+// companions returns the names of the document file and its companion
+// files, i.e., its sidecar and license files.
+func companions(name string) []string {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	return []string{name, sidecar.Path(name), base + ".license"}
+}
+
+// moveWithCompanions moves the document file, and its companion files,
+// if they exist, to the new name. The sidecar file name is updated.
+// Existing destination files are not overwritten, and files moved before
+// a failure are moved back.
+func moveWithCompanions(from, to string) error {
+	if from == to {
+		return nil
+	}
+	src, dst := companions(from), companions(to)
+	var moves [][2]string
+	for i := range src {
+		if _, err := os.Stat(src[i]); i > 0 && errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if _, err := os.Lstat(dst[i]); err == nil {
+			return fmt.Errorf("%v: destination exists", dst[i])
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		moves = append(moves, [2]string{src[i], dst[i]})
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0777); err != nil {
+		return err
+	}
+	for i, mv := range moves {
+		if err := moveFile(mv[0], mv[1]); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				if rerr := moveFile(moves[j][1], moves[j][0]); rerr != nil {
+					err = errors.Join(err, rerr)
+				}
+			}
+			return err
+		}
+	}
+	if s, err := sidecar.Read(dst[1]); err == nil {
+		s.File = filepath.Base(to)
+		return sidecar.Write(dst[1], s)
+	}
+	return nil
+}
+
+// moveFile renames the file, or, if the new name is on another file
+// system, copies the file and removes the original. The new file must not
+// exist.
+func moveFile(from, to string) error {
+	err := os.Rename(from, to)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := copyFile(from, to); err != nil {
+		return err
+	}
+	return os.Remove(from)
+}
+
+// copyFile copies the file to a new file, which must not exist.
+// The new file is removed if the copy fails.
+func copyFile(from, to string) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fi.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(to)
+	}
+	return err
+}
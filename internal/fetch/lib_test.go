@@ -0,0 +1,121 @@
+package fetch
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/library"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLibrary(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	LibraryFile = filepath.Join(dir, "library.jsonl")
+	defer func() { LibraryFile = library.DefaultPath() }()
+
+	doc := filepath.Join(dir, "article.pdf")
+	assert.Nil(os.WriteFile(doc, testPDF, 0666))
+	assert.Nil(os.WriteFile(filepath.Join(dir, "article.license"), []byte("cc-by\n"), 0666))
+	articles := []article.Article{
+		{
+			Handle: article.Handle{Type: article.DOI, Value: "10.1000/182"},
+			DOI:    "10.1000/182",
+			Title:  "The DOI Handbook",
+			File:   doc,
+		},
+		{
+			// nothing was written, not recorded
+			Handle: article.Handle{Type: article.DOI, Value: "10.1000/183"},
+		},
+	}
+	assert.Nil(recordArticles(articles))
+
+	var b bytes.Buffer
+	assert.Nil(LibList(&b, "", false))
+	assert.Contains(b.String(), "doi:10.1000/182")
+	assert.NotContains(b.String(), "10.1000/183")
+
+	assert.Nil(LibTag("10.1000/182", []string{"handbook"}, false))
+	b.Reset()
+	assert.Nil(LibList(&b, "handbook", true))
+	assert.Contains(b.String(), `"tags":["handbook"]`)
+
+	// move the file, along with its license, into a directory
+	sub := filepath.Join(dir, "sub")
+	assert.Nil(os.Mkdir(sub, 0777))
+	// existing files, including companions, are not overwritten
+	other := filepath.Join(sub, "article.license")
+	assert.Nil(os.WriteFile(other, []byte("other\n"), 0666))
+	assert.NotNil(LibMove(doc, sub))
+	assert.FileExists(doc)
+	assert.NoFileExists(filepath.Join(sub, "article.pdf"))
+	data, _ := os.ReadFile(other)
+	assert.Equal("other\n", string(data))
+	assert.Nil(os.Remove(other))
+
+	assert.Nil(LibMove(doc, sub))
+	assert.FileExists(filepath.Join(sub, "article.pdf"))
+	assert.FileExists(filepath.Join(sub, "article.license"))
+	assert.NoFileExists(doc)
+
+	b.Reset()
+	assert.Nil(LibShow(&b, []string{filepath.Join(sub, "article.pdf")}))
+	assert.Contains(b.String(), `"title": "The DOI Handbook"`)
+
+	assert.Nil(LibRemove([]string{"10.1000/182"}, true))
+	assert.NoFileExists(filepath.Join(sub, "article.pdf"))
+	assert.NoFileExists(filepath.Join(sub, "article.license"))
+	assert.NotNil(LibShow(&b, []string{"10.1000/182"}))
+}
+
+func TestCopyFile(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	from, to := filepath.Join(dir, "from.pdf"), filepath.Join(dir, "to.pdf")
+	assert.Nil(os.WriteFile(from, testPDF, 0640))
+
+	assert.Nil(copyFile(from, to))
+	data, err := os.ReadFile(to)
+	assert.Nil(err)
+	assert.Equal(testPDF, data)
+	fi, err := os.Stat(to)
+	if assert.Nil(err) {
+		assert.Equal(os.FileMode(0640), fi.Mode().Perm())
+	}
+	// the new file must not exist
+	assert.NotNil(copyFile(from, to))
+	assert.FileExists(to)
+}
+
+func TestRecordArticlesConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	LibraryFile = filepath.Join(dir, "library.jsonl")
+	defer func() { LibraryFile = library.DefaultPath() }()
+
+	const n = 8
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			doi := fmt.Sprintf("10.1000/%d", i)
+			assert.Nil(t, recordArticles([]article.Article{{
+				Handle:   article.Handle{Type: article.DOI, Value: doi},
+				DOI:      doi,
+				CiteFile: filepath.Join(dir, "citations.bib"),
+			}}))
+		}(i)
+	}
+	wg.Wait()
+	m, err := library.Load(LibraryFile)
+	if assert.Nil(t, err) {
+		assert.Len(t, m.Records(), n)
+	}
+	assert.NoFileExists(t, LibraryFile+".lock")
+}
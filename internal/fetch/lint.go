@@ -0,0 +1,303 @@
+package fetch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Milover/fetchref/internal/bib"
+	"github.com/Milover/fetchref/internal/doi"
+	"github.com/Milover/fetchref/internal/metainfo"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	// LintFmt is the output format of lint issues.
+	LintFmt = LintText
+
+	// LintFailLevel is the lowest level of issues for which Lint fails.
+	LintFailLevel = bib.LevelError
+
+	// LintOffline controls whether to omit checks which require requests,
+	// i.e., checking whether cited works have been retracted.
+	LintOffline = false
+)
+
+// LintFormat is the output format of lint issues.
+type LintFormat int
+
+const (
+	LintText LintFormat = iota
+	LintJSON
+	LintSARIF
+)
+
+var (
+	// lintFormatNames are the user-friendly LintFormat names.
+	lintFormatNames = [...]string{
+		"text",
+		"json",
+		"sarif",
+	}
+)
+
+// Set sets the format from its name.
+func (f *LintFormat) Set(name string) error {
+	for i, n := range lintFormatNames {
+		if strings.EqualFold(name, n) {
+			*f = LintFormat(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format: %v, expected one of: %v",
+		name, strings.Join(lintFormatNames[:], ", "))
+}
+
+// String returns the format name.
+func (f LintFormat) String() string {
+	return lintFormatNames[f]
+}
+
+// Type returns the format type name.
+func (f LintFormat) Type() string {
+	return "string"
+}
+
+// lintIssue is a lint issue of an entry of a file, as written by Lint in
+// JSON format.
+type lintIssue struct {
+	File string `json:"file"`
+	bib.Issue
+}
+
+// Lint checks the named BibTeX files for issues (see bib.Lint), and
+// whether the works they cite have been retracted, unless LintOffline is
+// set, and writes the issues to w, as text, JSON Lines or SARIF, depending
+// on LintFmt. An error is returned if any issue is at LintFailLevel, or
+// above.
+func Lint(w io.Writer, names []string) error {
+	var issues []lintIssue
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		f, err := bib.ParseBibTeX(data)
+		if err != nil {
+			return fmt.Errorf("%v: %w", name, err)
+		}
+		is := bib.Lint(f.Entries())
+		if !LintOffline {
+			is = append(is, lintRetractions(f.Entries())...)
+			sort.SliceStable(is, func(i, j int) bool { return is[i].Line < is[j].Line })
+		}
+		for _, i := range is {
+			issues = append(issues, lintIssue{File: name, Issue: i})
+		}
+	}
+
+	var err error
+	switch LintFmt {
+	case LintJSON:
+		err = writeLintJSON(w, issues)
+	case LintSARIF:
+		err = writeLintSARIF(w, issues)
+	default:
+		for _, is := range issues {
+			if _, err = fmt.Fprintf(w, "%v:%v\n", is.File, is.Issue); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	for _, is := range issues {
+		if is.Level >= LintFailLevel {
+			n++
+		}
+	}
+	if n != 0 {
+		return fmt.Errorf("lint: %v issue(s) at level %v or above", n, LintFailLevel)
+	}
+	return nil
+}
+
+// lintRetractions checks whether the works cited by the entries, by their
+// DOIs, have been retracted, and returns the issues. Works which could not
+// be checked are reported as errors of a separate rule, so that failed
+// requests do not pass unnoticed.
+func lintRetractions(entries []*bib.Entry) []bib.Issue {
+	results := make([][]bib.Issue, len(entries))
+	g := new(errgroup.Group)
+	g.SetLimit(importWorkers)
+	for i, e := range entries {
+		i, e := i, e
+		d := doi.Clean(bib.Plain(e.Get("doi")))
+		if !doi.IsValid(d) {
+			continue
+		}
+		add := func(rule string, format string, args ...interface{}) {
+			is := bib.Issue{
+				Rule:    rule,
+				Level:   bib.LevelError,
+				Key:     e.Key,
+				Field:   "doi",
+				Line:    e.Line(),
+				Message: fmt.Sprintf(format, args...),
+			}
+			if f := e.Field("doi"); f != nil && f.Line() > 0 {
+				is.Line = f.Line()
+			}
+			results[i] = append(results[i], is)
+		}
+		g.Go(func() error {
+			updates, err := reqWorkUpdates(d)
+			if err != nil {
+				add(bib.RuleRetractionCheck,
+					"could not check whether %v has been retracted: %v", d, err)
+				return nil
+			}
+			for _, u := range updates {
+				if u.retraction() {
+					add(bib.RuleRetracted,
+						"%v has been retracted (%v: %v)", d, u.Label, u.Notice)
+				}
+			}
+			return nil
+		})
+	}
+	_ = g.Wait() // failed checks are reported as issues
+	var issues []bib.Issue
+	for _, is := range results {
+		issues = append(issues, is...)
+	}
+	return issues
+}
+
+// writeLintJSON writes the issues to w as JSON Lines.
+func writeLintJSON(w io.Writer, issues []lintIssue) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, is := range issues {
+		if err := enc.Encode(is); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SARIF (Static Analysis Results Interchange Format) 2.1.0 log, see:
+//
+//	https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type (
+	sarifLog struct {
+		Version string     `json:"version"`
+		Schema  string     `json:"$schema"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		Version        string      `json:"version,omitempty"`
+		InformationURI string      `json:"informationUri,omitempty"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine int `json:"startLine"`
+	}
+)
+
+// writeLintSARIF writes the issues to w as a SARIF log.
+func writeLintSARIF(w io.Writer, issues []lintIssue) error {
+	driver := sarifDriver{
+		Name:    "fetchref",
+		Version: metainfo.Version,
+	}
+	if strings.HasPrefix(metainfo.Url, "http") {
+		driver.InformationURI = metainfo.Url
+	}
+	var ids []string
+	for id := range bib.Rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:               id,
+			ShortDescription: sarifMessage{Text: bib.Rules[id]},
+		})
+	}
+	run := sarifRun{
+		Tool:    sarifTool{Driver: driver},
+		Results: []sarifResult{},
+	}
+	for _, is := range issues {
+		loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: filepathToURI(is.File)},
+		}}
+		if is.Line > 0 {
+			loc.PhysicalLocation.Region = &sarifRegion{StartLine: is.Line}
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    is.Rule,
+			Level:     is.Level.String(),
+			Message:   sarifMessage{Text: is.Key + ": " + is.Message},
+			Locations: []sarifLocation{loc},
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// filepathToURI converts a file path to a URI, or a relative URI reference,
+// as used by SARIF artifact locations.
+func filepathToURI(name string) string {
+	u := url.URL{Path: filepath.ToSlash(name)}
+	if filepath.IsAbs(name) {
+		u.Scheme = "file"
+	}
+	return u.String()
+}
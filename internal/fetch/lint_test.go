@@ -0,0 +1,103 @@
+package fetch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Milover/fetchref/internal/bib"
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/stretchr/testify/assert"
+)
+
+const testLintBib = `@article{retracted,
+  title   = {Retracted},
+  author  = {Doe, J.},
+  journal = {Journal},
+  year    = {2001},
+  doi     = {10.1000/retracted},
+}
+
+@article{corrected,
+  title   = {Corrected},
+  author  = {Doe, J.},
+  journal = {Journal},
+  doi     = {10.1000/corrected},
+}
+`
+
+func TestLint(t *testing.T) {
+	assert := assert.New(t)
+	standInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		d := r.URL.Query().Get(crossref.QueryKeyFilter)[len(crossref.QueryValFilterUpdates):]
+		typ := map[string]string{
+			"10.1000/retracted": "retraction",
+			"10.1000/corrected": "correction",
+		}[d]
+		fmt.Fprintf(w, `{"status": "ok", "message": {"items": [{
+			"DOI": "10.1000/notice",
+			"update-to": [{"DOI": %q, "type": %q, "label": "Notice"}]
+		}]}}`, d, typ)
+	})
+	defer func() { LintFmt, LintFailLevel, LintOffline = LintText, bib.LevelError, false }()
+
+	name := filepath.Join(t.TempDir(), "refs.bib")
+	assert.Nil(os.WriteFile(name, []byte(testLintBib), 0666))
+
+	var b bytes.Buffer
+	assert.NotNil(Lint(&b, []string{name}))
+	assert.Equal(name+`:6: error: retracted: 10.1000/retracted has been retracted (Notice: 10.1000/notice) [retracted]
+`+name+`:9: warning: corrected: missing field year [missing-field]
+`, b.String())
+
+	LintOffline, LintFailLevel = true, bib.LevelNote
+	LintFmt = LintJSON
+	b.Reset()
+	assert.NotNil(Lint(&b, []string{name}))
+	var is lintIssue
+	assert.Nil(json.Unmarshal(b.Bytes(), &is))
+	assert.Equal(name, is.File)
+	assert.Equal(bib.RuleMissingField, is.Rule)
+	assert.Contains(b.String(), `"level":"warning"`)
+
+	LintFmt, LintFailLevel = LintSARIF, bib.LevelError
+	b.Reset()
+	assert.Nil(Lint(&b, []string{name}))
+	var log sarifLog
+	assert.Nil(json.Unmarshal(b.Bytes(), &log))
+	if assert.Len(log.Runs, 1) && assert.Len(log.Runs[0].Results, 1) {
+		res := log.Runs[0].Results[0]
+		assert.Equal("missing-field", res.RuleID)
+		assert.Equal("warning", res.Level)
+		assert.Equal("file://"+filepath.ToSlash(name), res.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+		assert.Equal(9, res.Locations[0].PhysicalLocation.Region.StartLine)
+	}
+	assert.Len(log.Runs[0].Tool.Driver.Rules, len(bib.Rules))
+}
+
+func TestLintLookupFailure(t *testing.T) {
+	assert := assert.New(t)
+	standInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	name := filepath.Join(t.TempDir(), "refs.bib")
+	assert.Nil(os.WriteFile(name, []byte(testLintBib), 0666))
+
+	var b bytes.Buffer
+	assert.NotNil(Lint(&b, []string{name}))
+	assert.Contains(b.String(), name+":6: error: retracted: could not check whether 10.1000/retracted has been retracted")
+	assert.NotContains(b.String(), "[retracted]")
+	assert.Contains(b.String(), "[retraction-check]")
+	assert.Contains(b.String(), "could not check whether 10.1000/corrected has been retracted")
+
+	// not checked at all when offline
+	defer func() { LintOffline = false }()
+	LintOffline = true
+	b.Reset()
+	assert.Nil(Lint(&b, []string{name}))
+	assert.NotContains(b.String(), "could not check")
+}
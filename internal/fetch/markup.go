@@ -0,0 +1,145 @@
+package fetch
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Milover/fetchref/internal/bib"
+	"github.com/Milover/fetchref/internal/markup"
+	"github.com/Milover/fetchref/internal/outfile"
+)
+
+var (
+	// MarkupFmt is the format of bibliographies of Markdown, Typst and
+	// Org documents.
+	MarkupFmt = bib.CSLJSON
+
+	// MarkupOutput is the name of the file to which the bibliography of
+	// a document is written, instead of the standard output.
+	MarkupOutput string
+
+	// MarkupRewrite controls whether documents are rewritten to cite
+	// generated keys instead of identifiers.
+	MarkupRewrite = false
+)
+
+// Markup resolves the citations of the named Markdown (Pandoc), Typst or
+// Org document (see markup.Scan), whose keys are DOIs, ISBNs or arXiv
+// identifiers, e.g., '[@doi:10.1000/xyz]', by fetching their metadata from
+// Crossref (or arXiv), and writes the bibliography, in MarkupFmt format, to
+// MarkupOutput, if set, or w. If MarkupRewrite is set, the document is
+// rewritten to cite generated keys, e.g., 'Smith_2020', which are also
+// the keys of the bibliography, instead of identifiers. Other keys are left
+// as they are. An error is returned if any identifier cannot be resolved.
+func Markup(w io.Writer, name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	syntax, err := markup.Detect(name)
+	if err != nil {
+		return err
+	}
+	cs := markup.Scan(string(data), syntax)
+
+	// works are cited by one, or, e.g., 'doi:10.1000/xyz' and
+	// '10.1000/xyz', by several keys
+	type work struct {
+		entry *bib.Entry
+		keys  []string
+	}
+	var works []*work
+	byID := make(map[string]*work)
+	seen := make(map[string]bool)
+	var others []string
+	for _, c := range cs {
+		if seen[c.Key] {
+			continue
+		}
+		seen[c.Key] = true
+		e, ok := identEntry(c.Key, c.Key)
+		if !ok {
+			others = append(others, c.Key)
+			continue
+		}
+		id := strings.ToLower(e.Fields[0].Name + ":" + e.Fields[0].Value)
+		if wk, found := byID[id]; found {
+			wk.keys = append(wk.keys, c.Key)
+			continue
+		}
+		wk := &work{entry: e, keys: []string{c.Key}}
+		byID[id] = wk
+		works = append(works, wk)
+	}
+	log.Printf("%v of %v cited keys are identifiers", len(seen)-len(others), len(seen))
+
+	seeds := make([]*bib.Entry, len(works))
+	for i, wk := range works {
+		seeds[i] = wk.entry
+	}
+	var entries []bib.Entry
+	var resolved []*work
+	var unresolved []string
+	for i, changes := range enrichEntries(seeds, bib.Fill) {
+		wk := works[i]
+		if len(changes) == 0 {
+			unresolved = append(unresolved, wk.keys...)
+			continue
+		}
+		if MarkupRewrite {
+			e := *wk.entry
+			e.Key = ""
+			entries = append(entries, e)
+			resolved = append(resolved, wk)
+			continue
+		}
+		for _, k := range wk.keys {
+			e := *wk.entry
+			e.Key = k
+			entries = append(entries, e)
+		}
+	}
+
+	if MarkupRewrite && len(entries) != 0 {
+		// generated keys must not clash with other cited keys
+		n := len(entries)
+		for _, k := range others {
+			entries = append(entries, bib.Entry{Key: k})
+		}
+		bib.UniqueKeys(entries)
+		entries = entries[:n]
+		keys := make(map[string]string)
+		for i, wk := range resolved {
+			for _, k := range wk.keys {
+				keys[k] = entries[i].Key
+			}
+		}
+		text := markup.Rewrite(string(data), cs, keys)
+		if err := outfile.WriteFile(name, []byte(text), 0666); err != nil {
+			return err
+		}
+		log.Printf("%v: %v cited keys rewritten", name, len(keys))
+	}
+
+	var b bytes.Buffer
+	if err := bib.Write(&b, entries, MarkupFmt); err != nil {
+		return err
+	}
+	if len(MarkupOutput) != 0 {
+		err = outfile.WriteFile(MarkupOutput, b.Bytes(), 0666)
+	} else {
+		_, err = w.Write(b.Bytes())
+	}
+	if err != nil {
+		return err
+	}
+	if len(unresolved) != 0 {
+		return fmt.Errorf("%v cited key(s) could not be resolved: %v",
+			len(unresolved), strings.Join(unresolved, ", "))
+	}
+	return nil
+}
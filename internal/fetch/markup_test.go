@@ -0,0 +1,56 @@
+package fetch
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Milover/fetchref/internal/bib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkup(t *testing.T) {
+	assert := assert.New(t)
+	standInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/10.1103%2FPhysRevLett.116.061102") &&
+			!strings.HasSuffix(r.URL.Path, "/10.1103/PhysRevLett.116.061102") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testRefreshWork))
+	})
+	defer func() { MarkupFmt, MarkupOutput, MarkupRewrite = bib.CSLJSON, "", false }()
+
+	name := filepath.Join(t.TempDir(), "notes.md")
+	text := "[@doi:10.1103/PhysRevLett.116.061102; @10.1103/PhysRevLett.116.061102]\n" +
+		"and @Abbott_2016, but not [@10.1000/missing].\n"
+	assert.Nil(os.WriteFile(name, []byte(text), 0666))
+
+	var b bytes.Buffer
+	err := Markup(&b, name)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "1 cited key(s) could not be resolved: 10.1000/missing")
+	}
+	es, err := bib.ParseCSL(b.Bytes())
+	if assert.Nil(err) && assert.Len(es, 2) {
+		assert.Equal("doi:10.1103/PhysRevLett.116.061102", es[0].Key)
+		assert.Equal("10.1103/PhysRevLett.116.061102", es[1].Key)
+		assert.Equal("Observation of Gravitational Waves", es[1].Get("title"))
+	}
+
+	MarkupFmt, MarkupRewrite = bib.Hayagriva, true
+	MarkupOutput = filepath.Join(t.TempDir(), "refs.yml")
+	b.Reset()
+	assert.NotNil(Markup(&b, name))
+	assert.Empty(b.String())
+	data, err := os.ReadFile(name)
+	assert.Nil(err)
+	assert.Equal("[@Abbott_2016a; @Abbott_2016a]\n"+
+		"and @Abbott_2016, but not [@10.1000/missing].\n", string(data))
+	data, err = os.ReadFile(MarkupOutput)
+	assert.Nil(err)
+	assert.True(strings.HasPrefix(string(data), "\"Abbott_2016a\":\n  type: \"article\"\n"), string(data))
+}
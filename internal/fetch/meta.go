@@ -0,0 +1,149 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/Milover/fetchref/internal/issn"
+	"golang.org/x/sync/errgroup"
+)
+
+// Meta retrieves metadata records from Crossref for a list of supplied
+// handles (DOIs, ISBNs, ISSNs, arXiv identifiers, PMIDs and/or PMCIDs),
+// and writes them as JSON to w. Works are returned for DOIs and ISBNs,
+// journals for ISSNs, while metadata for arXiv identifiers is retrieved
+// from arXiv, and for PMIDs and PMCIDs from PubMed.
+func Meta(w io.Writer, handles []string) error {
+	if len(handles) == 0 {
+		return nil
+	}
+	valid := validHandles(handles)
+	records, err := metaRecords(valid)
+	if References > 0 {
+		seen := make(map[string]bool)
+		for _, h := range valid {
+			seen[strings.ToLower(h.Value)] = true
+		}
+		records = append(records, expandMetaReferences(records, seen)...)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	for _, r := range records {
+		if r == nil {
+			continue
+		}
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// metaRecords requests the metadata records for the handles (see Meta).
+// Records which cannot be retrieved are nil.
+func metaRecords(handles []article.Handle) ([]interface{}, error) {
+	records := make([]interface{}, len(handles))
+
+	g := new(errgroup.Group)
+	for i := range handles {
+		h := handles[i]
+		r := &records[i]
+
+		g.Go(func() error {
+			if h.Type == article.ISSN {
+				j, err := reqCrossrefJournal(h.Value)
+				if err != nil {
+					return logErr(h.Value, err)
+				}
+				logISSNType(h.Value, j)
+				*r = j
+				return nil
+			}
+			if h.Type == article.ArXiv {
+				e, err := reqArXivEntry(h.Value)
+				if err != nil {
+					return logErr(h.Value, err)
+				}
+				*r = e
+				return nil
+			}
+			if h.Type == article.PMID || h.Type == article.PMCID {
+				rec, err := reqPubMedIDs(context.Background(), h)
+				if err != nil {
+					return logErr(h.Value, err)
+				}
+				sum, err := reqPubMedSummary(rec)
+				if err != nil {
+					return logErr(h.Value, err)
+				}
+				*r = sum
+				return nil
+			}
+			a := &article.Article{Handle: h}
+			work, err := reqCrossrefMeta(a)
+			if err != nil {
+				// fall back to the publisher's landing page
+				if h.Type == article.DOI && reqLandingMeta(a) == nil {
+					*r = a.Meta
+					return nil
+				}
+				return logErr(h.Value, err)
+			}
+			*r = work
+			return nil
+		})
+	}
+	err := g.Wait()
+	return records, err
+}
+
+// logISSNType logs whether the ISSN is the print or electronic ISSN
+// of the journal.
+func logISSNType(n string, j crossref.Journal) {
+	for _, t := range j.ISSNType {
+		if t.Value == n {
+			log.Printf("%v: %v ISSN of %q", n, issn.ParseType(t.Type), j.Title)
+			return
+		}
+	}
+}
+
+// reqCrossrefJournal requests the journal metadata from Crossref.
+func reqCrossrefJournal(n string) (crossref.Journal, error) {
+	u := &url.URL{
+		Scheme: "https",
+		Host:   crossref.API,
+		Path:   crossref.APIJournals,
+	}
+	u = u.JoinPath(url.PathEscape(n))
+
+	ctx, cncl := context.WithTimeout(context.Background(), GlobalReqTimeout)
+	defer cncl()
+
+	res, err := sendGetRequest(ctx, u.String())
+	if err != nil {
+		return crossref.Journal{}, err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return crossref.Journal{}, err
+	}
+	var msg crossref.JournalMessage
+	if err = json.Unmarshal(b, &msg); err != nil {
+		return crossref.Journal{}, err
+	}
+	if len(msg.Message.Title) == 0 {
+		return crossref.Journal{}, fmt.Errorf("crossref: no such journal")
+	}
+	return msg.Message, nil
+}
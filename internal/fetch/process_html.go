@@ -32,11 +32,18 @@ func newHse(
 // data is extracted from the node by the 'extractor', otherwise another node
 // is selected. Both children and sibling nodes are walked.
 func getFromHTML(n *html.Node, hse htmlSelectorExtractor) {
-	if hse.selector(n) {
-		hse.data.WriteString(hse.extractor(n))
-	}
+	walkHTML(n, func(n *html.Node) {
+		if hse.selector(n) {
+			hse.data.WriteString(hse.extractor(n))
+		}
+	})
+}
+
+// walkHTML walks an HTML tree depth-first, and calls fn on each node.
+func walkHTML(n *html.Node, fn func(*html.Node)) {
+	fn(n)
 	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		getFromHTML(c, hse)
+		walkHTML(c, fn)
 	}
 }
 
@@ -56,3 +63,56 @@ func extractURL(n *html.Node) string {
 	}
 	return ""
 }
+
+// selectMetaNode returns a selector which selects the first HTML meta node
+// with the given name (or property) attribute.
+func selectMetaNode(name string) func(*html.Node) bool {
+	var found bool
+	return func(n *html.Node) bool {
+		if found || !strings.EqualFold(metaName(n), name) {
+			return false
+		}
+		found = true
+		return true
+	}
+}
+
+// metaName returns the name (or property) attribute of a HTML meta node,
+// or an empty string if the node is not a meta node.
+func metaName(n *html.Node) string {
+	if n.Type != html.ElementNode || n.Data != "meta" {
+		return ""
+	}
+	for _, atr := range n.Attr {
+		if atr.Key == "name" || atr.Key == "property" {
+			return atr.Val
+		}
+	}
+	return ""
+}
+
+// collectMeta collects the contents of all named HTML meta nodes in an
+// HTML tree, keyed by their (lower-case) names, in document order.
+func collectMeta(n *html.Node) map[string][]string {
+	meta := make(map[string][]string)
+	walkHTML(n, func(n *html.Node) {
+		name := strings.ToLower(metaName(n))
+		if len(name) == 0 {
+			return
+		}
+		if c := extractMetaContent(n); len(c) != 0 {
+			meta[name] = append(meta[name], c)
+		}
+	})
+	return meta
+}
+
+// extractMetaContent extracts the content of a HTML meta node.
+func extractMetaContent(n *html.Node) string {
+	for _, atr := range n.Attr {
+		if atr.Key == "content" {
+			return strings.TrimSpace(atr.Val)
+		}
+	}
+	return ""
+}
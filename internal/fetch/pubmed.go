@@ -0,0 +1,205 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/pubmed"
+)
+
+// pubmedQuery returns the query parameters identifying the tool, and
+// the user, if Mailto is set, to NCBI's APIs, as required by NCBI.
+func pubmedQuery() url.Values {
+	query := url.Values{}
+	query.Add(pubmed.QueryKeyTool, pubmed.QueryValTool)
+	if len(Mailto) != 0 {
+		query.Add(pubmed.QueryKeyEmail, Mailto)
+	}
+	return query
+}
+
+// reqPubMedIDs converts a PMID or PMCID into the article's other
+// identifiers by querying NCBI's PMC ID converter.
+// Articles which are not in PMC cannot be converted, in which case
+// only the supplied identifier is returned.
+func reqPubMedIDs(ctx context.Context, h article.Handle) (pubmed.IDConvRecord, error) {
+	rec := pubmed.IDConvRecord{}
+	switch h.Type {
+	case article.PMID:
+		rec.PMID = h.Value
+	case article.PMCID:
+		rec.PMCID = h.Value
+	default:
+		return rec, fmt.Errorf("unknown article handle type: %v", h.Type)
+	}
+
+	u, err := url.Parse(pubmed.IDConvURL)
+	if err != nil {
+		return rec, err
+	}
+	query := pubmedQuery()
+	query.Add(pubmed.QueryKeyIDs, h.Value)
+	query.Add(pubmed.QueryKeyFormat, pubmed.QueryValFormat)
+	u.RawQuery = query.Encode()
+
+	ctx, cncl := context.WithTimeout(ctx, GlobalReqTimeout)
+	defer cncl()
+
+	res, err := sendGetRequest(ctx, u.String())
+	if err != nil {
+		return rec, err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return rec, err
+	}
+	var msg pubmed.IDConvMessage
+	if err = json.Unmarshal(b, &msg); err != nil {
+		return rec, err
+	}
+	if len(msg.Records) == 0 || msg.Records[0].Status == "error" {
+		return rec, nil
+	}
+	return msg.Records[0], nil
+}
+
+// reqPubMedSummary requests the document summary of an article from
+// NCBI's E-utilities. PubMed is queried if the PMID is known,
+// otherwise PMC is queried.
+func reqPubMedSummary(rec pubmed.IDConvRecord) (pubmed.Summary, error) {
+	u, err := url.Parse(pubmed.ESummaryURL)
+	if err != nil {
+		return pubmed.Summary{}, err
+	}
+	query := pubmedQuery()
+	id := rec.PMID
+	if len(id) != 0 {
+		query.Add(pubmed.QueryKeyDB, pubmed.QueryValDBPubMed)
+	} else {
+		id = strings.TrimPrefix(rec.PMCID, "PMC")
+		query.Add(pubmed.QueryKeyDB, pubmed.QueryValDBPMC)
+	}
+	query.Add(pubmed.QueryKeyID, id)
+	query.Add(pubmed.QueryKeyRetMode, pubmed.QueryValRetMode)
+	u.RawQuery = query.Encode()
+
+	ctx, cncl := context.WithTimeout(context.Background(), GlobalReqTimeout)
+	defer cncl()
+
+	res, err := sendGetRequest(ctx, u.String())
+	if err != nil {
+		return pubmed.Summary{}, err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return pubmed.Summary{}, err
+	}
+	// the result is keyed by the UID of the record
+	var msg struct {
+		Result map[string]json.RawMessage `json:"result"`
+	}
+	if err = json.Unmarshal(b, &msg); err != nil {
+		return pubmed.Summary{}, err
+	}
+	raw, found := msg.Result[id]
+	if !found {
+		return pubmed.Summary{}, fmt.Errorf("pubmed: no query results")
+	}
+	var sum pubmed.Summary
+	if err = json.Unmarshal(raw, &sum); err != nil {
+		return pubmed.Summary{}, err
+	}
+	return sum, nil
+}
+
+// reqPubMedMeta resolves a PMID or PMCID, and sets the article title,
+// DOI and metadata.
+func reqPubMedMeta(a *article.Article) error {
+	rec, err := reqPubMedIDs(context.Background(), a.Handle)
+	if err != nil {
+		return err
+	}
+	sum, err := reqPubMedSummary(rec)
+	if err != nil {
+		return err
+	}
+	a.Title = strings.TrimSuffix(sum.Title, ".")
+	a.DOI = rec.DOI
+	for _, id := range sum.ArticleIDs {
+		if len(a.DOI) == 0 && id.IDType == pubmed.ArticleIDTypeDOI {
+			a.DOI = id.Value
+		}
+	}
+	w := sum.Work(a.DOI)
+	a.Meta = &w
+	return nil
+}
+
+// reqPMCOAInfo requests the article's open access full-text links from
+// the PMC OA web service, and sets the article download URL to the PDF.
+// Only articles in the PMC open access subset can be downloaded.
+func reqPMCOAInfo(ctx context.Context, a *article.Article, service string) error {
+	rec, err := reqPubMedIDs(ctx, a.Handle)
+	if err != nil {
+		return err
+	}
+	if len(rec.PMCID) == 0 {
+		return fmt.Errorf("pmc: article not in PMC")
+	}
+
+	u, err := url.Parse(service)
+	if err != nil {
+		return err
+	}
+	query := pubmedQuery()
+	query.Add(pubmed.QueryKeyID, rec.PMCID)
+	u.RawQuery = query.Encode()
+
+	ctx, cncl := context.WithTimeout(ctx, GlobalReqTimeout)
+	defer cncl()
+
+	res, err := sendGetRequest(ctx, u.String())
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	var msg pubmed.OAMessage
+	if err = xml.Unmarshal(b, &msg); err != nil {
+		return err
+	}
+	if msg.Error != nil {
+		return fmt.Errorf("pmc: %v", msg.Error.Message)
+	}
+	if len(msg.Records) == 0 {
+		return fmt.Errorf("pmc: no query results")
+	}
+	for _, l := range msg.Records[0].Links {
+		if l.Format != pubmed.OAFormatPDF {
+			continue
+		}
+		if a.Url, err = url.Parse(l.Href); err != nil {
+			return err
+		}
+		// NCBI serves the FTP tree over HTTPS as well
+		if a.Url.Scheme == "ftp" {
+			a.Url.Scheme = "https"
+		}
+		return nil
+	}
+	return fmt.Errorf("pmc: no PDF available, only: %v", msg.Records[0].Links)
+}
@@ -0,0 +1,186 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/pubmed"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testIDConv = `{"status":"ok","records":[` +
+		`{"pmcid":"PMC3531190","pmid":"23193287","doi":"10.1093/nar/gks1195"}]}`
+	testIDConvNotInPMC = `{"status":"ok","records":[` +
+		`{"pmid":"1","status":"error","errmsg":"invalid article id"}]}`
+	testESummary = `{"result":{"uids":["23193287"],"23193287":{` +
+		`"uid":"23193287","title":"Database resources of the NCBI.",` +
+		`"source":"Nucleic Acids Res","authors":[{"name":"NCBI RC"}],` +
+		`"articleids":[{"idtype":"doi","value":"10.1093/nar/gks1195"}]}}}`
+	testESummaryNoDOI = `{"result":{"uids":["1"],"1":{` +
+		`"uid":"1","title":"Formate assay in body fluids.","articleids":[]}}}`
+	testOA = `<OA><records returned-count="1" total-count="1">` +
+		`<record id="PMC3531190" license="CC BY-NC">` +
+		`<link format="tgz" href="ftp://ftp.ncbi.nlm.nih.gov/pub/pmc/oa_package/PMC3531190.tar.gz"/>` +
+		`<link format="pdf" href="ftp://ftp.ncbi.nlm.nih.gov/pub/pmc/oa_pdf/gks1195.pdf"/>` +
+		`</record></records></OA>`
+	testOANotOA = `<OA><error code="idIsNotOpenAccess">` +
+		`identifier 'PMC1' is not Open Access</error></OA>`
+)
+
+// newPubMedStandIn starts a local stand-in for NCBI's APIs and points
+// the pubmed package at it. Requests which do not identify the tool
+// and the user are rejected.
+func newPubMedStandIn(t *testing.T) {
+	mux := http.NewServeMux()
+	identified := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			if q.Get(pubmed.QueryKeyTool) != pubmed.QueryValTool ||
+				q.Get(pubmed.QueryKeyEmail) != Mailto {
+				http.Error(w, "tool and email not set", http.StatusBadRequest)
+				return
+			}
+			h(w, r)
+		}
+	}
+	mux.HandleFunc("/idconv", identified(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get(pubmed.QueryKeyIDs) {
+		case "23193287", "PMC3531190":
+			fmt.Fprint(w, testIDConv)
+		default:
+			fmt.Fprint(w, testIDConvNotInPMC)
+		}
+	}))
+	mux.HandleFunc("/esummary", identified(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get(pubmed.QueryKeyID) {
+		case "23193287":
+			fmt.Fprint(w, testESummary)
+		default:
+			fmt.Fprint(w, testESummaryNoDOI)
+		}
+	}))
+	mux.HandleFunc("/oa", identified(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get(pubmed.QueryKeyID) {
+		case "PMC3531190":
+			fmt.Fprint(w, testOA)
+		default:
+			fmt.Fprint(w, testOANotOA)
+		}
+	}))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	idconv, esummary, oa := pubmed.IDConvURL, pubmed.ESummaryURL, pubmed.OAURL
+	t.Cleanup(func() {
+		pubmed.IDConvURL, pubmed.ESummaryURL, pubmed.OAURL = idconv, esummary, oa
+	})
+	pubmed.IDConvURL = srv.URL + "/idconv"
+	pubmed.ESummaryURL = srv.URL + "/esummary"
+	pubmed.OAURL = srv.URL + "/oa"
+}
+
+type pubmedTest struct {
+	Name   string
+	Input  string
+	Handle article.Handle
+	Title  string
+	DOI    string
+	URL    string
+	Err    bool
+}
+
+var pubmedTests = []pubmedTest{
+	{
+		Name:   "good-pmid",
+		Input:  "23193287",
+		Handle: article.Handle{Value: "23193287", Type: article.PMID},
+		Title:  "Database resources of the NCBI",
+		DOI:    "10.1093/nar/gks1195",
+		URL:    "https://ftp.ncbi.nlm.nih.gov/pub/pmc/oa_pdf/gks1195.pdf",
+	},
+	{
+		Name:   "good-pmcid-prefix",
+		Input:  "pmcid:pmc3531190",
+		Handle: article.Handle{Value: "PMC3531190", Type: article.PMCID},
+		Title:  "Database resources of the NCBI",
+		DOI:    "10.1093/nar/gks1195",
+		URL:    "https://ftp.ncbi.nlm.nih.gov/pub/pmc/oa_pdf/gks1195.pdf",
+	},
+	{
+		Name:   "good-pmid-not-in-pmc",
+		Input:  "PMID:1",
+		Handle: article.Handle{Value: "1", Type: article.PMID},
+		Title:  "Formate assay in body fluids",
+		Err:    true,
+	},
+}
+
+func TestPubMed(t *testing.T) {
+	newPubMedStandIn(t)
+	defer func() { Mailto = "" }()
+	Mailto = "user@example.com"
+
+	for _, tt := range pubmedTests {
+		t.Run(tt.Name, func(t *testing.T) {
+			h := validHandles([]string{tt.Input})
+			assert.Equal(t, []article.Handle{tt.Handle}, h)
+
+			a := &article.Article{Handle: h[0]}
+			assert.Nil(t, reqPubMedMeta(a))
+			assert.Equal(t, tt.Title, a.Title)
+			assert.Equal(t, tt.DOI, a.DOI)
+
+			err := reqPMCOAInfo(context.Background(), a, pubmed.OAURL)
+			if tt.Err {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.URL, a.Url.String())
+		})
+	}
+}
+
+func TestValidHandles(t *testing.T) {
+	var tests = []struct {
+		Name   string
+		Input  string
+		Handle article.Handle
+	}{
+		{
+			Name:   "pmid-issn-checksum",
+			Input:  "23193298",
+			Handle: article.Handle{Value: "23193298", Type: article.PMID},
+		},
+		{
+			Name:   "issn-dash",
+			Input:  "0022-1120",
+			Handle: article.Handle{Value: "0022-1120", Type: article.ISSN},
+		},
+		{
+			Name:   "issn-prefix",
+			Input:  "ISSN:00221120",
+			Handle: article.Handle{Value: "0022-1120", Type: article.ISSN},
+		},
+		{
+			Name:   "pmcid",
+			Input:  "PMC3531190",
+			Handle: article.Handle{Value: "PMC3531190", Type: article.PMCID},
+		},
+		{
+			Name:   "isbn",
+			Input:  "978-0-306-40615-7",
+			Handle: article.Handle{Value: "9780306406157", Type: article.ISBN},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, []article.Handle{tt.Handle}, validHandles([]string{tt.Input}))
+		})
+	}
+}
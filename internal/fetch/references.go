@@ -0,0 +1,243 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/Milover/fetchref/internal/doi"
+	"github.com/Milover/fetchref/internal/isbn"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	// References is the depth to which the works referenced by fetched
+	// works are also fetched, i.e., 1 fetches the works they reference,
+	// 2 also the works referenced by these, etc., 0 for none.
+	References = 0
+
+	// ReferenceMinScore is the minimum Crossref relevance score of the
+	// work matching a reference without a DOI, for the reference to be
+	// resolved to the work.
+	ReferenceMinScore = 60.0
+)
+
+// referenceWorkers is the number of references resolved concurrently.
+const referenceWorkers = 8
+
+// expandReferences appends the works referenced by the articles, up to
+// References levels deep, to the articles, requests their metadata and
+// returns the articles. Works which are already among the articles are not
+// appended again, and unresolved references are reported.
+func expandReferences(articles []article.Article, gen func(*article.Article) string) []article.Article {
+	seen := make(map[string]bool)
+	for i := range articles {
+		seen[strings.ToLower(articles[i].Handle.Value)] = true
+		seen[strings.ToLower(articles[i].DOI)] = true
+	}
+	var unresolved []crossref.Reference
+	total := 0
+	start := 0
+	for depth := 1; depth <= References; depth++ {
+		end := len(articles)
+		var refs []crossref.Reference
+		for i := start; i < end; i++ {
+			if articles[i].Meta != nil {
+				refs = append(refs, articles[i].Meta.Reference...)
+			}
+		}
+		handles, unres := resolveReferences(refs, seen)
+		total += len(refs)
+		unresolved = append(unresolved, unres...)
+		for _, h := range handles {
+			articles = append(articles, article.Article{Handle: h})
+			articles[len(articles)-1].GeneratorFunc(gen)
+		}
+		log.Printf("references (depth %v): %v references, %v new works",
+			depth, len(refs), len(handles))
+		if len(handles) == 0 {
+			break
+		}
+		if err := fetchMetas(articles[end:]); err != nil {
+			log.Println("errors occurred during metadata fetch")
+		}
+		start = end
+	}
+	logUnresolved(unresolved, total)
+	return articles
+}
+
+// expandMetaReferences requests the metadata records of the works referenced
+// by the works among the records, up to References levels deep, and returns
+// them. The handles of the records are seen, i.e., they are not requested
+// again, and unresolved references are reported.
+func expandMetaReferences(records []interface{}, seen map[string]bool) []interface{} {
+	var unresolved []crossref.Reference
+	var expanded []interface{}
+	total := 0
+	level := records
+	for depth := 1; depth <= References; depth++ {
+		var refs []crossref.Reference
+		for _, r := range level {
+			switch w := r.(type) {
+			case crossref.Work:
+				refs = append(refs, w.Reference...)
+			case *crossref.Work:
+				if w != nil {
+					refs = append(refs, w.Reference...)
+				}
+			}
+		}
+		handles, unres := resolveReferences(refs, seen)
+		total += len(refs)
+		unresolved = append(unresolved, unres...)
+		log.Printf("references (depth %v): %v references, %v new works",
+			depth, len(refs), len(handles))
+		if len(handles) == 0 {
+			break
+		}
+		level, _ = metaRecords(handles) // errors are logged
+		expanded = append(expanded, level...)
+	}
+	logUnresolved(unresolved, total)
+	return expanded
+}
+
+// resolveReferences returns the handles of the referenced works, i.e.,
+// their DOIs or ISBNs, or the DOIs of the works matching references without
+// either (see reqReferenceDOI), which are not seen, and the references
+// which cannot be resolved. The handles are marked as seen.
+func resolveReferences(refs []crossref.Reference, seen map[string]bool) ([]article.Handle, []crossref.Reference) {
+	found := make([]article.Handle, len(refs))
+	g := new(errgroup.Group)
+	g.SetLimit(referenceWorkers)
+	for i := range refs {
+		i, ref := i, refs[i]
+		switch d := doi.Clean(ref.DOI); {
+		case doi.IsValid(d):
+			found[i] = article.Handle{Value: d, Type: article.DOI}
+			continue
+		case isbn.IsValid(ref.ISBN):
+			found[i] = article.Handle{Value: isbn.Clean(ref.ISBN), Type: article.ISBN}
+			continue
+		}
+		g.Go(func() error {
+			d, err := reqReferenceDOI(ref)
+			if err != nil {
+				return err
+			}
+			found[i] = article.Handle{Value: d, Type: article.DOI}
+			return nil
+		})
+	}
+	_ = g.Wait() // unresolved references are reported
+
+	var handles []article.Handle
+	var unresolved []crossref.Reference
+	for i, h := range found {
+		if len(h.Value) == 0 {
+			unresolved = append(unresolved, refs[i])
+			continue
+		}
+		if k := strings.ToLower(h.Value); !seen[k] {
+			seen[k] = true
+			handles = append(handles, h)
+		}
+	}
+	return handles, unresolved
+}
+
+// referenceQuery returns the bibliographic query for a reference, i.e.,
+// its unstructured citation, or its structured fields.
+func referenceQuery(ref crossref.Reference) string {
+	if s := strings.TrimSpace(ref.Unstructured); len(s) != 0 {
+		return s
+	}
+	title := ref.JournalTitle
+	if len(title) == 0 {
+		title = ref.VolumeTitle
+	}
+	var ss []string
+	for _, s := range []string{ref.Author, ref.ArticleTitle, title, ref.Volume, ref.FirstPage, ref.Year} {
+		if s = strings.TrimSpace(s); len(s) != 0 {
+			ss = append(ss, s)
+		}
+	}
+	return strings.Join(ss, ", ")
+}
+
+// reqReferenceDOI requests the work best matching the reference from
+// Crossref, by a bibliographic query, and returns its DOI, if the match
+// scores at least ReferenceMinScore, and was published in (about) the year
+// of the reference, if known.
+func reqReferenceDOI(ref crossref.Reference) (string, error) {
+	q := referenceQuery(ref)
+	if len(q) == 0 {
+		return "", fmt.Errorf("empty reference")
+	}
+	u := &url.URL{
+		Scheme: "https",
+		Host:   crossref.API,
+		Path:   crossref.APIWorks,
+	}
+	query := url.Values{}
+	query.Add(crossref.QueryKeyBib, q)
+	query.Add(crossref.QueryKeyRows, crossref.QueryValRows)
+	u.RawQuery = query.Encode()
+
+	ctx, cncl := context.WithTimeout(context.Background(), GlobalReqTimeout)
+	defer cncl()
+
+	res, err := sendGetRequest(ctx, u.String())
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	var msg crossref.WorksMessage
+	if err = json.Unmarshal(b, &msg); err != nil {
+		return "", err
+	}
+	if len(msg.Message.Items) == 0 {
+		return "", fmt.Errorf("crossref: no query results")
+	}
+	w := msg.Message.Items[0]
+	if w.Score < ReferenceMinScore {
+		return "", fmt.Errorf("no confident match: %v (score %.1f)", w.DOI, w.Score)
+	}
+	if y, err := strconv.Atoi(strings.TrimSpace(ref.Year)); err == nil && w.Year() > 0 &&
+		(w.Year()-y > 1 || y-w.Year() > 1) {
+		return "", fmt.Errorf("no matching year: %v (%v)", w.DOI, w.Year())
+	}
+	return w.DOI, nil
+}
+
+// logUnresolved logs the references which cannot be resolved, out of
+// the total number of references.
+func logUnresolved(unresolved []crossref.Reference, total int) {
+	if total == 0 {
+		return
+	}
+	log.Printf("%v of %v references could not be resolved", len(unresolved), total)
+	for _, ref := range unresolved {
+		s := referenceQuery(ref)
+		if len(s) == 0 {
+			s = "(empty reference)"
+		}
+		if len(ref.Key) != 0 {
+			s = ref.Key + ": " + s
+		}
+		log.Printf("unresolved reference: %v", s)
+	}
+}
@@ -0,0 +1,119 @@
+package fetch
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/stretchr/testify/assert"
+)
+
+// testCitingWork is a Crossref work which references a work by DOI, one
+// which can be matched by its unstructured citation, and one which cannot.
+const testCitingWork = `{"status": "ok", "message": {
+	"DOI": "10.1000/citing",
+	"type": "journal-article",
+	"title": ["A Citing Paper"],
+	"issued": {"date-parts": [[2020]]},
+	"reference": [
+		{"key": "ref1", "DOI": "10.1103/PhysRevLett.116.061102"},
+		{"key": "ref2", "unstructured": "B. P. Abbott et al., Phys. Rev. Lett. 116, 061102 (2016)"},
+		{"key": "ref3", "unstructured": "J. Doe, personal communication (2019)"}
+	]
+}}`
+
+// referencesHandler serves testCitingWork and testRefreshWork, and answers
+// bibliographic queries for the Abbott et al. paper with a confident match.
+func referencesHandler(w http.ResponseWriter, r *http.Request) {
+	switch q := r.URL.Query().Get(crossref.QueryKeyBib); {
+	case strings.Contains(q, "Abbott"):
+		w.Write([]byte(`{"message": {"items": [{"DOI": "10.1103/physrevlett.116.061102",
+			"score": 95.2, "issued": {"date-parts": [[2016, 2, 11]]}}]}}`))
+	case len(q) != 0:
+		w.Write([]byte(`{"message": {"items": [{"DOI": "10.1000/unrelated",
+			"score": 12.5, "issued": {"date-parts": [[2019]]}}]}}`))
+	case strings.HasSuffix(r.URL.Path, "10.1000/citing"),
+		strings.HasSuffix(r.URL.Path, "10.1000%2Fciting"):
+		w.Write([]byte(testCitingWork))
+	case strings.HasSuffix(r.URL.Path, "/10.1103%2FPhysRevLett.116.061102"),
+		strings.HasSuffix(r.URL.Path, "/10.1103/PhysRevLett.116.061102"):
+		w.Write([]byte(testRefreshWork))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestResolveReferences(t *testing.T) {
+	assert := assert.New(t)
+	standInClient(t, referencesHandler)
+
+	refs := []crossref.Reference{
+		{Key: "a", DOI: "10.1000/seen"},
+		{Key: "b", DOI: "https://doi.org/10.1103/PhysRevLett.116.061102"},
+		{Key: "c", Unstructured: "B. P. Abbott et al., Phys. Rev. Lett. 116, 061102 (2016)"},
+		{Key: "d", Unstructured: "B. P. Abbott et al., Phys. Rev. Lett. (2010)", Year: "2010"},
+		{Key: "e", Unstructured: "J. Doe, personal communication (2019)"},
+		{Key: "f", ISBN: "978-0-306-40615-7"},
+		{Key: "g"},
+	}
+	seen := map[string]bool{"10.1000/seen": true}
+	handles, unresolved := resolveReferences(refs, seen)
+	var got []string
+	for _, h := range handles {
+		got = append(got, h.Value)
+	}
+	assert.Equal([]string{"10.1103/PhysRevLett.116.061102", "9780306406157"}, got)
+	var keys []string
+	for _, ref := range unresolved {
+		keys = append(keys, ref.Key)
+	}
+	assert.Equal([]string{"d", "e", "g"}, keys)
+	assert.True(seen["9780306406157"])
+}
+
+func TestMetaReferences(t *testing.T) {
+	assert := assert.New(t)
+	standInClient(t, referencesHandler)
+	defer func() { References = 0 }()
+
+	References = 2
+	var b bytes.Buffer
+	assert.Nil(Meta(&b, []string{"10.1000/citing"}))
+	var dois []string
+	dec := json.NewDecoder(&b)
+	for dec.More() {
+		var w crossref.Work
+		if !assert.Nil(dec.Decode(&w)) {
+			break
+		}
+		dois = append(dois, w.DOI)
+	}
+	assert.Equal([]string{"10.1000/citing", "10.1103/PhysRevLett.116.061102"}, dois)
+}
+
+func TestExpandMetaReferences(t *testing.T) {
+	standInClient(t, referencesHandler)
+	defer func() { References = 0 }()
+	References = 1
+
+	refs := []crossref.Reference{{Key: "ref1", DOI: "10.1103/PhysRevLett.116.061102"}}
+	var tests = []struct {
+		Name   string
+		Record interface{}
+	}{
+		{Name: "value", Record: crossref.Work{Reference: refs}},
+		{Name: "pointer", Record: &crossref.Work{Reference: refs}}, // landing page fallback
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			expanded := expandMetaReferences([]interface{}{tt.Record}, make(map[string]bool))
+			if assert.Len(t, expanded, 1) {
+				b, _ := json.Marshal(expanded[0])
+				assert.Contains(t, string(b), `"DOI":"10.1103/PhysRevLett.116.061102"`)
+			}
+		})
+	}
+}
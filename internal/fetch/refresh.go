@@ -0,0 +1,50 @@
+package fetch
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Milover/fetchref/internal/bib"
+	"github.com/Milover/fetchref/internal/diff"
+	"github.com/Milover/fetchref/internal/outfile"
+)
+
+var (
+	// RefreshPolicy is the policy by which metadata is merged into
+	// the entries of refreshed bibliographies.
+	RefreshPolicy = bib.Fill
+
+	// RefreshFields are the fields which are overwritten, if RefreshPolicy
+	// is bib.Overwrite, all fields if empty.
+	RefreshFields []string
+
+	// RefreshOutput is the name of the file to which a refreshed
+	// bibliography is written, instead of writing a diff.
+	RefreshOutput string
+)
+
+// Refresh refreshes the entries of the named BibTeX file, which have
+// a DOI, an ISBN or an arXiv identifier, with Crossref (or arXiv) metadata,
+// according to RefreshPolicy, and writes the patched file to RefreshOutput,
+// if set, or a unified diff of the changes to w. Citation keys, comments,
+// the order of entries and the formatting of unchanged fields are retained.
+func Refresh(w io.Writer, name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	f, err := bib.ParseBibTeX(data)
+	if err != nil {
+		return fmt.Errorf("%v: %w", name, err)
+	}
+	changes := enrichEntries(f.Entries(), RefreshPolicy, RefreshFields...)
+	logChanges(changes)
+
+	patched := f.Bytes()
+	if len(RefreshOutput) != 0 {
+		return outfile.WriteFile(RefreshOutput, patched, 0666)
+	}
+	_, err = io.WriteString(w, diff.Unified(name, name, data, patched))
+	return err
+}
@@ -0,0 +1,116 @@
+package fetch
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Milover/fetchref/internal/bib"
+	"github.com/stretchr/testify/assert"
+)
+
+const testRefreshBib = `% thesis references
+@article{ligo,
+  title   = {Observation of gravitational waves},
+  author  = {Abbott, B. P.},
+  journal = {Phys. Rev. Lett.},
+  doi     = {10.1103/PhysRevLett.116.061102},
+}
+
+@misc{notes, title = {Notes}}
+`
+
+const testRefreshWork = `{"status": "ok", "message": {
+	"DOI": "10.1103/PhysRevLett.116.061102",
+	"type": "journal-article",
+	"title": ["Observation of Gravitational Waves"],
+	"author": [{"given": "B. P.", "family": "Abbott"}],
+	"container-title": ["Physical Review Letters"],
+	"volume": "116",
+	"page": "061102",
+	"issued": {"date-parts": [[2016, 2]]}
+}}`
+
+// roundTripFunc is an http.RoundTripper function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// standInClient replaces the HTTP client, for the duration of the test,
+// with one which serves all requests with the handler.
+func standInClient(t *testing.T, h http.HandlerFunc) {
+	c := client
+	t.Cleanup(func() { client = c })
+	client = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		// as the default transport, which does not send cancelled requests
+		if err := r.Context().Err(); err != nil {
+			return nil, err
+		}
+		rec := httptest.NewRecorder()
+		h(rec, r)
+		res := rec.Result()
+		res.Request = r
+		return res, nil
+	})}
+}
+
+func TestRefresh(t *testing.T) {
+	assert := assert.New(t)
+	standInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/10.1103%2FPhysRevLett.116.061102") &&
+			!strings.HasSuffix(r.URL.Path, "/10.1103/PhysRevLett.116.061102") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testRefreshWork))
+	})
+	defer func() { RefreshPolicy, RefreshFields, RefreshOutput = bib.Fill, nil, "" }()
+
+	name := filepath.Join(t.TempDir(), "refs.bib")
+	assert.Nil(os.WriteFile(name, []byte(testRefreshBib), 0666))
+
+	var b bytes.Buffer
+	assert.Nil(Refresh(&b, name))
+	assert.Equal(`--- `+name+`
++++ `+name+`
+@@ -4,6 +4,10 @@
+   author  = {Abbott, B. P.},
+   journal = {Phys. Rev. Lett.},
+   doi     = {10.1103/PhysRevLett.116.061102},
++  year = {2016},
++  month = feb,
++  volume = {116},
++  pages = {061102},
+ }
+ 
+ @misc{notes, title = {Notes}}
+`, b.String())
+
+	RefreshPolicy, RefreshFields = bib.Overwrite, []string{"title"}
+	RefreshOutput = name + ".new"
+	b.Reset()
+	assert.Nil(Refresh(&b, name))
+	assert.Empty(b.String())
+	data, err := os.ReadFile(RefreshOutput)
+	assert.Nil(err)
+	assert.Equal(`% thesis references
+@article{ligo,
+  title   = {Observation of Gravitational Waves},
+  author  = {Abbott, B. P.},
+  journal = {Phys. Rev. Lett.},
+  doi     = {10.1103/PhysRevLett.116.061102},
+  year = {2016},
+  month = feb,
+  volume = {116},
+  pages = {061102},
+}
+
+@misc{notes, title = {Notes}}
+`, string(data))
+}
@@ -0,0 +1,93 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/Milover/fetchref/internal/issn"
+)
+
+var (
+	// SearchISSN restricts search results to works published in the
+	// journal with the ISSN.
+	SearchISSN string
+
+	// SearchRows is the maximum number of search results.
+	SearchRows = 20
+)
+
+// Search queries Crossref for works matching the query and writes a
+// table of the results (DOI, year and title) to w.
+// If SearchISSN is set, only works published in the journal are
+// listed, the most recent first, and the query may be empty.
+func Search(w io.Writer, query []string) error {
+	if len(query) == 0 && len(SearchISSN) == 0 {
+		return fmt.Errorf("nothing to search for, supply a query or an ISSN")
+	}
+	works, err := reqCrossrefSearch(strings.Join(query, " "))
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, work := range works {
+		var title string
+		if len(work.Title) != 0 {
+			title = work.Title[0]
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%v\n", work.DOI, work.Issued.Year(), title)
+	}
+	return tw.Flush()
+}
+
+// reqCrossrefSearch requests a list of works matching the query
+// from Crossref.
+func reqCrossrefSearch(q string) ([]crossref.Work, error) {
+	u := &url.URL{
+		Scheme: "https",
+		Host:   crossref.API,
+		Path:   crossref.APIWorks,
+	}
+	query := url.Values{}
+	if len(SearchISSN) != 0 {
+		if !issn.IsValid(SearchISSN) {
+			return nil, fmt.Errorf("invalid ISSN: %v", SearchISSN)
+		}
+		u.Path = crossref.APIJournals
+		u = u.JoinPath(url.PathEscape(issn.Clean(SearchISSN)), crossref.APIWorks)
+	}
+	if len(q) != 0 {
+		query.Add(crossref.QueryKeyBib, q)
+	} else {
+		query.Add(crossref.QueryKeySort, crossref.QueryValSortPublished)
+		query.Add(crossref.QueryKeyOrder, crossref.QueryValOrderDesc)
+	}
+	query.Add(crossref.QueryKeyRows, strconv.Itoa(SearchRows))
+	u.RawQuery = query.Encode()
+
+	ctx, cncl := context.WithTimeout(context.Background(), GlobalReqTimeout)
+	defer cncl()
+
+	res, err := sendGetRequest(ctx, u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	var msg crossref.WorksMessage
+	if err = json.Unmarshal(b, &msg); err != nil {
+		return nil, err
+	}
+	return msg.Message.Items, nil
+}
@@ -0,0 +1,323 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/arxiv"
+	"github.com/Milover/fetchref/internal/config"
+	"github.com/Milover/fetchref/internal/libgen"
+	"github.com/Milover/fetchref/internal/outfile"
+	"github.com/Milover/fetchref/internal/pubmed"
+)
+
+// Source is a download source, which resolves articles to candidate
+// download locations.
+type Source interface {
+	// Name returns the (unique) name of the source.
+	Name() string
+	// Supports reports whether the source can resolve articles
+	// with the handle.
+	Supports(h article.Handle) bool
+	// Resolve returns the candidate download locations of the article,
+	// in order of preference.
+	Resolve(ctx context.Context, a *article.Article) ([]Candidate, error)
+	// Priority returns the priority of the source, sources with a lower
+	// priority are tried first.
+	Priority() int
+}
+
+// Candidate is a candidate download location of an article.
+type Candidate struct {
+	URL     *url.URL
+	Header  http.Header // additional download request headers
+	License string      // license of the article (URL)
+}
+
+// sourceConstructor constructs a source from its configuration.
+type sourceConstructor func(config.Source) (Source, error)
+
+var (
+	// doiHandles are the handle types which are (usually) resolved to DOIs
+	// during metadata retrieval, and can therefore be downloaded from
+	// DOI-based sources.
+	doiHandles = []article.HandleType{article.DOI, article.PMID, article.PMCID}
+
+	// builtinSources are the built-in download sources.
+	builtinSources = []funcSource{
+		{
+			name:     "crossref",
+			priority: 10,
+			handles:  doiHandles,
+			resolve:  withoutMirror(crossrefLinkCandidates),
+		},
+		{
+			name:     "unpaywall",
+			priority: 20,
+			handles:  doiHandles,
+			resolve:  withoutMirror(unpaywallCandidates),
+		},
+		{
+			name:     "arxiv",
+			priority: 30,
+			handles:  []article.HandleType{article.ArXiv},
+			mirrors:  arxiv.Mirrors,
+			resolve:  single(reqArXivMirrorInfo),
+		},
+		{
+			name:     "pmc",
+			priority: 30,
+			handles:  []article.HandleType{article.PMID, article.PMCID},
+			resolve: single(func(ctx context.Context, a *article.Article, _ string) error {
+				return reqPMCOAInfo(ctx, a, pubmed.OAURL)
+			}),
+		},
+		{
+			name:     "landing",
+			priority: 40,
+			handles:  doiHandles,
+			resolve: single(func(ctx context.Context, a *article.Article, _ string) error {
+				return reqLandingInfo(ctx, a)
+			}),
+		},
+		{
+			name:     "scihub",
+			priority: 50,
+			handles:  []article.HandleType{article.DOI},
+			mirrors:  mirrors,
+			resolve:  single(reqSciHubMirrorInfo),
+		},
+		{
+			name:     "libgen",
+			priority: 50,
+			handles:  []article.HandleType{article.ISBN},
+			mirrors:  libgen.Mirrors,
+			resolve:  single(reqLibgenMirrorInfo),
+		},
+	}
+
+	// sourceTypes is the registry of source types, which can be enabled
+	// and configured through the configuration file.
+	sourceTypes = make(map[string]sourceConstructor)
+
+	// sources are the enabled download sources, ordered by priority.
+	sources []Source
+
+	// sourcesSetUp reports whether the download sources have been set up,
+	// in which case sources holds the enabled sources, if any.
+	sourcesSetUp = false
+)
+
+func init() {
+	for i := range builtinSources {
+		s := builtinSources[i]
+		sourceTypes[s.name] = s.configure
+	}
+}
+
+// resolveFunc returns the candidate download locations of an article,
+// in order of preference, using the mirror, if the source has mirrors.
+type resolveFunc func(ctx context.Context, a *article.Article, mirror string) ([]Candidate, error)
+
+// funcSource is a download source which resolves articles using a resolve
+// function, e.g., unpaywallCandidates.
+// Mirror-based sources call the resolve function for each mirror until
+// one succeeds, other sources call it once, with an empty mirror.
+type funcSource struct {
+	name     string
+	priority int
+	handles  []article.HandleType
+	mirrors  []string
+	resolve  resolveFunc
+}
+
+// withoutMirror adapts a resolve function which does not use mirrors.
+func withoutMirror(fn func(context.Context, *article.Article) ([]Candidate, error)) resolveFunc {
+	return func(ctx context.Context, a *article.Article, _ string) ([]Candidate, error) {
+		return fn(ctx, a)
+	}
+}
+
+// single adapts a request function which sets the article download
+// location, e.g., reqSciHubMirrorInfo, to a resolve function returning
+// a single candidate. The request function is called on a copy of the
+// article, so the article is not modified.
+func single(fn func(context.Context, *article.Article, string) error) resolveFunc {
+	return func(ctx context.Context, a *article.Article, mirror string) ([]Candidate, error) {
+		b := *a
+		b.Url, b.Header, b.License = nil, nil, ""
+		if err := fn(ctx, &b, mirror); err != nil {
+			return nil, err
+		}
+		return []Candidate{{URL: b.Url, Header: b.Header, License: b.License}}, nil
+	}
+}
+
+// configure returns a copy of the source, configured by c.
+func (s funcSource) configure(c config.Source) (Source, error) {
+	s.name = c.Name
+	if c.Priority != nil {
+		s.priority = *c.Priority
+	}
+	if len(c.Mirrors) != 0 {
+		if len(s.mirrors) == 0 {
+			return nil, fmt.Errorf("source %v does not use mirrors", c.Name)
+		}
+		s.mirrors = c.Mirrors
+	}
+	return s, nil
+}
+
+// Name returns the name of the source.
+func (s funcSource) Name() string {
+	return s.name
+}
+
+// Priority returns the priority of the source.
+func (s funcSource) Priority() int {
+	return s.priority
+}
+
+// Supports reports whether the source can resolve articles
+// with the handle.
+func (s funcSource) Supports(h article.Handle) bool {
+	for _, t := range s.handles {
+		if h.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the candidate download locations of the article, from
+// the first mirror which resolves it.
+func (s funcSource) Resolve(ctx context.Context, a *article.Article) ([]Candidate, error) {
+	mrs := s.mirrors
+	if len(mrs) == 0 {
+		mrs = []string{""}
+	}
+	for _, m := range mrs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		cs, err := s.resolve(ctx, a, m)
+		if err != nil {
+			log.Printf("%v: %v: %v", a.Handle.Value, s.name, err)
+			continue
+		}
+		return cs, nil
+	}
+	return nil, fmt.Errorf("could not get article info")
+}
+
+// defaultSources returns the download sources enabled when none are
+// configured. Sources which require additional configuration are enabled
+// only if configured.
+func defaultSources() []config.Source {
+	var cs []config.Source
+	for _, s := range builtinSources {
+		switch {
+		case s.name == "unpaywall" && len(Mailto) == 0:
+			continue
+		case s.name == "landing" && !usesInstitutionalAccess():
+			continue
+		}
+		cs = append(cs, config.Source{Name: s.name})
+	}
+	return cs
+}
+
+// setupSources constructs the enabled download sources from their
+// configuration, and orders them by priority. Configured sources without
+// a priority are assigned one based on their position in the list,
+// i.e., 10, 20, 30..., while the default sources use their default
+// priorities.
+func setupSources(cs []config.Source) error {
+	configured := len(cs) != 0
+	if !configured {
+		cs = defaultSources()
+	}
+	sourcesSetUp = false
+	sources = sources[:0]
+	for i, c := range cs {
+		if c.Disabled {
+			continue
+		}
+		// configured sources are tried in the order in which they are listed
+		if configured && c.Priority == nil {
+			p := (i + 1) * 10
+			c.Priority = &p
+		}
+		construct, found := sourceTypes[c.SourceType()]
+		if !found {
+			return fmt.Errorf("unknown source type: %v", c.SourceType())
+		}
+		s, err := construct(c)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, s)
+	}
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].Priority() < sources[j].Priority()
+	})
+	sourcesSetUp = true
+	return nil
+}
+
+// enabledSources returns the enabled download sources, ordered by priority.
+// The default sources are set up if the sources have not been set up yet,
+// while configured sources which are all disabled enable none, in which
+// case an error is returned.
+func enabledSources() ([]Source, error) {
+	if !sourcesSetUp {
+		if err := setupSources(nil); err != nil {
+			return nil, err
+		}
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no download sources enabled")
+	}
+	return sources, nil
+}
+
+// downloadFromSources tries to download the article from each enabled
+// source which supports it, in turn, until one succeeds.
+func downloadFromSources(ctx context.Context, a *article.Article) error {
+	ss, err := enabledSources()
+	if err != nil {
+		return err
+	}
+	for _, s := range ss {
+		if !s.Supports(a.Handle) {
+			continue
+		}
+		cs, err := s.Resolve(ctx, a)
+		if err != nil {
+			log.Printf("%v: %v: %v", a.Handle.Value, s.Name(), err)
+			continue
+		}
+		for _, c := range cs {
+			a.Url, a.Header, a.License = c.URL, c.Header, c.License
+			a.Source = s.Name()
+			err := reqDownload(a)
+			if errors.Is(err, outfile.ErrExists) {
+				log.Printf("%v: %v, skipped", a.Handle.Value, err)
+				return nil
+			}
+			if err != nil {
+				log.Printf("%v: %v: %v", a.Handle.Value, s.Name(), err)
+				continue
+			}
+			log.Printf("%v: downloaded from %v: %v", a.Handle.Value, s.Name(), a.Url)
+			return nil
+		}
+	}
+	return fmt.Errorf("could not download article from any source")
+}
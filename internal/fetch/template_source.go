@@ -0,0 +1,204 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/config"
+	"github.com/Milover/fetchref/internal/jsonpath"
+)
+
+var (
+	// placeholderPattern matches URL template placeholders, e.g., '{doi}'.
+	placeholderPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+	// placeholderHandles are the handle types supported by templates
+	// using a placeholder.
+	placeholderHandles = map[string][]article.HandleType{
+		"doi":               doiHandles,
+		"doi_urlencoded":    doiHandles,
+		"isbn":              {article.ISBN},
+		"handle":            nil, // all handle types
+		"handle_urlencoded": nil,
+	}
+)
+
+func init() {
+	sourceTypes["template"] = newTemplateSource
+}
+
+// templateSource is a download source which resolves articles by filling
+// in a URL template, e.g., 'https://docs.internal/{doi_urlencoded}.pdf',
+// pointing either to the file itself or to a JSON lookup endpoint.
+type templateSource struct {
+	name     string
+	priority int
+	url      string
+	jsonPath string
+	tokenEnv string
+	handles  []article.HandleType // nil if all are supported
+}
+
+// newTemplateSource constructs a template source from its configuration.
+func newTemplateSource(c config.Source) (Source, error) {
+	s := &templateSource{
+		name:     c.Name,
+		priority: 60,
+		url:      c.URL,
+		jsonPath: c.JSONPath,
+		tokenEnv: c.TokenEnv,
+	}
+	if c.Priority != nil {
+		s.priority = *c.Priority
+	}
+	if len(s.url) == 0 {
+		return nil, fmt.Errorf("source %v: url not set", c.Name)
+	}
+	if len(s.jsonPath) != 0 {
+		if err := jsonpath.Validate(s.jsonPath); err != nil {
+			return nil, fmt.Errorf("source %v: %w", c.Name, err)
+		}
+	}
+	matches := placeholderPattern.FindAllStringSubmatch(s.url, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("source %v: url has no placeholders", c.Name)
+	}
+	for _, m := range matches {
+		hs, found := placeholderHandles[m[1]]
+		if !found {
+			return nil, fmt.Errorf("source %v: unknown placeholder: %v", c.Name, m[0])
+		}
+		if hs != nil {
+			s.handles = hs
+		}
+	}
+	return s, nil
+}
+
+// Name returns the name of the source.
+func (s *templateSource) Name() string {
+	return s.name
+}
+
+// Priority returns the priority of the source.
+func (s *templateSource) Priority() int {
+	return s.priority
+}
+
+// Supports reports whether the source can resolve articles
+// with the handle.
+func (s *templateSource) Supports(h article.Handle) bool {
+	if s.handles == nil {
+		return true
+	}
+	for _, t := range s.handles {
+		if h.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// expand fills in the URL template with the article's identifiers.
+func (s *templateSource) expand(a *article.Article) (string, error) {
+	var err error
+	u := placeholderPattern.ReplaceAllStringFunc(s.url, func(p string) string {
+		var v string
+		switch p[1 : len(p)-1] {
+		case "doi", "doi_urlencoded":
+			v = a.DOI
+		case "isbn", "handle", "handle_urlencoded":
+			v = a.Handle.Value
+		}
+		if len(v) == 0 && err == nil {
+			err = fmt.Errorf("cannot fill in %v, value not set", p)
+		}
+		if p == "{doi_urlencoded}" || p == "{handle_urlencoded}" {
+			return url.QueryEscape(v)
+		}
+		return v
+	})
+	return u, err
+}
+
+// header returns the request headers, i.e., the bearer token
+// authorization header, if a token is configured.
+func (s *templateSource) header() (http.Header, error) {
+	if len(s.tokenEnv) == 0 {
+		return nil, nil
+	}
+	token := os.Getenv(s.tokenEnv)
+	if len(token) == 0 {
+		return nil, fmt.Errorf("environment variable not set: %v", s.tokenEnv)
+	}
+	h := http.Header{}
+	h.Set("Authorization", "Bearer "+token)
+	return h, nil
+}
+
+// Resolve returns the download location of the article, i.e., the filled
+// in URL template, or the file URL returned by the JSON lookup endpoint.
+func (s *templateSource) Resolve(ctx context.Context, a *article.Article) ([]Candidate, error) {
+	raw, err := s.expand(a)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	header, err := s.header()
+	if err != nil {
+		return nil, err
+	}
+	if len(s.jsonPath) != 0 {
+		lu := u
+		if u, err = s.lookup(ctx, lu, header); err != nil {
+			return nil, err
+		}
+		log.Printf("%v: %v: file URL: %v", a.Handle.Value, s.name, u)
+		// do not leak the token to other hosts, e.g., file storage
+		if u.Host != lu.Host {
+			header = nil
+		}
+	}
+	return []Candidate{{URL: u, Header: header}}, nil
+}
+
+// lookup requests the file URL from the JSON lookup endpoint.
+// Relative file URLs are resolved against the lookup URL.
+func (s *templateSource) lookup(
+	ctx context.Context,
+	u *url.URL,
+	header http.Header,
+) (*url.URL, error) {
+	ctx, cncl := context.WithTimeout(ctx, GlobalReqTimeout)
+	defer cncl()
+
+	res, err := sendGetRequestHeader(ctx, u.String(), header)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	f, err := jsonpath.LookupString(b, s.jsonPath)
+	if err != nil {
+		return nil, err
+	}
+	fu, err := url.Parse(f)
+	if err != nil {
+		return nil, err
+	}
+	return res.Request.URL.ResolveReference(fu), nil
+}
@@ -0,0 +1,90 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateSource(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lookup", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"url":"/files/%v.pdf"}}`, r.URL.Query().Get("doi"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	t.Setenv("TEMPLATE_TOKEN", "secret")
+
+	a := &article.Article{
+		Handle: article.Handle{Value: "10.1000/1", Type: article.DOI},
+		DOI:    "10.1000/1",
+	}
+
+	s, err := newTemplateSource(config.Source{
+		Name: "archive",
+		URL:  "https://docs.internal/{doi_urlencoded}.pdf",
+	})
+	assert.Nil(t, err)
+	assert.True(t, s.Supports(a.Handle))
+	assert.False(t, s.Supports(article.Handle{Type: article.ISBN}))
+	cs, err := s.Resolve(context.Background(), a)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://docs.internal/10.1000%2F1.pdf", cs[0].URL.String())
+
+	s, err = newTemplateSource(config.Source{
+		Name:     "archive-api",
+		URL:      srv.URL + "/lookup?doi={doi}",
+		JSONPath: "$.data.url",
+		TokenEnv: "TEMPLATE_TOKEN",
+	})
+	assert.Nil(t, err)
+	cs, err = s.Resolve(context.Background(), a)
+	assert.Nil(t, err)
+	assert.Equal(t, srv.URL+"/files/10.1000/1.pdf", cs[0].URL.String())
+	assert.Equal(t, "Bearer secret", cs[0].Header.Get("Authorization"))
+
+	_, err = newTemplateSource(config.Source{Name: "bad", URL: "https://x/{cake}"})
+	assert.NotNil(t, err)
+}
+
+func TestSetupSources(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { sources, sourcesSetUp = nil, false }()
+
+	// configured sources which are all disabled enable none
+	assert.Nil(setupSources([]config.Source{
+		{Name: "scihub", Disabled: true},
+		{Name: "libgen", Disabled: true},
+	}))
+	ss, err := enabledSources()
+	assert.NotNil(err)
+	assert.Empty(ss)
+
+	assert.Nil(setupSources([]config.Source{
+		{Name: "libgen"},
+		{Name: "arxiv", Priority: new(int)},
+	}))
+	ss, err = enabledSources()
+	if assert.Nil(err) && assert.Len(ss, 2) {
+		assert.Equal("arxiv", ss[0].Name())
+		assert.Equal("libgen", ss[1].Name())
+	}
+
+	assert.NotNil(setupSources([]config.Source{{Name: "cake"}}))
+
+	// the default sources are set up if none have been
+	sources, sourcesSetUp = nil, false
+	ss, err = enabledSources()
+	assert.Nil(err)
+	assert.NotEmpty(ss)
+}
@@ -0,0 +1,142 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"sort"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/unpaywall"
+)
+
+var (
+	// Mailto is the e-mail address supplied to APIs which require one,
+	// e.g., Unpaywall. Unpaywall is not queried if it is not set.
+	Mailto string
+
+	// OAVersion is the preferred version of open access copies.
+	OAVersion = unpaywall.Published
+)
+
+// reqUnpaywallWork requests the open access status and locations of
+// a work from Unpaywall.
+func reqUnpaywallWork(ctx context.Context, doi string) (unpaywall.Work, error) {
+	u := &url.URL{
+		Scheme: "https",
+		Host:   unpaywall.URL,
+		Path:   unpaywall.API,
+	}
+	u = u.JoinPath(url.PathEscape(doi))
+	query := url.Values{}
+	query.Add(unpaywall.QueryKeyEmail, Mailto)
+	u.RawQuery = query.Encode()
+
+	ctx, cncl := context.WithTimeout(ctx, GlobalReqTimeout)
+	defer cncl()
+
+	res, err := sendGetRequest(ctx, u.String())
+	if err != nil {
+		return unpaywall.Work{}, err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return unpaywall.Work{}, err
+	}
+	var w unpaywall.Work
+	if err = json.Unmarshal(b, &w); err != nil {
+		return unpaywall.Work{}, err
+	}
+	return w, nil
+}
+
+// oaLocations returns the open access locations of a work which link to
+// a PDF. Locations of the preferred version come first, followed by the
+// rest, from the most to the least final version. Unpaywall's best
+// location comes first among locations of the same version.
+func oaLocations(w unpaywall.Work) []unpaywall.Location {
+	locs := make([]unpaywall.Location, 0, len(w.OALocations))
+	for _, l := range w.OALocations {
+		if len(l.URLForPDF) != 0 {
+			locs = append(locs, l)
+		}
+	}
+	rank := func(l unpaywall.Location) int {
+		v, _ := unpaywall.ParseVersion(l.Version)
+		if v == OAVersion {
+			return -1
+		}
+		return int(v)
+	}
+	sort.SliceStable(locs, func(i, j int) bool {
+		ri, rj := rank(locs[i]), rank(locs[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return locs[i].IsBest && !locs[j].IsBest
+	})
+	return locs
+}
+
+// unpaywallCandidates requests the open access locations of the article
+// from Unpaywall, and returns their PDFs, ordered by suitability (see
+// oaLocations). If there are none, the PDF linked from the landing page
+// of an open access location is returned.
+func unpaywallCandidates(ctx context.Context, a *article.Article) ([]Candidate, error) {
+	if len(a.DOI) == 0 {
+		return nil, fmt.Errorf("cannot retrieve open access info, DOI not set")
+	}
+	w, err := reqUnpaywallWork(ctx, a.DOI)
+	if err != nil {
+		return nil, err
+	}
+	if !w.IsOA {
+		return nil, fmt.Errorf("unpaywall: no open access copy available")
+	}
+	locs := oaLocations(w)
+	if len(locs) == 0 {
+		return unpaywallLandingCandidates(ctx, a, w)
+	}
+	var cands []Candidate
+	for _, l := range locs {
+		u, err := url.Parse(l.URLForPDF)
+		if err != nil {
+			logErr(a.Handle.Value, err)
+			continue
+		}
+		cands = append(cands, Candidate{URL: u, License: l.License})
+	}
+	if len(cands) == 0 {
+		return nil, fmt.Errorf("unpaywall: no open access PDF available")
+	}
+	return cands, nil
+}
+
+// unpaywallLandingCandidates returns the PDF link candidate from the landing
+// page of the first open access location which has one. It is used when
+// Unpaywall knows of no direct PDF links.
+func unpaywallLandingCandidates(ctx context.Context, a *article.Article, w unpaywall.Work) ([]Candidate, error) {
+	for _, l := range w.OALocations {
+		if len(l.URLForLandingPage) == 0 {
+			continue
+		}
+		landing, err := url.Parse(l.URLForLandingPage)
+		if err != nil {
+			continue
+		}
+		// only publishers' landing pages require institutional access
+		m, err := reqLandingPage(ctx, landing, l.HostType == unpaywall.HostTypePublisher)
+		if logErr(a.Handle.Value, err) != nil || m.PDF == nil {
+			continue
+		}
+		log.Printf("%v: open access %v from %v landing page: %v",
+			a.Handle.Value, l.Version, l.HostType, m.PDF)
+		return []Candidate{{URL: m.PDF, License: l.License}}, nil
+	}
+	return nil, fmt.Errorf("unpaywall: no open access PDF available")
+}
@@ -0,0 +1,148 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/config"
+	"github.com/Milover/fetchref/internal/proxy"
+	"github.com/Milover/fetchref/internal/unpaywall"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOALocations(t *testing.T) {
+	locs := []unpaywall.Location{
+		{URLForPDF: "submitted.pdf", Version: "submittedVersion"},
+		{URLForPDF: "accepted.pdf", Version: "acceptedVersion"},
+		{URLForLandingPage: "landing", Version: "publishedVersion"},
+		{URLForPDF: "published.pdf", Version: "publishedVersion"},
+		{URLForPDF: "best-published.pdf", Version: "publishedVersion", IsBest: true},
+		{URLForPDF: "unknown.pdf", Version: "cake"},
+	}
+	var tests = []struct {
+		Name    string
+		Version unpaywall.Version
+		URLs    []string
+	}{
+		{
+			Name:    "published",
+			Version: unpaywall.Published,
+			URLs: []string{"best-published.pdf", "published.pdf",
+				"accepted.pdf", "submitted.pdf", "unknown.pdf"},
+		},
+		{
+			Name:    "accepted",
+			Version: unpaywall.Accepted,
+			URLs: []string{"accepted.pdf", "best-published.pdf",
+				"published.pdf", "submitted.pdf", "unknown.pdf"},
+		},
+		{
+			Name:    "submitted",
+			Version: unpaywall.Submitted,
+			URLs: []string{"submitted.pdf", "unknown.pdf",
+				"best-published.pdf", "published.pdf", "accepted.pdf"},
+		},
+	}
+	defer func() { OAVersion = unpaywall.Published }()
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			OAVersion = tt.Version
+			var urls []string
+			for _, l := range oaLocations(unpaywall.Work{OALocations: locs}) {
+				urls = append(urls, l.URLForPDF)
+			}
+			assert.Equal(t, tt.URLs, urls)
+		})
+	}
+}
+
+func TestUnpaywallLandingProxy(t *testing.T) {
+	var tests = []struct {
+		Name     string
+		HostType string
+		Host     string
+		PDF      string
+	}{
+		{
+			Name:     "repository",
+			HostType: unpaywall.HostTypeRepository,
+			Host:     "www.publisher.com",
+			PDF:      "https://www.publisher.com/content/pdf/jfm.2013.1.pdf",
+		},
+		{
+			Name:     "publisher",
+			HostType: unpaywall.HostTypePublisher,
+			Host:     "www-publisher-com.ezproxy.example.edu",
+			PDF:      "https://www-publisher-com.ezproxy.example.edu/content/pdf/jfm.2013.1.pdf",
+		},
+	}
+	p := Proxy
+	defer func() { Proxy = p }()
+	Proxy = proxy.Proxy{Host: "ezproxy.example.edu"}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			var hosts []string
+			standInClient(t, func(w http.ResponseWriter, r *http.Request) {
+				hosts = append(hosts, r.URL.Host)
+				w.Write([]byte(testLandingPage))
+			})
+			a := &article.Article{}
+			w := unpaywall.Work{OALocations: []unpaywall.Location{{
+				URLForLandingPage: "https://www.publisher.com/article/jfm.2013.1",
+				HostType:          tt.HostType,
+			}}}
+			cs, err := unpaywallLandingCandidates(context.Background(), a, w)
+			if assert.Nil(t, err) && assert.Len(t, cs, 1) {
+				assert.Equal(t, []string{tt.Host}, hosts)
+				assert.Equal(t, tt.PDF, cs[0].URL.String())
+			}
+		})
+	}
+}
+
+func TestUnpaywallCandidates(t *testing.T) {
+	assert := assert.New(t)
+	var paths []string
+	standInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Host+r.URL.Path)
+		switch r.URL.Host {
+		case unpaywall.URL:
+			w.Write([]byte(`{"doi": "10.1000/182", "is_oa": true, "oa_locations": [
+				{"url_for_pdf": "https://repository.org/captcha.pdf", "version": "publishedVersion", "is_best": true},
+				{"url_for_pdf": "https://publisher.com/article.pdf", "version": "publishedVersion"}
+			]}`))
+		case "repository.org":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("<!DOCTYPE html><html>" + strings.Repeat(" ", 8192) + "</html>"))
+		default:
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write(testPDF)
+		}
+	})
+	defer func() { sources, sourcesSetUp = nil, false }()
+	assert.Nil(setupSources([]config.Source{{Name: "unpaywall"}}))
+
+	// the next location is tried, if the first one fails
+	a := &article.Article{
+		Handle: article.Handle{Type: article.DOI, Value: "10.1000/182"},
+		DOI:    "10.1000/182",
+	}
+	name := filepath.Join(t.TempDir(), "article")
+	a.GeneratorFunc(func(*article.Article) string { return name })
+	assert.Nil(downloadFromSources(context.Background(), a))
+	assert.Equal(name+".pdf", a.File)
+	assert.Equal("https://publisher.com/article.pdf", a.Url.String())
+
+	// cancelled contexts are respected by the requests
+	paths = nil
+	ctx, cncl := context.WithCancel(context.Background())
+	cncl()
+	_, err := unpaywallCandidates(ctx, a)
+	assert.True(errors.Is(err, context.Canceled), err)
+	assert.Empty(paths)
+}
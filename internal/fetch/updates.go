@@ -0,0 +1,304 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/bib"
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/Milover/fetchref/internal/doi"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	// FailOnRetraction controls whether Fetch fails with ErrRetracted
+	// if any of the fetched works has been retracted.
+	FailOnRetraction = false
+
+	// ErrRetracted is returned by Fetch if FailOnRetraction is set and
+	// a fetched work has been retracted.
+	ErrRetracted = errors.New("retracted works fetched")
+)
+
+// updateRows is the maximum number of update notices requested per work.
+const updateRows = "20"
+
+// updateKind is the kind of an update, ordered by severity.
+type updateKind int
+
+const (
+	updateOther updateKind = iota // e.g., new versions or editions
+	updateCorrection
+	updateConcern
+	updateRetraction
+)
+
+// kindOf returns the kind of the update type, as used by Crossref and
+// Retraction Watch.
+func kindOf(typ string) updateKind {
+	switch strings.ToLower(strings.NewReplacer(" ", "_", "-", "_").Replace(typ)) {
+	case "retraction", "partial_retraction", "withdrawal", "removal":
+		return updateRetraction
+	case "expression_of_concern":
+		return updateConcern
+	case "correction", "corrigendum", "erratum", "addendum", "clarification":
+		return updateCorrection
+	}
+	return updateOther
+}
+
+// workUpdate is an update of a work by a notice, e.g., a retraction or
+// a correction notice.
+type workUpdate struct {
+	crossref.WorkUpdate
+	// Notice is the DOI of the notice.
+	Notice string
+}
+
+// retraction reports whether the update retracts the work.
+func (u workUpdate) retraction() bool {
+	return kindOf(u.Type) == updateRetraction
+}
+
+// reqWorkUpdates requests the updates of the work with the DOI from
+// Crossref, i.e., the notices which update it.
+func reqWorkUpdates(d string) ([]workUpdate, error) {
+	u := &url.URL{
+		Scheme: "https",
+		Host:   crossref.API,
+		Path:   crossref.APIWorks,
+	}
+	query := url.Values{}
+	query.Add(crossref.QueryKeyFilter, crossref.QueryValFilterUpdates+d)
+	query.Add(crossref.QueryKeyRows, updateRows)
+	u.RawQuery = query.Encode()
+
+	ctx, cncl := context.WithTimeout(context.Background(), GlobalReqTimeout)
+	defer cncl()
+
+	res, err := sendGetRequest(ctx, u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	var msg crossref.WorksMessage
+	if err = json.Unmarshal(b, &msg); err != nil {
+		return nil, err
+	}
+	var updates []workUpdate
+	for _, n := range msg.Message.Items {
+		for _, upd := range n.UpdateTo {
+			if doi.Equal(upd.DOI, d) && !doi.Equal(n.DOI, d) {
+				updates = append(updates, workUpdate{WorkUpdate: upd, Notice: n.DOI})
+			}
+		}
+	}
+	return updates, nil
+}
+
+// fetchUpdates sets the updates of the articles, i.e., the notices
+// recorded in their metadata ('updated-by', which includes Retraction
+// Watch data), and the notices which update them, as requested from
+// Crossref by their DOIs.
+func fetchUpdates(articles []article.Article) {
+	g := new(errgroup.Group)
+	for i := range articles {
+		a := &articles[i]
+		if len(a.DOI) == 0 {
+			continue
+		}
+		g.Go(func() error {
+			var us []crossref.WorkUpdate
+			if a.Meta != nil {
+				us = append(us, a.Meta.UpdatedBy...)
+			}
+			ws, err := reqWorkUpdates(a.DOI)
+			for _, w := range ws {
+				w.DOI = w.Notice
+				if !hasUpdate(us, w.WorkUpdate) {
+					us = append(us, w.WorkUpdate)
+				}
+			}
+			a.Updates = us
+			return logErr(a.Handle.Value, err)
+		})
+	}
+	_ = g.Wait() // errors are logged
+}
+
+// hasUpdate reports whether the updates contain an update of the same kind
+// by the same notice.
+func hasUpdate(us []crossref.WorkUpdate, u crossref.WorkUpdate) bool {
+	for _, v := range us {
+		if doi.Equal(v.DOI, u.DOI) && kindOf(v.Type) == kindOf(u.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// retracted reports whether any of the updates retracts the work.
+func retracted(us []crossref.WorkUpdate) bool {
+	for _, u := range us {
+		if kindOf(u.Type) == updateRetraction {
+			return true
+		}
+	}
+	return false
+}
+
+// updateLabel returns the display-friendly label of the update.
+func updateLabel(u crossref.WorkUpdate) string {
+	if len(u.Label) != 0 {
+		return u.Label
+	}
+	l := strings.ReplaceAll(u.Type, "_", " ")
+	if len(l) == 0 {
+		return "Update"
+	}
+	return strings.ToUpper(l[:1]) + l[1:]
+}
+
+// formatUpdate formats the update for the run summary, e.g.,
+// 'Retraction 10.1000/xyz, 2020-01-02, retraction-watch'.
+func formatUpdate(u crossref.WorkUpdate) string {
+	ss := []string{updateLabel(u) + " " + u.DOI}
+	if len(u.Updated.DateParts) != 0 && len(u.Updated.DateParts[0]) != 0 {
+		var ds []string
+		for i, p := range u.Updated.DateParts[0] {
+			if i == 0 {
+				ds = append(ds, fmt.Sprintf("%04d", p))
+			} else {
+				ds = append(ds, fmt.Sprintf("%02d", p))
+			}
+		}
+		ss = append(ss, strings.Join(ds, "-"))
+	}
+	if len(u.Source) != 0 {
+		ss = append(ss, u.Source)
+	}
+	return strings.Join(ss, ", ")
+}
+
+// logUpdates logs warnings about fetched works which have been retracted,
+// corrected or are subject to an expression of concern, and about works
+// which are themselves notices, and reports whether any work has been
+// retracted.
+func logUpdates(articles []article.Article) bool {
+	var found bool
+	for i := range articles {
+		a := &articles[i]
+		for _, u := range a.Updates {
+			switch kindOf(u.Type) {
+			case updateRetraction:
+				log.Printf("WARNING: %v: has been retracted (%v)", a.DOI, formatUpdate(u))
+				found = true
+			case updateConcern:
+				log.Printf("WARNING: %v: is subject to an expression of concern (%v)", a.DOI, formatUpdate(u))
+			case updateCorrection:
+				log.Printf("WARNING: %v: has been corrected (%v)", a.DOI, formatUpdate(u))
+			default:
+				log.Printf("%v: has been updated (%v)", a.DOI, formatUpdate(u))
+			}
+		}
+		if a.Meta == nil {
+			continue
+		}
+		for _, u := range a.Meta.UpdateTo {
+			log.Printf("WARNING: %v: is a notice (%v) of %v, not the work itself",
+				a.DOI, strings.ToLower(updateLabel(u)), u.DOI)
+		}
+	}
+	return found
+}
+
+// updateNote returns the note with which the citation of a work with the
+// updates is annotated, e.g., 'Retracted. Retraction: doi:10.1000/xyz',
+// or an empty string if there are no corrections, expressions of concern
+// or retractions.
+func updateNote(us []crossref.WorkUpdate) string {
+	var ss []string
+	for _, u := range us {
+		if kindOf(u.Type) == updateOther {
+			continue
+		}
+		ss = append(ss, updateLabel(u)+": doi:"+u.DOI)
+	}
+	if len(ss) == 0 {
+		return ""
+	}
+	note := strings.Join(ss, "; ")
+	if retracted(us) {
+		note = "Retracted. " + note
+	}
+	return note
+}
+
+// annotateCitation annotates the citation of the article with a note
+// about its updates (see updateNote), if the citation format is BibTeX,
+// RIS or CSL-JSON.
+func annotateCitation(a *article.Article) error {
+	note := updateNote(a.Updates)
+	if len(note) == 0 || len(a.Citation) == 0 {
+		return nil
+	}
+	switch CiteFormat {
+	case crossref.BibTeX:
+		f, err := bib.ParseBibTeX(a.Citation)
+		if err != nil {
+			return err
+		}
+		for _, e := range f.Entries() {
+			n := bib.Escape(note)
+			if old := strings.TrimSpace(e.Get("note")); len(old) != 0 {
+				n = old + ". " + n
+			}
+			e.Set("note", n)
+		}
+		a.Citation = f.Bytes()
+	case crossref.RIS:
+		i := bytes.Index(a.Citation, []byte("ER  -"))
+		if i < 0 {
+			return fmt.Errorf("malformed RIS citation")
+		}
+		eol := "\n"
+		if bytes.Contains(a.Citation, []byte("\r\n")) {
+			eol = "\r\n"
+		}
+		c := append([]byte(nil), a.Citation[:i]...)
+		c = append(c, "N1  - "+note+eol...)
+		a.Citation = append(c, a.Citation[i:]...)
+	case crossref.CiteprocJSON:
+		var item map[string]json.RawMessage
+		if err := json.Unmarshal(a.Citation, &item); err != nil {
+			return err
+		}
+		var old string
+		_ = json.Unmarshal(item["note"], &old)
+		if old = strings.TrimSpace(old); len(old) != 0 {
+			note = old + ". " + note
+		}
+		n, err := json.Marshal(note)
+		if err != nil {
+			return err
+		}
+		item["note"] = n
+		if a.Citation, err = json.Marshal(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
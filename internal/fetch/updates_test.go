@@ -0,0 +1,116 @@
+package fetch
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/stretchr/testify/assert"
+)
+
+const testUpdateNotices = `{"status": "ok", "message": {"items": [
+	{"DOI": "10.1000/retraction", "update-to": [
+		{"DOI": "10.1000/work", "type": "retraction", "label": "Retraction",
+		 "updated": {"date-parts": [[2021, 3, 4]]}}
+	]},
+	{"DOI": "10.1000/erratum", "update-to": [
+		{"DOI": "10.1000/work", "type": "erratum", "label": "Erratum"},
+		{"DOI": "10.1000/other", "type": "erratum", "label": "Erratum"}
+	]}
+]}}`
+
+func TestFetchUpdates(t *testing.T) {
+	assert := assert.New(t)
+	standInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get(crossref.QueryKeyFilter) != crossref.QueryValFilterUpdates+"10.1000/work" {
+			w.Write([]byte(`{"status": "ok", "message": {"items": []}}`))
+			return
+		}
+		w.Write([]byte(testUpdateNotices))
+	})
+
+	articles := []article.Article{
+		{DOI: "10.1000/work", Meta: &crossref.Work{
+			DOI: "10.1000/work",
+			UpdatedBy: []crossref.WorkUpdate{{
+				DOI: "10.1000/retraction", Type: "retraction", Label: "Retraction",
+				Source: "retraction-watch", RecordID: 12345,
+			}},
+		}},
+		{DOI: "10.1000/fine"},
+		{Handle: article.Handle{Value: "no DOI"}},
+	}
+	fetchUpdates(articles)
+	assert.Equal([]crossref.WorkUpdate{
+		{DOI: "10.1000/retraction", Type: "retraction", Label: "Retraction",
+			Source: "retraction-watch", RecordID: 12345},
+		{DOI: "10.1000/erratum", Type: "erratum", Label: "Erratum"},
+	}, articles[0].Updates)
+	assert.Empty(articles[1].Updates)
+	assert.Empty(articles[2].Updates)
+	assert.True(logUpdates(articles))
+	assert.False(logUpdates(articles[1:]))
+}
+
+func TestAnnotateCitation(t *testing.T) {
+	defer func() { CiteFormat = crossref.BibTeX }()
+	retraction := []crossref.WorkUpdate{
+		{DOI: "10.1000/re_1", Type: "retraction", Label: "Retraction"},
+		{DOI: "10.1000/v2", Type: "new_version"},
+	}
+	concern := []crossref.WorkUpdate{
+		{DOI: "10.1000/eoc", Type: "expression_of_concern"},
+	}
+	tests := []struct {
+		Name     string
+		Format   crossref.ContentType
+		Updates  []crossref.WorkUpdate
+		Citation string
+		Expected string
+	}{
+		{
+			Name:     "bibtex",
+			Format:   crossref.BibTeX,
+			Updates:  retraction,
+			Citation: " @article{Doe_2020, title={A study}, year={2020}}",
+			Expected: " @article{Doe_2020, title={A study}, year={2020},\n note = {Retracted. Retraction: doi:10.1000/re\\_1}}",
+		},
+		{
+			Name:     "bibtex-note",
+			Format:   crossref.BibTeX,
+			Updates:  concern,
+			Citation: "@article{Doe_2020, note={In press}}",
+			Expected: "@article{Doe_2020, note={In press. Expression of concern: doi:10.1000/eoc}}",
+		},
+		{
+			Name:     "ris",
+			Format:   crossref.RIS,
+			Updates:  retraction,
+			Citation: "TY  - JOUR\nTI  - A study\nER  - \n",
+			Expected: "TY  - JOUR\nTI  - A study\nN1  - Retracted. Retraction: doi:10.1000/re_1\nER  - \n",
+		},
+		{
+			Name:     "csl-json",
+			Format:   crossref.CiteprocJSON,
+			Updates:  concern,
+			Citation: `{"type":"article-journal","title":"A study"}`,
+			Expected: `{"note":"Expression of concern: doi:10.1000/eoc","title":"A study","type":"article-journal"}`,
+		},
+		{
+			Name:     "no-notes",
+			Format:   crossref.BibTeX,
+			Updates:  retraction[1:],
+			Citation: "@article{Doe_2020, title={A study}}",
+			Expected: "@article{Doe_2020, title={A study}}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			CiteFormat = tt.Format
+			a := article.Article{Citation: []byte(tt.Citation), Updates: tt.Updates}
+			assert.Nil(t, annotateCitation(&a))
+			assert.Equal(t, tt.Expected, string(a.Citation))
+		})
+	}
+}
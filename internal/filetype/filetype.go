@@ -0,0 +1,125 @@
+package filetype
+
+import (
+	"bytes"
+	"mime"
+	"strings"
+)
+
+// Type is the (detected) type of a downloaded file.
+type Type int
+
+// File types which can be detected.
+const (
+	Unknown Type = iota
+	PDF
+	EPUB
+	DjVu
+	HTML
+)
+
+// SniffLen is the number of leading bytes needed by Detect.
+const SniffLen = 1024
+
+var (
+	// names are the user-friendly Type names.
+	names = [...]string{
+		"unknown",
+		"PDF",
+		"EPUB",
+		"DjVu",
+		"HTML",
+	}
+	// extensions are the file name extensions of each Type.
+	extensions = [...]string{
+		"",
+		".pdf",
+		".epub",
+		".djvu",
+		".html",
+	}
+	// mediaTypes are the media (MIME) types of each Type.
+	mediaTypes = map[string]Type{
+		"application/pdf":       PDF,
+		"application/x-pdf":     PDF,
+		"application/epub+zip":  EPUB,
+		"image/vnd.djvu":        DjVu,
+		"image/x-djvu":          DjVu,
+		"text/html":             HTML,
+		"application/xhtml+xml": HTML,
+	}
+	// htmlPrefixes are the (lower-case) prefixes of HTML documents.
+	htmlPrefixes = []string{
+		"<!doctype html",
+		"<!--",
+		"<html",
+		"<head",
+		"<body",
+		"<script",
+		"<title",
+		"<meta",
+	}
+)
+
+// String returns the Type (name) as a user-friendly string.
+func (t Type) String() string {
+	return names[t]
+}
+
+// Extension returns the file name extension of the Type, including
+// the leading dot, or an empty string if the Type is Unknown.
+func (t Type) Extension() string {
+	return extensions[t]
+}
+
+// Detect returns the Type of a file from its leading bytes and the media
+// type reported by the server, e.g., via the Content-Type header.
+// Documents (PDF, EPUB and DjVu) are only recognised by their magic bytes,
+// since servers often report generic or wrong media types, and serve
+// error pages as documents. A document whose magic bytes contradict the
+// reported document type is Unknown. Files without magic bytes are HTML
+// only if reported as such, otherwise Unknown. At most SniffLen leading
+// bytes are examined.
+func Detect(contentType string, head []byte) Type {
+	if len(head) > SniffLen {
+		head = head[:SniffLen]
+	}
+	var reported Type
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		reported = mediaTypes[strings.ToLower(mt)]
+	}
+	switch t := detectMagic(head); {
+	case t == Unknown && reported == HTML:
+		return HTML
+	case t == HTML || reported == Unknown || reported == HTML || reported == t:
+		return t
+	}
+	return Unknown
+}
+
+// detectMagic returns the Type of a file from its leading bytes only.
+func detectMagic(head []byte) Type {
+	// some servers prepend white space or a BOM to documents
+	s := bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	s = bytes.TrimLeft(s, " \t\r\n\f\x00")
+	switch {
+	case bytes.HasPrefix(s, []byte("%PDF-")):
+		return PDF
+	// the first (stored) entry of an EPUB is its media type
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")) &&
+		bytes.Contains(head, []byte("mimetypeapplication/epub+zip")):
+		return EPUB
+	case bytes.HasPrefix(head, []byte("AT&TFORM")):
+		return DjVu
+	}
+	s = bytes.ToLower(s)
+	if bytes.HasPrefix(s, []byte("<?xml")) && bytes.Contains(s, []byte("<html")) {
+		return HTML
+	}
+	for _, p := range htmlPrefixes {
+		if bytes.HasPrefix(s, []byte(p)) {
+			return HTML
+		}
+	}
+	return Unknown
+}
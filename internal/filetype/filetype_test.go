@@ -0,0 +1,120 @@
+package filetype
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type detectTest struct {
+	Name        string
+	ContentType string
+	Head        string
+	Output      Type
+}
+
+var detectTests = []detectTest{
+	{
+		Name:        "pdf",
+		ContentType: "application/pdf",
+		Head:        "%PDF-1.7\n%\xe2\xe3\xcf\xd3\n",
+		Output:      PDF,
+	},
+	{
+		Name:        "pdf-octet-stream",
+		ContentType: "application/octet-stream",
+		Head:        "%PDF-1.4\n",
+		Output:      PDF,
+	},
+	{
+		Name:        "pdf-leading-junk",
+		ContentType: "",
+		Head:        "\r\n\r\n%PDF-1.5\n",
+		Output:      PDF,
+	},
+	{
+		Name:        "pdf-bom",
+		ContentType: "",
+		Head:        "\xef\xbb\xbf%PDF-1.5\n",
+		Output:      PDF,
+	},
+	{
+		Name:        "html-mentioning-pdf",
+		ContentType: "application/pdf",
+		Head:        "<html><body>Not a %PDF-1.7 file</body></html>",
+		Output:      HTML,
+	},
+	{
+		Name:        "text-mentioning-pdf",
+		ContentType: "",
+		Head:        "Error: expected %PDF-1.7 header",
+		Output:      Unknown,
+	},
+	{
+		Name:        "html-as-pdf",
+		ContentType: "application/pdf",
+		Head:        "\n  <!DOCTYPE html>\n<html><body>captcha</body></html>",
+		Output:      HTML,
+	},
+	{
+		Name:        "html-content-type",
+		ContentType: "text/html; charset=utf-8",
+		Head:        "Access denied",
+		Output:      HTML,
+	},
+	{
+		Name:        "html-comment-as-pdf",
+		ContentType: "application/pdf",
+		Head:        "<!-- generated by the error handler -->\n<p>Not found</p>",
+		Output:      HTML,
+	},
+	{
+		Name:        "text-as-pdf",
+		ContentType: "application/pdf",
+		Head:        "Service temporarily unavailable",
+		Output:      Unknown,
+	},
+	{
+		Name:        "pdf-as-html",
+		ContentType: "text/html",
+		Head:        "%PDF-1.4\n",
+		Output:      PDF,
+	},
+	{
+		Name:        "djvu-as-pdf",
+		ContentType: "application/pdf",
+		Head:        "AT&TFORM\x00\x00\x00\x00DJVM",
+		Output:      Unknown,
+	},
+	{
+		Name:        "text-as-djvu",
+		ContentType: "image/vnd.djvu",
+		Head:        "Unsupported file",
+		Output:      Unknown,
+	},
+	{
+		Name:        "epub",
+		ContentType: "application/octet-stream",
+		Head:        "PK\x03\x04\x0a\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x08\x00\x00\x00mimetypeapplication/epub+zip",
+		Output:      EPUB,
+	},
+	{
+		Name:        "djvu",
+		ContentType: "",
+		Head:        "AT&TFORM\x00\x00\x00\x00DJVM",
+		Output:      DjVu,
+	},
+	{
+		Name:        "unknown",
+		ContentType: "application/octet-stream",
+		Head:        "\x00\x01\x02\x03",
+		Output:      Unknown,
+	},
+}
+
+func TestDetect(t *testing.T) {
+	assert := assert.New(t)
+	for _, tt := range detectTests {
+		assert.Equal(tt.Output, Detect(tt.ContentType, []byte(tt.Head)), tt.Name)
+	}
+}
@@ -0,0 +1,184 @@
+// Package index implements an on-disk full-text and metadata index of
+// documents in a directory tree, and fielded queries over it.
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Milover/fetchref/internal/article"
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/Milover/fetchref/internal/outfile"
+)
+
+// FileName is the name of the index file, which is stored in the root
+// of the indexed directory tree.
+const FileName = ".fetchref-index.json"
+
+// Version is the index format version. Indexes of other versions are
+// rebuilt.
+const Version = 1
+
+// Doc is an indexed document.
+type Doc struct {
+	Path    string    `json:"path"` // relative to the indexed directory
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+
+	DOI     string         `json:"doi,omitempty"`
+	Title   string         `json:"title,omitempty"`
+	Authors []string       `json:"authors,omitempty"`
+	Journal string         `json:"journal,omitempty"`
+	Year    int            `json:"year,omitempty"`
+	Meta    *crossref.Work `json:"metadata,omitempty"`
+
+	// Terms are the frequencies of the terms of the document's text.
+	Terms map[string]int `json:"terms,omitempty"`
+	// Length is the number of terms of the document's text.
+	Length int `json:"length"`
+}
+
+// SetMeta sets the document's metadata, and the title, authors, journal
+// and year from it.
+func (d *Doc) SetMeta(w *crossref.Work) {
+	d.Meta = w
+	if w == nil {
+		return
+	}
+	if len(w.DOI) != 0 {
+		d.DOI = w.DOI
+	}
+	if len(w.Title) != 0 {
+		d.Title = w.Title[0]
+	}
+	if len(w.Author) != 0 {
+		d.Authors = d.Authors[:0]
+		for _, a := range w.Author {
+			name := strings.TrimSpace(a.Given + " " + a.Family)
+			if len(name) == 0 {
+				name = a.Name
+			}
+			d.Authors = append(d.Authors, name)
+		}
+	}
+	if len(w.ContainerTitle) != 0 {
+		d.Journal = w.ContainerTitle[0]
+	}
+	if y := w.Year(); y > 0 {
+		d.Year = y
+	}
+}
+
+// SetText sets the document's terms from its text.
+func (d *Doc) SetText(text string) {
+	d.Terms = make(map[string]int)
+	d.Length = 0
+	for _, t := range Tokens(text) {
+		d.Terms[t]++
+		d.Length++
+	}
+}
+
+// Index is an index of the documents in a directory tree.
+type Index struct {
+	Version int             `json:"version"`
+	Docs    map[string]*Doc `json:"docs"` // by path
+
+	dir string
+}
+
+// Load loads the index of the directory. An empty index is returned if the
+// directory has not been indexed yet, or if the index is of another format
+// version.
+func Load(dir string) (*Index, error) {
+	ix := &Index{Version: Version, Docs: make(map[string]*Doc), dir: dir}
+	b, err := os.ReadFile(filepath.Join(dir, FileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ix, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var stored Index
+	if err := json.Unmarshal(b, &stored); err != nil {
+		return nil, fmt.Errorf("%v: %w", filepath.Join(dir, FileName), err)
+	}
+	if stored.Version == Version && stored.Docs != nil {
+		ix.Docs = stored.Docs
+	}
+	return ix, nil
+}
+
+// Dir returns the indexed directory.
+func (ix *Index) Dir() string {
+	return ix.dir
+}
+
+// Save writes the index to the index file, atomically.
+func (ix *Index) Save() error {
+	b, err := json.Marshal(ix)
+	if err != nil {
+		return err
+	}
+	return outfile.WriteFile(filepath.Join(ix.dir, FileName), b, 0666)
+}
+
+// Stale reports whether the document at the path, relative to the indexed
+// directory, with file info fi, is not indexed, or was modified since it
+// was indexed.
+func (ix *Index) Stale(path string, fi fs.FileInfo) bool {
+	d, found := ix.Docs[path]
+	return !found || d.Size != fi.Size() || !d.ModTime.Equal(fi.ModTime())
+}
+
+// Put adds the document to the index, replacing a document with the
+// same path.
+func (ix *Index) Put(d *Doc) {
+	ix.Docs[d.Path] = d
+}
+
+// Prune removes documents, whose paths are not kept, from the index,
+// and returns the number of removed documents.
+func (ix *Index) Prune(keep map[string]bool) int {
+	var n int
+	for path := range ix.Docs {
+		if !keep[path] {
+			delete(ix.Docs, path)
+			n++
+		}
+	}
+	return n
+}
+
+// sorted returns the documents ordered by path.
+func (ix *Index) sorted() []*Doc {
+	docs := make([]*Doc, 0, len(ix.Docs))
+	for _, d := range ix.Docs {
+		docs = append(docs, d)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].Path < docs[j].Path })
+	return docs
+}
+
+// Tokens splits the text into terms, i.e., lower case words consisting of
+// letters and digits, transliterated to ASCII if possible.
+func Tokens(text string) []string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.Is(unicode.Mn, r)
+	})
+	for i, w := range words {
+		w = strings.ToLower(w)
+		if t := strings.ReplaceAll(article.Transliterate(w), " ", ""); len(t) != 0 {
+			w = strings.ToLower(t)
+		}
+		words[i] = w
+	}
+	return words
+}
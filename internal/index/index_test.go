@@ -0,0 +1,96 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/stretchr/testify/assert"
+)
+
+// testIndex returns an index of a few documents.
+func testIndex(dir string) *Index {
+	ix := &Index{Version: Version, Docs: make(map[string]*Doc), dir: dir}
+	docs := []struct {
+		Path string
+		Meta crossref.Work
+		Text string
+	}{
+		{
+			Path: "ligo.pdf",
+			Meta: crossref.Work{
+				DOI:            "10.1103/PhysRevLett.116.061102",
+				Title:          []string{"Observation of Gravitational Waves from a Binary Black Hole Merger"},
+				Author:         []crossref.Author{{Given: "B. P.", Family: "Abbott"}},
+				ContainerTitle: []string{"Physical Review Letters"},
+				Issued:         crossref.DateParts{DateParts: [][]int{{2016}}},
+			},
+			Text: "On September 14, 2015 the two detectors of LIGO observed a transient gravitational-wave signal.",
+		},
+		{
+			Path: "sub/schrodinger.pdf",
+			Meta: crossref.Work{
+				DOI:            "10.1103/PhysRev.28.1049",
+				Title:          []string{"An Undulatory Theory of the Mechanics of Atoms and Molecules"},
+				Author:         []crossref.Author{{Given: "E.", Family: "Schrödinger"}},
+				ContainerTitle: []string{"Physical Review"},
+				Issued:         crossref.DateParts{DateParts: [][]int{{1926}}},
+			},
+			Text: "The theory which is reported in the following pages is based on waves. Waves, waves and waves.",
+		},
+		{
+			Path: "unknown.pdf",
+			Text: "Some notes on gravitational lensing.",
+		},
+	}
+	for _, tt := range docs {
+		d := &Doc{Path: tt.Path}
+		if tt.Meta.DOI != "" {
+			meta := tt.Meta
+			d.SetMeta(&meta)
+		}
+		d.SetText(tt.Text)
+		ix.Put(d)
+	}
+	return ix
+}
+
+func TestIndex(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	ix, err := Load(dir)
+	assert.Nil(err)
+	assert.Empty(ix.Docs)
+
+	ix = testIndex(dir)
+	assert.Nil(ix.Save())
+	ix, err = Load(dir)
+	assert.Nil(err)
+	assert.Len(ix.Docs, 3)
+	d := ix.Docs["sub/schrodinger.pdf"]
+	if assert.NotNil(d) {
+		assert.Equal(4, d.Terms["waves"])
+		assert.Equal([]string{"E. Schrödinger"}, d.Authors)
+		assert.Equal(1926, d.Year)
+	}
+
+	name := filepath.Join(dir, "ligo.pdf")
+	assert.Nil(os.WriteFile(name, []byte("%PDF-"), 0666))
+	fi, err := os.Stat(name)
+	assert.Nil(err)
+	assert.True(ix.Stale("ligo.pdf", fi))
+	ix.Docs["ligo.pdf"].Size = fi.Size()
+	ix.Docs["ligo.pdf"].ModTime = fi.ModTime()
+	assert.False(ix.Stale("ligo.pdf", fi))
+
+	assert.Equal(2, ix.Prune(map[string]bool{"ligo.pdf": true}))
+	assert.Len(ix.Docs, 1)
+}
+
+func TestTokens(t *testing.T) {
+	assert.Equal(t,
+		[]string{"schrodinger", "s", "wave", "equation", "1926", "日本"},
+		Tokens("Schrödinger's wave-equation (1926), 日本"))
+}
@@ -0,0 +1,268 @@
+package index
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Milover/fetchref/internal/doi"
+)
+
+// BM25 ranking parameters, and the weight of query terms matching the
+// title, relative to matching the text.
+const (
+	bm25K1      = 1.2
+	bm25B       = 0.75
+	titleWeight = 2.0
+)
+
+// Query is a parsed search query. Documents match a query if they contain
+// all of its terms, and match all of its fields.
+type Query struct {
+	Terms    []string // full text terms
+	Authors  []string // author names, or parts of names
+	Titles   []string // title words
+	Journals []string // journal names, or parts of names
+	DOIs     []string // DOIs, or DOI prefixes
+	YearMin  int      // 0 if unbounded
+	YearMax  int      // 0 if unbounded
+}
+
+// ParseQuery parses a search query consisting of full text terms and
+// fielded terms, i.e., 'author:', 'title:', 'journal:', 'doi:' and 'year:'
+// followed by a value, e.g.:
+//
+//	gravitational author:"van der Waals" year:2010..2015 journal:nature
+//
+// Field values containing spaces are quoted. Years are either a single
+// year, or a range, whose start or end may be omitted, e.g., '2010..'.
+func ParseQuery(s string) (Query, error) {
+	var q Query
+	for _, tok := range split(s) {
+		field, value, found := strings.Cut(tok, ":")
+		if !found {
+			q.Terms = append(q.Terms, Tokens(tok)...)
+			continue
+		}
+		value = strings.Trim(value, `"`)
+		switch strings.ToLower(field) {
+		case "author":
+			q.Authors = append(q.Authors, value)
+		case "title":
+			q.Titles = append(q.Titles, Tokens(value)...)
+		case "journal":
+			q.Journals = append(q.Journals, value)
+		case "doi":
+			q.DOIs = append(q.DOIs, doi.Clean(value))
+		case "year":
+			if err := q.parseYears(value); err != nil {
+				return Query{}, err
+			}
+		default:
+			// not a field, e.g., 'ratio 1:2'
+			q.Terms = append(q.Terms, Tokens(tok)...)
+		}
+	}
+	return q, nil
+}
+
+// parseYears parses a year, or a range of years, e.g., '2010..2015'.
+func (q *Query) parseYears(s string) error {
+	lo, hi, isRange := strings.Cut(s, "..")
+	if !isRange {
+		hi = lo
+	}
+	var err error
+	if len(lo) != 0 {
+		if q.YearMin, err = strconv.Atoi(lo); err != nil {
+			return fmt.Errorf("bad year in query: %q", s)
+		}
+	}
+	if len(hi) != 0 {
+		if q.YearMax, err = strconv.Atoi(hi); err != nil {
+			return fmt.Errorf("bad year in query: %q", s)
+		}
+	}
+	if len(lo) == 0 && len(hi) == 0 {
+		return fmt.Errorf("bad year in query: %q", s)
+	}
+	return nil
+}
+
+// split splits the query at white space outside of double quotes.
+func split(s string) []string {
+	var toks []string
+	var b strings.Builder
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			b.WriteRune(r)
+		case !quoted && (r == ' ' || r == '\t' || r == '\n'):
+			if b.Len() != 0 {
+				toks = append(toks, b.String())
+				b.Reset()
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() != 0 {
+		toks = append(toks, b.String())
+	}
+	return toks
+}
+
+// Hit is a document matching a query.
+type Hit struct {
+	Doc   *Doc
+	Score float64
+}
+
+// Search returns the documents matching the query, ranked by relevance to
+// the query's full text and title terms (BM25), or, if the query has none,
+// by year, most recent first.
+func (ix *Index) Search(q Query) []Hit {
+	docs := ix.sorted()
+	var avgLen float64
+	for _, d := range docs {
+		avgLen += float64(d.Length)
+	}
+	if len(docs) != 0 {
+		avgLen /= float64(len(docs))
+	}
+	idf := make(map[string]float64)
+	for _, t := range append(append([]string(nil), q.Terms...), q.Titles...) {
+		var n int
+		for _, d := range docs {
+			if d.Terms[t] != 0 || contains(Tokens(d.Title), t) {
+				n++
+			}
+		}
+		idf[t] = math.Log(1 + (float64(len(docs))-float64(n)+0.5)/(float64(n)+0.5))
+	}
+
+	var hits []Hit
+	for _, d := range docs {
+		if !q.matchFields(d) {
+			continue
+		}
+		title := Tokens(d.Title)
+		var score float64
+		matched := true
+		for _, t := range q.Terms {
+			tf := float64(d.Terms[t])
+			inTitle := contains(title, t)
+			if tf == 0 && !inTitle && !q.matchAuthorTerm(d, t) {
+				matched = false
+				break
+			}
+			norm := 1 - bm25B
+			if avgLen > 0 {
+				norm += bm25B * float64(d.Length) / avgLen
+			}
+			score += idf[t] * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+			if inTitle {
+				score += titleWeight * idf[t]
+			}
+		}
+		if !matched {
+			continue
+		}
+		for _, t := range q.Titles {
+			score += titleWeight * idf[t]
+		}
+		hits = append(hits, Hit{Doc: d, Score: score})
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Doc.Year > hits[j].Doc.Year
+	})
+	return hits
+}
+
+// matchFields reports whether the document matches the query's fields.
+func (q Query) matchFields(d *Doc) bool {
+	if q.YearMin != 0 && d.Year < q.YearMin {
+		return false
+	}
+	if q.YearMax != 0 && (d.Year == 0 || d.Year > q.YearMax) {
+		return false
+	}
+	for _, v := range q.DOIs {
+		if !doi.Equal(v, d.DOI) &&
+			!strings.HasPrefix(strings.ToLower(d.DOI), strings.ToLower(v)) {
+			return false
+		}
+	}
+	title := Tokens(d.Title)
+	for _, t := range q.Titles {
+		if !contains(title, t) {
+			return false
+		}
+	}
+	for _, v := range q.Authors {
+		var found bool
+		for _, a := range d.Authors {
+			if containsAll(Tokens(a), Tokens(v)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, v := range q.Journals {
+		names := []string{d.Journal}
+		if d.Meta != nil {
+			names = append(names, d.Meta.ShortContainerTitle...)
+		}
+		var found bool
+		for _, n := range names {
+			if len(n) != 0 && containsAll(Tokens(n), Tokens(v)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchAuthorTerm reports whether the term is part of an author's name.
+func (q Query) matchAuthorTerm(d *Doc, t string) bool {
+	for _, a := range d.Authors {
+		if contains(Tokens(a), t) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether the terms contain the term t.
+func contains(terms []string, t string) bool {
+	for _, s := range terms {
+		if s == t {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAll reports whether the terms contain all of the terms ts.
+func containsAll(terms, ts []string) bool {
+	for _, t := range ts {
+		if !contains(terms, t) {
+			return false
+		}
+	}
+	return len(ts) != 0
+}
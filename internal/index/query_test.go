@@ -0,0 +1,101 @@
+package index
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuery(t *testing.T) {
+	assert := assert.New(t)
+	var tests = []struct {
+		Name  string
+		Input string
+		Query Query
+		Error bool
+	}{
+		{
+			Name:  "terms",
+			Input: "Gravitational  waves",
+			Query: Query{Terms: []string{"gravitational", "waves"}},
+		},
+		{
+			Name:  "fields",
+			Input: `author:"van der Waals" title:"Binary Black" journal:nature doi:doi:10.1103/`,
+			Query: Query{
+				Authors:  []string{"van der Waals"},
+				Titles:   []string{"binary", "black"},
+				Journals: []string{"nature"},
+				DOIs:     []string{"10.1103/"},
+			},
+		},
+		{
+			Name:  "year-range",
+			Input: "year:2010..2015",
+			Query: Query{YearMin: 2010, YearMax: 2015},
+		},
+		{
+			Name:  "year-open",
+			Input: "year:..2015 ratio 1:2",
+			Query: Query{YearMax: 2015, Terms: []string{"ratio", "1", "2"}},
+		},
+		{
+			Name:  "year",
+			Input: "year:2016",
+			Query: Query{YearMin: 2016, YearMax: 2016},
+		},
+		{
+			Name:  "bad-year",
+			Input: "year:recent",
+			Error: true,
+		},
+		{
+			Name:  "empty-year",
+			Input: "year:..",
+			Error: true,
+		},
+	}
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.Input)
+		if tt.Error {
+			assert.NotNil(err, tt.Name)
+			continue
+		}
+		assert.Nil(err, tt.Name)
+		assert.Equal(tt.Query, q, tt.Name)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	assert := assert.New(t)
+	ix := testIndex(t.TempDir())
+	var tests = []struct {
+		Query string
+		Paths []string
+	}{
+		{"", []string{"ligo.pdf", "sub/schrodinger.pdf", "unknown.pdf"}},
+		// title matches outweigh text matches
+		{"waves", []string{"ligo.pdf", "sub/schrodinger.pdf"}},
+		{"waves theory", []string{"sub/schrodinger.pdf"}},
+		{"gravitational", []string{"ligo.pdf", "unknown.pdf"}},
+		{"gravitational year:2000..", []string{"ligo.pdf"}},
+		{"year:..1950", []string{"sub/schrodinger.pdf"}},
+		{"author:schrodinger", []string{"sub/schrodinger.pdf"}},
+		{"abbott", []string{"ligo.pdf"}},
+		{`author:"B. P. Abbott" title:merger`, []string{"ligo.pdf"}},
+		{"journal:review", []string{"ligo.pdf", "sub/schrodinger.pdf"}},
+		{`journal:"physical review letters"`, []string{"ligo.pdf"}},
+		{"doi:10.1103/physrev.28.1049", []string{"sub/schrodinger.pdf"}},
+		{"doi:10.1103/", []string{"ligo.pdf", "sub/schrodinger.pdf"}},
+		{"lensing waves", nil},
+	}
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.Query)
+		assert.Nil(err, tt.Query)
+		var paths []string
+		for _, h := range ix.Search(q) {
+			paths = append(paths, h.Doc.Path)
+		}
+		assert.Equal(tt.Paths, paths, tt.Query)
+	}
+}
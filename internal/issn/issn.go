@@ -0,0 +1,100 @@
+package issn
+
+import (
+	"strings"
+)
+
+// Type is the ISSN type, i.e., the medium the ISSN was assigned to.
+type Type int
+
+// ISSN types as reported by Crossref (and most other registries).
+const (
+	Unknown Type = iota
+	Print
+	Electronic
+	Linking
+)
+
+var (
+	// typeNames are the user-friendly Type names.
+	typeNames = [...]string{
+		"unknown",
+		"print",
+		"electronic",
+		"linking",
+	}
+)
+
+// ParseType returns the Type corresponding to a registry-supplied
+// ISSN type string, e.g., 'print', 'pissn', 'electronic' or 'eissn'.
+func ParseType(s string) Type {
+	switch strings.ToLower(s) {
+	case "print", "pissn":
+		return Print
+	case "electronic", "eissn":
+		return Electronic
+	case "linking", "lissn":
+		return Linking
+	}
+	return Unknown
+}
+
+// String returns the Type (name) as a user-friendly string.
+func (t Type) String() string {
+	return typeNames[t]
+}
+
+// Clean returns the ISSN in its canonical 'NNNN-NNNC' form, with an
+// upper-case check digit and without an 'ISSN:' prefix. An ISSN which does
+// not have 8 characters (sans dashes) is returned unchanged.
+func Clean(n string) string {
+	str := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(trimPrefix(n)), "-", ""))
+	if len(str) != 8 {
+		return n
+	}
+	return str[:4] + "-" + str[4:]
+}
+
+// IsValid reports whether n is a valid ISSN, i.e., whether it consists
+// of seven digits followed by a valid check digit. A dash separating
+// the two 4-character halves is permitted.
+func IsValid(n string) bool {
+	str := strings.ReplaceAll(n, "-", "")
+	if len(str) != 8 {
+		return false
+	}
+
+	var sum int
+	for i := 0; i < 7; i++ {
+		if str[i] < '0' || str[i] > '9' {
+			return false
+		}
+		sum += int(str[i]-'0') * (8 - i)
+	}
+
+	cs := (11 - sum%11) % 11
+	if cs == 10 {
+		return str[7] == 'x' || str[7] == 'X'
+	}
+	return int(str[7]-'0') == cs
+}
+
+// IsHandle reports whether n is a valid ISSN given as a handle, i.e., in
+// the hyphenated 'NNNN-NNNC' form, or with a (case-insensitive) 'ISSN:'
+// prefix. Eight digits without either are not an ISSN handle, since they
+// are, e.g., just as likely a PMID.
+func IsHandle(n string) bool {
+	if m := trimPrefix(n); m != n {
+		return IsValid(strings.TrimSpace(m))
+	}
+	return len(n) == 9 && n[4] == '-' && IsValid(n)
+}
+
+// trimPrefix strips a case-insensitive 'ISSN:' prefix from n, if present.
+func trimPrefix(n string) string {
+	const prefix = "issn:"
+	if len(n) > len(prefix) && strings.EqualFold(n[:len(prefix)], prefix) {
+		return n[len(prefix):]
+	}
+	return n
+}
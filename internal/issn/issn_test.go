@@ -0,0 +1,101 @@
+package issn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type issnTest struct {
+	Name   string
+	Input  string
+	Output bool
+}
+
+var issnTests = []issnTest{
+	{
+		Name:   "good-issn",
+		Input:  "00221120",
+		Output: true,
+	},
+	{
+		Name:   "good-issn-dash",
+		Input:  "0022-1120",
+		Output: true,
+	},
+	{
+		Name:   "good-issn-x",
+		Input:  "2434-561X",
+		Output: true,
+	},
+	{
+		Name:   "good-issn-x-lower",
+		Input:  "2434-561x",
+		Output: true,
+	},
+	{
+		Name:   "bad-issn-checksum",
+		Input:  "0022-1121",
+		Output: false,
+	},
+	{
+		Name:   "bad-issn-x-checksum",
+		Input:  "0022-112X",
+		Output: false,
+	},
+	{
+		Name:   "bad-issn-rune",
+		Input:  "00a2-1120",
+		Output: false,
+	},
+	{
+		Name:   "bad-issn-length",
+		Input:  "0022-11200",
+		Output: false,
+	},
+	{
+		Name:   "bad-cake",
+		Input:  "cake",
+		Output: false,
+	},
+}
+
+func TestIsValid(t *testing.T) {
+	for _, tt := range issnTests {
+		t.Run(tt.Name, func(t *testing.T) {
+			out := IsValid(tt.Input)
+			assert.Equal(t, tt.Output, out)
+		})
+	}
+}
+
+func TestIsHandle(t *testing.T) {
+	var tests = []issnTest{
+		{Name: "good-dash", Input: "0022-1120", Output: true},
+		{Name: "good-prefix", Input: "issn:00221120", Output: true},
+		{Name: "good-prefix-dash", Input: "ISSN: 2434-561X", Output: true},
+		{Name: "bad-no-dash", Input: "00221120", Output: false},
+		{Name: "bad-pmid", Input: "23193298", Output: false},
+		{Name: "bad-dash-position", Input: "002-21120", Output: false},
+		{Name: "bad-prefix-checksum", Input: "issn:0022-1121", Output: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Output, IsHandle(tt.Input))
+		})
+	}
+}
+
+func TestClean(t *testing.T) {
+	assert.Equal(t, "0022-1120", Clean("00221120"))
+	assert.Equal(t, "0022-1120", Clean("ISSN:0022-1120"))
+	assert.Equal(t, "2434-561X", Clean("2434561x"))
+	assert.Equal(t, "cake", Clean("cake"))
+}
+
+func TestParseType(t *testing.T) {
+	assert.Equal(t, Print, ParseType("print"))
+	assert.Equal(t, Electronic, ParseType("eissn"))
+	assert.Equal(t, Linking, ParseType("LISSN"))
+	assert.Equal(t, Unknown, ParseType("cake"))
+}
@@ -0,0 +1,107 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Lookup returns the value at the path in a JSON document.
+//
+// Only a subset of JSONPath is supported: the root ('$'), child members in
+// dot ('.name') or bracket ('['name']') notation and array indices
+// ('[0]'), e.g., '$.data.files[0].url'.
+func Lookup(doc []byte, path string) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(doc, &v); err != nil {
+		return nil, err
+	}
+	steps, err := parse(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range steps {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			var found bool
+			if v, found = t[s]; !found {
+				return nil, fmt.Errorf("jsonpath: %v: no member %q", path, s)
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("jsonpath: %v: %q is not an index", path, s)
+			}
+			if i < 0 {
+				i += len(t)
+			}
+			if i < 0 || i >= len(t) {
+				return nil, fmt.Errorf("jsonpath: %v: index out of range: %v", path, s)
+			}
+			v = t[i]
+		default:
+			return nil, fmt.Errorf("jsonpath: %v: cannot descend into %q", path, s)
+		}
+	}
+	return v, nil
+}
+
+// LookupString returns the string value at the path in a JSON document.
+func LookupString(doc []byte, path string) (string, error) {
+	v, err := Lookup(doc, path)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("jsonpath: %v: not a string: %v", path, v)
+	}
+	return s, nil
+}
+
+// Validate reports whether the path is a valid (supported) JSONPath.
+func Validate(path string) error {
+	_, err := parse(path)
+	return err
+}
+
+// parse splits a path into member names and array indices.
+func parse(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("jsonpath: %v: must start with '$'", path)
+	}
+	var steps []string
+	rest := path[1:]
+	for len(rest) != 0 {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			i := strings.IndexAny(rest, ".[")
+			if i == -1 {
+				i = len(rest)
+			}
+			if i == 0 {
+				return nil, fmt.Errorf("jsonpath: %v: empty member name", path)
+			}
+			steps = append(steps, rest[:i])
+			rest = rest[i:]
+		case '[':
+			i := strings.IndexByte(rest, ']')
+			if i == -1 {
+				return nil, fmt.Errorf("jsonpath: %v: unterminated '['", path)
+			}
+			s := strings.TrimSpace(rest[1:i])
+			if len(s) > 1 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+				s = s[1 : len(s)-1]
+			} else if _, err := strconv.Atoi(s); err != nil {
+				return nil, fmt.Errorf("jsonpath: %v: unsupported selector: %v", path, s)
+			}
+			steps = append(steps, s)
+			rest = rest[i+1:]
+		default:
+			return nil, fmt.Errorf("jsonpath: %v: unexpected %q", path, rest[0])
+		}
+	}
+	return steps, nil
+}
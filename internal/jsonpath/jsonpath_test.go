@@ -0,0 +1,87 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testDoc = `{
+	"status": "ok",
+	"data": {
+		"files": [
+			{"url": "https://docs.internal/a.pdf", "size": 1024},
+			{"url": "https://docs.internal/b.epub"}
+		],
+		"file.name": "a.pdf"
+	}
+}`
+
+type jsonpathTest struct {
+	Name   string
+	Input  string
+	Output string
+	Err    bool
+}
+
+var jsonpathTests = []jsonpathTest{
+	{
+		Name:   "good-dot",
+		Input:  "$.status",
+		Output: "ok",
+	},
+	{
+		Name:   "good-index",
+		Input:  "$.data.files[0].url",
+		Output: "https://docs.internal/a.pdf",
+	},
+	{
+		Name:   "good-negative-index",
+		Input:  "$.data.files[-1].url",
+		Output: "https://docs.internal/b.epub",
+	},
+	{
+		Name:   "good-bracket",
+		Input:  "$['data']['file.name']",
+		Output: "a.pdf",
+	},
+	{
+		Name:  "bad-not-string",
+		Input: "$.data.files[0].size",
+		Err:   true,
+	},
+	{
+		Name:  "bad-member",
+		Input: "$.data.cake",
+		Err:   true,
+	},
+	{
+		Name:  "bad-index",
+		Input: "$.data.files[2]",
+		Err:   true,
+	},
+	{
+		Name:  "bad-root",
+		Input: "data.files",
+		Err:   true,
+	},
+	{
+		Name:  "bad-wildcard",
+		Input: "$.data.files[*].url",
+		Err:   true,
+	},
+}
+
+func TestLookupString(t *testing.T) {
+	for _, tt := range jsonpathTests {
+		t.Run(tt.Name, func(t *testing.T) {
+			out, err := LookupString([]byte(testDoc), tt.Input)
+			if tt.Err {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.Output, out)
+		})
+	}
+}
@@ -0,0 +1,276 @@
+// Package latex scans LaTeX documents, and the auxiliary (.aux) files
+// written by LaTeX, for citations and bibliography files.
+package latex
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Document holds the citations of a LaTeX document.
+type Document struct {
+	// Keys are the cited keys, in order of first citation.
+	Keys []string
+	// Bibs are the names of the bibliography (.bib) files of the document.
+	Bibs []string
+	// Notes are the identifiers (DOIs, ISBNs...) of keys, noted in
+	// comments, e.g., '% smith2020: 10.1000/xyz', by key.
+	Notes map[string]string
+}
+
+var (
+	// command matches a command name, without the backslash.
+	command = regexp.MustCompile(`^[A-Za-z@]+\*?`)
+
+	// note matches a 'key: identifier' or 'key = identifier' comment.
+	note = regexp.MustCompile(`^\s*([^\s:=,{}%]+)\s*[:=]\s*(\S+)\s*$`)
+)
+
+// notCite are commands which contain 'cite' but do not cite anything.
+var notCite = map[string]bool{
+	"citestyle":        true,
+	"citesetup":        true,
+	"citetrackerfalse": true,
+	"citetrackertrue":  true,
+}
+
+// Scan scans the named LaTeX document (.tex) or auxiliary file (.aux),
+// including the files it inputs, for citations, i.e., \cite and its
+// natbib and biblatex variants, \nocite and \citation, and bibliography
+// files, i.e., \bibliography, \addbibresource and \bibdata. Included
+// files are resolved relative to the directory of the named file.
+func Scan(name string) (Document, error) {
+	s := &scanner{
+		dir:   filepath.Dir(name),
+		seen:  make(map[string]bool),
+		keys:  make(map[string]bool),
+		bibs:  make(map[string]bool),
+		notes: make(map[string]string),
+	}
+	if err := s.file(name); err != nil {
+		return Document{}, err
+	}
+	s.doc.Notes = s.notes
+	return s.doc, nil
+}
+
+// scanner holds the state of a scan.
+type scanner struct {
+	dir   string
+	doc   Document
+	seen  map[string]bool // scanned files
+	keys  map[string]bool
+	bibs  map[string]bool
+	notes map[string]string
+}
+
+// file scans the named file, unless it has already been scanned.
+func (s *scanner) file(name string) error {
+	if s.seen[filepath.Clean(name)] {
+		return nil
+	}
+	s.seen[filepath.Clean(name)] = true
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	return s.text(string(data))
+}
+
+// text scans the text of a file, ignoring comments, which are only
+// scanned for notes.
+func (s *scanner) text(text string) error {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		code, comment := splitComment(line)
+		if m := note.FindStringSubmatch(comment); m != nil {
+			if _, found := s.notes[m[1]]; !found {
+				s.notes[m[1]] = m[2]
+			}
+		}
+		b.WriteString(code + "\n")
+	}
+	return s.code(b.String())
+}
+
+// code scans LaTeX code for commands.
+func (s *scanner) code(code string) error {
+	for {
+		i := strings.IndexByte(code, '\\')
+		if i < 0 {
+			return nil
+		}
+		code = code[i+1:]
+		name := command.FindString(code)
+		if len(name) == 0 {
+			// skip escaped characters, e.g., '\\' or '\{'
+			if len(code) != 0 {
+				code = code[1:]
+			}
+			continue
+		}
+		code = code[len(name):]
+		name = strings.TrimSuffix(name, "*")
+
+		var args []string
+		switch {
+		case name == "citation" || name == "bibdata" || name == "bibliography" ||
+			name == "addbibresource" || name == "addglobalbib" || name == "input" ||
+			name == "include" || name == "@input" || name == "subfile":
+			args, code = arguments(code, false)
+		case name == "abx@aux@cite":
+			// biblatex: \abx@aux@cite{key} or \abx@aux@cite{refsection}{key}
+			args, code = arguments(code, true)
+			if len(args) > 1 {
+				args = args[len(args)-1:]
+			}
+		case strings.Contains(strings.ToLower(name), "cite") && !notCite[name]:
+			// multicite commands, e.g., \autocites, take several key lists
+			args, code = arguments(code, strings.HasSuffix(name, "cites"))
+		default:
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		switch name {
+		case "bibdata", "bibliography":
+			for _, b := range splitList(args[0]) {
+				if filepath.Ext(b) != ".bib" {
+					b += ".bib"
+				}
+				s.addBib(b)
+			}
+		case "addbibresource", "addglobalbib":
+			s.addBib(args[0])
+		case "input", "include", "subfile":
+			in := s.path(strings.TrimSpace(args[0]))
+			if len(filepath.Ext(in)) == 0 {
+				in += ".tex"
+			}
+			if err := s.file(in); err != nil {
+				return err
+			}
+		case "@input":
+			// auxiliary files of included files may not exist yet
+			if err := s.file(s.path(args[0])); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		default:
+			for _, a := range args {
+				for _, k := range splitList(a) {
+					s.addKey(k)
+				}
+			}
+		}
+	}
+}
+
+// addKey adds a cited key, unless it was already added. The '*' key,
+// i.e., \nocite{*}, is ignored.
+func (s *scanner) addKey(k string) {
+	if len(k) == 0 || k == "*" || s.keys[k] {
+		return
+	}
+	s.keys[k] = true
+	s.doc.Keys = append(s.doc.Keys, k)
+}
+
+// addBib adds a bibliography file, unless it was already added.
+func (s *scanner) addBib(b string) {
+	b = s.path(strings.TrimSpace(b))
+	if len(b) == 0 || s.bibs[b] {
+		return
+	}
+	s.bibs[b] = true
+	s.doc.Bibs = append(s.doc.Bibs, b)
+}
+
+// path returns the path of a file named in the document.
+func (s *scanner) path(name string) string {
+	if len(name) == 0 || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(s.dir, name)
+}
+
+// splitComment splits a line at the first unescaped '%', into code and
+// comment.
+func splitComment(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '%':
+			return line[:i], line[i+1:]
+		}
+	}
+	return line, ""
+}
+
+// arguments parses the mandatory ('{...}') arguments following a command,
+// skipping optional ('[...]' and, for multicite commands, '(...)')
+// arguments, and returns them and the remaining code. Only the first
+// mandatory argument is parsed, unless all is set.
+func arguments(code string, all bool) ([]string, string) {
+	var args []string
+	for {
+		code = strings.TrimLeft(code, " \t\r\n")
+		if len(code) == 0 {
+			return args, code
+		}
+		var open, close byte
+		switch code[0] {
+		case '[':
+			open, close = '[', ']'
+		case '(':
+			if !all {
+				return args, code
+			}
+			open, close = '(', ')'
+		case '{':
+			open, close = '{', '}'
+		default:
+			return args, code
+		}
+		depth, end := 0, -1
+	group:
+		for i := 0; i < len(code); i++ {
+			switch code[i] {
+			case '\\':
+				i++
+			case open:
+				depth++
+			case close:
+				if depth--; depth == 0 {
+					end = i
+					break group
+				}
+			}
+		}
+		if end < 0 {
+			return args, ""
+		}
+		if open == '{' {
+			args = append(args, code[1:end])
+		}
+		code = code[end+1:]
+		if open == '{' && !all {
+			return args, code
+		}
+	}
+}
+
+// splitList splits a comma separated list, trimming white space.
+func splitList(s string) []string {
+	var ss []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); len(v) != 0 {
+			ss = append(ss, v)
+		}
+	}
+	return ss
+}
@@ -0,0 +1,64 @@
+package latex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testMain = `\documentclass{article}
+\usepackage[style=authoryear]{biblatex}
+\addbibresource{refs.bib}
+\begin{document}
+As shown by \textcite{smith2020}, and others \citep[see][p.~3]{10.1000/xyz, arXiv:2101.01234}.
+% \cite{commented}
+% smith2020: 10.1000/smith
+100\% \autocites(see)()[12]{a}[13]{b} \nocite{*}
+\input{chapter}
+\bibliography{more,other.bib}
+\end{document}
+`
+
+const testChapter = `\section{Chapter}
+\cite*{smith2020,
+  9780306406157}\citestyle{nature}
+`
+
+const testAux = `\relax
+\citation{aux1}
+\abx@aux@cite{0}{aux2}
+\@input{missing.aux}
+\bibdata{refs}
+`
+
+func TestScan(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"main.tex":    testMain,
+		"chapter.tex": testChapter,
+		"main.aux":    testAux,
+	} {
+		assert.Nil(os.WriteFile(filepath.Join(dir, name), []byte(data), 0666))
+	}
+
+	doc, err := Scan(filepath.Join(dir, "main.tex"))
+	assert.Nil(err)
+	assert.Equal([]string{"smith2020", "10.1000/xyz", "arXiv:2101.01234", "a", "b", "9780306406157"}, doc.Keys)
+	assert.Equal([]string{
+		filepath.Join(dir, "refs.bib"),
+		filepath.Join(dir, "more.bib"),
+		filepath.Join(dir, "other.bib"),
+	}, doc.Bibs)
+	assert.Equal("10.1000/smith", doc.Notes["smith2020"])
+
+	doc, err = Scan(filepath.Join(dir, "main.aux"))
+	assert.Nil(err)
+	assert.Equal([]string{"aux1", "aux2"}, doc.Keys)
+	assert.Equal([]string{filepath.Join(dir, "refs.bib")}, doc.Bibs)
+
+	_, err = Scan(filepath.Join(dir, "missing.tex"))
+	assert.NotNil(err)
+}
@@ -0,0 +1,298 @@
+package library
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/Milover/fetchref/internal/outfile"
+)
+
+// ErrNotFound is returned if no record matches a reference.
+var ErrNotFound = errors.New("no such record")
+
+// LockTimeout is the maximum time Lock waits for a lock held by another
+// process.
+var LockTimeout = 10 * time.Second
+
+// staleLock is the age after which a lock is considered abandoned.
+const staleLock = time.Minute
+
+// File is a file belonging to a record, i.e., a downloaded document.
+type File struct {
+	Path    string `json:"path"` // absolute path
+	SHA256  string `json:"sha256,omitempty"`
+	Size    int64  `json:"size,omitempty"`
+	Source  string `json:"source,omitempty"`
+	URL     string `json:"url,omitempty"`
+	License string `json:"license,omitempty"`
+}
+
+// Record is a library record of a single work.
+type Record struct {
+	ID         string         `json:"id"`
+	Handle     string         `json:"handle"`
+	HandleType string         `json:"handle_type"`
+	DOI        string         `json:"doi,omitempty"`
+	Title      string         `json:"title,omitempty"`
+	Files      []File         `json:"files,omitempty"`
+	Citations  []string       `json:"citations,omitempty"` // absolute paths
+	Tags       []string       `json:"tags,omitempty"`
+	Meta       *crossref.Work `json:"metadata,omitempty"`
+	Added      time.Time      `json:"added"`
+	Updated    time.Time      `json:"updated"`
+}
+
+// RecordID returns the ID of a record, i.e., 'doi:<doi>', with the DOI
+// in lower case, if the DOI is known, or '<handle type>:<handle>' otherwise.
+func RecordID(handleType, handle, doi string) string {
+	if len(doi) != 0 {
+		return "doi:" + strings.ToLower(doi)
+	}
+	return handleType + ":" + handle
+}
+
+// Year returns the year of publication of the work, or 0 if unknown.
+func (r Record) Year() int {
+	if r.Meta == nil {
+		return 0
+	}
+	return r.Meta.Year()
+}
+
+// HasTag reports whether the record is tagged with the tag.
+func (r Record) HasTag(tag string) bool {
+	for _, t := range r.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Manifest is the library manifest, i.e., the set of library records,
+// stored in a JSON Lines file, one record per line.
+type Manifest struct {
+	path    string
+	records map[string]*Record
+}
+
+// DefaultPath returns the default manifest file path, i.e.,
+// 'fetchref/library.jsonl' in the user's configuration directory,
+// or an empty string if the directory cannot be determined.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "fetchref", "library.jsonl")
+}
+
+// Lock acquires an advisory lock on the manifest file, i.e., creates
+// '<path>.lock' exclusively, waiting for up to LockTimeout if the lock is
+// held by another process. Locks older than a minute are considered
+// abandoned and are taken over. The returned function releases the lock.
+func Lock(path string) (func() error, error) {
+	lock := path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lock), 0777); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(LockTimeout)
+	for {
+		f, err := os.OpenFile(lock, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+		if err == nil {
+			fmt.Fprintln(f, os.Getpid())
+			if err := f.Close(); err != nil {
+				os.Remove(lock)
+				return nil, err
+			}
+			return func() error { return os.Remove(lock) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		if fi, err := os.Stat(lock); err == nil && time.Since(fi.ModTime()) > staleLock {
+			os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%v: locked by another process", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Load reads the manifest from the file. A missing file yields
+// an empty manifest.
+func Load(path string) (*Manifest, error) {
+	m := &Manifest{path: path, records: make(map[string]*Record)}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	s.Buffer(nil, 64<<20)
+	for n := 1; s.Scan(); n++ {
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(line, &r); err != nil {
+			return nil, fmt.Errorf("%v:%d: %w", path, n, err)
+		}
+		m.records[r.ID] = &r
+	}
+	return m, s.Err()
+}
+
+// Save writes the manifest to its file atomically, records are ordered
+// by the time they were added.
+func (m *Manifest) Save() error {
+	var b bytes.Buffer
+	for _, r := range m.Records() {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	return outfile.WriteFile(m.path, b.Bytes(), 0666)
+}
+
+// Records returns all records, ordered by the time they were added.
+func (m *Manifest) Records() []*Record {
+	rs := make([]*Record, 0, len(m.records))
+	for _, r := range m.records {
+		rs = append(rs, r)
+	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		if !rs[i].Added.Equal(rs[j].Added) {
+			return rs[i].Added.Before(rs[j].Added)
+		}
+		return rs[i].ID < rs[j].ID
+	})
+	return rs
+}
+
+// Find returns the record matching the reference, i.e., a record ID,
+// DOI, handle or the path of one of the record's files. If several records
+// match by handle or path, the one added first is returned.
+func (m *Manifest) Find(ref string) (*Record, error) {
+	if r, found := m.records[ref]; found {
+		return r, nil
+	}
+	if r, found := m.records["doi:"+strings.ToLower(ref)]; found {
+		return r, nil
+	}
+	// in a fixed order, so that the same record is found every time
+	abs, _ := filepath.Abs(ref)
+	for _, r := range m.Records() {
+		if r.Handle == ref {
+			return r, nil
+		}
+		for _, f := range r.Files {
+			if f.Path == abs {
+				return r, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%v: %w", ref, ErrNotFound)
+}
+
+// Put adds the record to the manifest, or merges it into an existing record
+// with the same ID: metadata is replaced, if set, while files, citations
+// and tags are added.
+func (m *Manifest) Put(r Record) *Record {
+	now := time.Now().UTC().Truncate(time.Second)
+	old, found := m.records[r.ID]
+	if !found {
+		r.Added, r.Updated = now, now
+		m.records[r.ID] = &r
+		return &r
+	}
+	if len(r.DOI) != 0 {
+		old.DOI = r.DOI
+	}
+	if len(r.Title) != 0 {
+		old.Title = r.Title
+	}
+	if r.Meta != nil {
+		old.Meta = r.Meta
+	}
+	for _, f := range r.Files {
+		old.AddFile(f)
+	}
+	for _, c := range r.Citations {
+		old.Citations = appendUnique(old.Citations, c)
+	}
+	for _, t := range r.Tags {
+		old.Tags = appendUnique(old.Tags, t)
+	}
+	old.Updated = now
+	return old
+}
+
+// Remove removes the record from the manifest.
+func (m *Manifest) Remove(r *Record) {
+	delete(m.records, r.ID)
+}
+
+// AddFile adds the file to the record, or replaces the file with the same
+// path.
+func (r *Record) AddFile(f File) {
+	for i := range r.Files {
+		if r.Files[i].Path == f.Path {
+			r.Files[i] = f
+			return
+		}
+	}
+	r.Files = append(r.Files, f)
+}
+
+// Tag adds the tags to the record.
+func (r *Record) Tag(tags ...string) {
+	for _, t := range tags {
+		r.Tags = appendUnique(r.Tags, t)
+	}
+	r.Updated = time.Now().UTC().Truncate(time.Second)
+}
+
+// Untag removes the tags from the record.
+func (r *Record) Untag(tags ...string) {
+	kept := r.Tags[:0]
+	for _, t := range r.Tags {
+		remove := false
+		for _, u := range tags {
+			remove = remove || t == u
+		}
+		if !remove {
+			kept = append(kept, t)
+		}
+	}
+	r.Tags = kept
+	r.Updated = time.Now().UTC().Truncate(time.Second)
+}
+
+// appendUnique appends s to ss, unless ss already contains it.
+func appendUnique(ss []string, s string) []string {
+	for _, e := range ss {
+		if e == s {
+			return ss
+		}
+	}
+	return append(ss, s)
+}
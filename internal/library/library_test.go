@@ -0,0 +1,116 @@
+package library
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifest(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "library.jsonl")
+
+	m, err := Load(path)
+	assert.Nil(err)
+	assert.Empty(m.Records())
+
+	doc := filepath.Join(t.TempDir(), "article.pdf")
+	m.Put(Record{
+		ID:         RecordID("doi", "10.1000/ABC", "10.1000/ABC"),
+		Handle:     "10.1000/ABC",
+		HandleType: "doi",
+		DOI:        "10.1000/ABC",
+		Title:      "A Title",
+		Files:      []File{{Path: doc, SHA256: "00"}},
+	})
+	m.Put(Record{
+		ID:         RecordID("isbn", "9780262033848", ""),
+		Handle:     "9780262033848",
+		HandleType: "isbn",
+	})
+	// merged into the existing record
+	r := m.Put(Record{
+		ID:        "doi:10.1000/abc",
+		Files:     []File{{Path: doc, SHA256: "11"}},
+		Citations: []string{"/tmp/citations.bib"},
+		Tags:      []string{"reading"},
+	})
+	assert.Equal("A Title", r.Title)
+	assert.Len(r.Files, 1)
+	assert.Equal("11", r.Files[0].SHA256)
+	assert.Nil(m.Save())
+
+	m, err = Load(path)
+	assert.Nil(err)
+	assert.Len(m.Records(), 2)
+	for _, ref := range []string{"doi:10.1000/abc", "10.1000/ABC", "10.1000/abc", doc} {
+		r, err := m.Find(ref)
+		if assert.Nil(err, ref) {
+			assert.Equal("doi:10.1000/abc", r.ID, ref)
+			assert.True(r.HasTag("reading"), ref)
+		}
+	}
+	r, err = m.Find("9780262033848")
+	assert.Nil(err)
+	assert.Equal("isbn:9780262033848", r.ID)
+
+	r.Tag("book", "book")
+	assert.Equal([]string{"book"}, r.Tags)
+	r.Untag("book")
+	assert.Empty(r.Tags)
+
+	m.Remove(r)
+	_, err = m.Find("9780262033848")
+	assert.True(errors.Is(err, ErrNotFound))
+}
+
+func TestLock(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "fetchref", "library.jsonl")
+	defer func(d time.Duration) { LockTimeout = d }(LockTimeout)
+	LockTimeout = 100 * time.Millisecond
+
+	unlock, err := Lock(path)
+	if !assert.Nil(err) {
+		return
+	}
+	_, err = Lock(path)
+	assert.NotNil(err)
+	assert.Nil(unlock())
+	assert.NoFileExists(path + ".lock")
+
+	// abandoned locks are taken over
+	assert.Nil(os.WriteFile(path+".lock", nil, 0666))
+	old := time.Now().Add(-2 * staleLock)
+	assert.Nil(os.Chtimes(path+".lock", old, old))
+	unlock, err = Lock(path)
+	if assert.Nil(err) {
+		assert.Nil(unlock())
+	}
+}
+
+func TestFindOrder(t *testing.T) {
+	assert := assert.New(t)
+	m, err := Load(filepath.Join(t.TempDir(), "library.jsonl"))
+	if !assert.Nil(err) {
+		return
+	}
+	doc := filepath.Join(t.TempDir(), "article.pdf")
+	for _, id := range []string{"pmid:1", "isbn:1", "doi:10.1000/1", "pmcid:1"} {
+		r := m.Put(Record{ID: id, Handle: "1", Files: []File{{Path: doc}}})
+		r.Added = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	// records added at the same time are ordered by ID
+	for i := 0; i < 20; i++ {
+		for _, ref := range []string{"1", doc} {
+			r, err := m.Find(ref)
+			if assert.Nil(err) {
+				assert.Equal("doi:10.1000/1", r.ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,273 @@
+// Package markup scans Markdown (Pandoc), Typst and Org documents for
+// citations.
+package markup
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Syntax is the markup syntax of a document.
+type Syntax int
+
+const (
+	Markdown Syntax = iota
+	Typst
+	Org
+)
+
+// Detect detects the syntax of the named document from its file name
+// extension.
+func Detect(name string) (Syntax, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown", ".pandoc", ".qmd", ".rmd":
+		return Markdown, nil
+	case ".typ":
+		return Typst, nil
+	case ".org":
+		return Org, nil
+	}
+	return 0, fmt.Errorf("%v: unknown document syntax, expected Markdown (.md), Typst (.typ) or Org (.org)", name)
+}
+
+// Citation is a citation of a key, which is at [Start:End] of the text.
+type Citation struct {
+	Key        string
+	Start, End int
+}
+
+var (
+	// typstCite matches the key of a Typst cite function call, i.e.,
+	// 'cite(<key>)' or 'cite(label("key"))'.
+	typstCite = regexp.MustCompile(`cite\(\s*(?:<([^>\s]+)>|label\(\s*"([^"]+)"\s*\))`)
+
+	// orgCite matches the references of an Org citation, e.g.,
+	// '[cite/t:see @key1; @key2 p. 3]'.
+	orgCite = regexp.MustCompile(`\[cite(?:/[^:\]]*)?:([^\]]*)\]`)
+
+	// orgKey matches a key of an Org citation.
+	orgKey = regexp.MustCompile(`@[^\s;\]]+`)
+
+	// orgBlock matches the start or end of an Org source, example or
+	// verbatim block.
+	orgBlock = regexp.MustCompile(`(?i)^\s*#\+(begin|end)_(src|example|verbatim|export)\b`)
+)
+
+// Scan returns the citations of the text, in order, i.e., Pandoc citations,
+// e.g., '[@key]', '@key' or '@{key}', for Markdown, references, e.g.,
+// '@key', and 'cite' function calls, e.g., '#cite(<key>)' or
+// '#cite(label("key"))', for Typst, and Org citations, e.g.,
+// '[cite:@key1;@key2]', for Org. Code, raw text and comments are not
+// scanned.
+func Scan(text string, s Syntax) []Citation {
+	var cs []Citation
+	masked := mask(text, s)
+	switch s {
+	case Typst:
+		cs = scanAt(masked, func(r rune) bool {
+			return isWord(r) || r == '-' || r == '.' || r == ':'
+		})
+		for _, m := range typstCite.FindAllStringSubmatchIndex(masked, -1) {
+			i := 2
+			if m[2] < 0 {
+				i = 4
+			}
+			cs = append(cs, Citation{Key: text[m[i]:m[i+1]], Start: m[i], End: m[i+1]})
+		}
+	case Org:
+		for _, m := range orgCite.FindAllStringSubmatchIndex(masked, -1) {
+			for _, k := range orgKey.FindAllStringIndex(masked[m[2]:m[3]], -1) {
+				start, end := m[2]+k[0]+1, m[2]+k[1]
+				end = trimPunct(text, start, end)
+				if end > start {
+					cs = append(cs, Citation{Key: text[start:end], Start: start, End: end})
+				}
+			}
+		}
+	default:
+		cs = scanAt(masked, func(r rune) bool {
+			return isWord(r) || strings.ContainsRune(":.#$%&-+?<>~/", r)
+		})
+	}
+	sort.SliceStable(cs, func(i, j int) bool { return cs[i].Start < cs[j].Start })
+	return cs
+}
+
+// Rewrite replaces the cited keys in the text by their replacements, if any.
+// The citations are those returned by Scan.
+func Rewrite(text string, cs []Citation, keys map[string]string) string {
+	var b strings.Builder
+	last := 0
+	for _, c := range cs {
+		k, found := keys[c.Key]
+		if !found || c.Start < last {
+			continue
+		}
+		b.WriteString(text[last:c.Start])
+		b.WriteString(k)
+		last = c.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// scanAt returns the '@key' and '@{key}' citations of the text, where
+// keys begin with a letter, a digit or '_', and consist of runes for
+// which keyRune is true, apart from trailing punctuation. An '@' preceded
+// by a letter, a digit or a backslash, e.g., in an e-mail address, is not
+// a citation.
+func scanAt(text string, keyRune func(rune) bool) []Citation {
+	var cs []Citation
+	for i := 0; i < len(text); i++ {
+		if text[i] != '@' {
+			continue
+		}
+		if prev, _ := utf8.DecodeLastRuneInString(text[:i]); i > 0 && (isWord(prev) || prev == '\\') {
+			continue
+		}
+		start := i + 1
+		if strings.HasPrefix(text[start:], "{") {
+			if end := strings.IndexByte(text[start:], '}'); end > 1 {
+				cs = append(cs, Citation{Key: text[start+1 : start+end], Start: start + 1, End: start + end})
+				i = start + end
+			}
+			continue
+		}
+		end := start
+		for end < len(text) {
+			r, n := utf8.DecodeRuneInString(text[end:])
+			if !keyRune(r) || (end == start && !isWord(r)) {
+				break
+			}
+			end += n
+		}
+		if end = trimPunct(text, start, end); end > start {
+			cs = append(cs, Citation{Key: text[start:end], Start: start, End: end})
+			i = end - 1
+		}
+	}
+	return cs
+}
+
+// trimPunct returns the end of text[start:end] without trailing
+// punctuation, i.e., runes which are not letters, digits or '_'.
+func trimPunct(text string, start, end int) int {
+	for end > start {
+		r, n := utf8.DecodeLastRuneInString(text[start:end])
+		if isWord(r) {
+			break
+		}
+		end -= n
+	}
+	return end
+}
+
+// isWord reports whether r is a letter, a digit or '_'.
+func isWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// mask returns the text with code, raw text and comments replaced by
+// spaces, retaining line breaks and byte offsets.
+func mask(text string, s Syntax) string {
+	b := []byte(text)
+	blank := func(start, end int) {
+		for i := start; i < end; i++ {
+			if b[i] != '\n' {
+				b[i] = ' '
+			}
+		}
+	}
+	if s == Org {
+		block := false
+		off := 0
+		for _, line := range strings.SplitAfter(text, "\n") {
+			trimmed := strings.TrimSpace(line)
+			m := orgBlock.FindStringSubmatch(line)
+			switch {
+			case m != nil:
+				block = strings.EqualFold(m[1], "begin")
+				blank(off, off+len(line))
+			case block, trimmed == "#", strings.HasPrefix(trimmed, "# "),
+				strings.HasPrefix(trimmed, "#+"):
+				blank(off, off+len(line))
+			}
+			off += len(line)
+		}
+		return string(b)
+	}
+
+	for i := 0; i < len(b); i++ {
+		switch {
+		case b[i] == '`':
+			// code spans, raw text and fenced code blocks end with
+			// a backtick run of the same length
+			n := 1
+			for i+n < len(b) && b[i+n] == '`' {
+				n++
+			}
+			fence := strings.Repeat("`", n)
+			end := i + n
+			for {
+				j := strings.Index(text[end:], fence)
+				if j < 0 {
+					end = -1
+					break
+				}
+				end += j
+				m := len(fence)
+				for end+m < len(b) && b[end+m] == '`' {
+					m++
+				}
+				if m == n {
+					end += n
+					break
+				}
+				end += m
+			}
+			if end < 0 {
+				i += n - 1
+				continue
+			}
+			blank(i, end)
+			i = end - 1
+		case s == Markdown && strings.HasPrefix(text[i:], "<!--"):
+			end := strings.Index(text[i:], "-->")
+			if end < 0 {
+				end = len(b) - i - 3
+			}
+			blank(i, i+end+3)
+			i += end + 2
+		case s == Markdown && b[i] == '~' && (i == 0 || b[i-1] == '\n') &&
+			strings.HasPrefix(text[i:], "~~~"):
+			end := strings.Index(text[i+3:], "\n~~~")
+			if end < 0 {
+				end = len(b) - i - 3
+			} else {
+				end += 4
+			}
+			blank(i, i+3+end)
+			i += 2 + end
+		case s == Typst && strings.HasPrefix(text[i:], "//") && (i == 0 || b[i-1] != ':'):
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				end = len(b) - i
+			}
+			blank(i, i+end)
+			i += end
+		case s == Typst && strings.HasPrefix(text[i:], "/*"):
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				end = len(b) - i - 4
+			}
+			blank(i, i+end+4)
+			i += end + 3
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,85 @@
+package markup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScan(t *testing.T) {
+	var tests = []struct {
+		Name   string
+		Syntax Syntax
+		Text   string
+		Keys   []string
+	}{
+		{
+			Name:   "pandoc",
+			Syntax: Markdown,
+			Text: "As shown [see @doi:10.1000/xyz, p. 3; -@smith2020].\n" +
+				"@{10.1000/a b} says so, mail me@example.com or \\@escaped.\n" +
+				"Ends with @arXiv:2101.01234.\n",
+			Keys: []string{"doi:10.1000/xyz", "smith2020", "10.1000/a b", "arXiv:2101.01234"},
+		},
+		{
+			Name:   "pandoc-code",
+			Syntax: Markdown,
+			Text: "Code `@inline` and ``@a ` b``.\n" +
+				"```python\n@decorator\n```\n" +
+				"~~~\n@tilde\n~~~\n" +
+				"<!-- [@comment] -->\n[@real]\n",
+			Keys: []string{"real"},
+		},
+		{
+			Name:   "typst",
+			Syntax: Typst,
+			Text: "See @arXiv:2101.01234. and #cite(<smith2020>), " +
+				"#cite(label(\"10.1000/xyz\"), form: \"prose\").\n" +
+				"// @commented\n/* #cite(<block>) */ https://example.com/@user `@raw`\n",
+			Keys: []string{"arXiv:2101.01234", "smith2020", "10.1000/xyz", "user"},
+		},
+		{
+			Name:   "org",
+			Syntax: Org,
+			Text: "As shown [cite/t:see @doi:10.1000/xyz p. 3;@smith2020].\n" +
+				"# [cite:@commented]\n" +
+				"#+begin_src python\n[cite:@src]\n#+end_src\n" +
+				"[cite:@isbn:9780306406157.]\n",
+			Keys: []string{"doi:10.1000/xyz", "smith2020", "isbn:9780306406157"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			var keys []string
+			for _, c := range Scan(tt.Text, tt.Syntax) {
+				assert.Equal(t, c.Key, tt.Text[c.Start:c.End])
+				keys = append(keys, c.Key)
+			}
+			assert.Equal(t, tt.Keys, keys)
+		})
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	assert := assert.New(t)
+	text := "[@doi:10.1000/xyz; @smith2020] and @{doi:10.1000/xyz}."
+	out := Rewrite(text, Scan(text, Markdown), map[string]string{
+		"doi:10.1000/xyz": "Doe_2020",
+	})
+	assert.Equal("[@Doe_2020; @smith2020] and @{Doe_2020}.", out)
+}
+
+func TestDetect(t *testing.T) {
+	assert := assert.New(t)
+	for name, s := range map[string]Syntax{
+		"notes.md":  Markdown,
+		"paper.typ": Typst,
+		"todo.ORG":  Org,
+	} {
+		got, err := Detect(name)
+		assert.Nil(err, name)
+		assert.Equal(s, got, name)
+	}
+	_, err := Detect("notes.txt")
+	assert.NotNil(err)
+}
@@ -0,0 +1,55 @@
+package outfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReserve(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	name := filepath.Join(dir, "article")
+	assert.Nil(WriteFile(name+".pdf", []byte("old"), 0666))
+
+	var tests = []struct {
+		Policy Policy
+		Output string
+		Error  error
+	}{
+		{Policy: Overwrite, Output: name + ".pdf"},
+		{Policy: Skip, Error: ErrExists},
+		{Policy: Suffix, Output: name + "-1.pdf"},
+		{Policy: Suffix, Output: name + "-2.pdf"}, // -1 is reserved
+		{Policy: Hash, Output: name + "-" + HashKey("10.1000/1") + ".pdf"},
+	}
+	for _, tt := range tests {
+		out, err := tt.Policy.Reserve(name, ".pdf", "10.1000/1")
+		assert.True(errors.Is(err, tt.Error), tt.Policy.String())
+		assert.Equal(tt.Output, out, tt.Policy.String())
+		if err == nil && tt.Policy != Suffix {
+			Release(out)
+		}
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	assert := assert.New(t)
+	name := filepath.Join(t.TempDir(), "citations.bib")
+
+	assert.Nil(AppendFile(name, []byte("a\n"), 0666))
+	assert.Nil(AppendFile(name, []byte("b\n"), 0666))
+	data, _ := os.ReadFile(name)
+	assert.Equal("a\nb\n", string(data))
+
+	assert.Nil(WriteFile(name, []byte("c\n"), 0666))
+	data, _ = os.ReadFile(name)
+	assert.Equal("c\n", string(data))
+
+	// no temporary files are left behind
+	entries, _ := os.ReadDir(filepath.Dir(name))
+	assert.Len(entries, 1)
+}
@@ -0,0 +1,121 @@
+package outfile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+var (
+	ErrBadPolicy = fmt.Errorf(
+		"unknown conflict policy, available policies are: %q",
+		names)
+
+	// ErrExists is returned by Policy.Reserve if the file exists, and the
+	// Policy is Skip.
+	ErrExists = errors.New("file exists")
+)
+
+var (
+	// names are the user-friendly Policy names.
+	names = [...]string{
+		"overwrite",
+		"skip",
+		"suffix",
+		"hash",
+	}
+
+	// reserved are the file names reserved by Policy.Reserve, which are
+	// treated as existing, so that concurrent writers do not collide.
+	reserved   = make(map[string]bool)
+	reservedMu sync.Mutex
+)
+
+// Policy is the policy for resolving conflicts with existing files,
+// i.e., for choosing the name of an output file which already exists.
+type Policy int
+
+// Available conflict policies.
+const (
+	Overwrite Policy = iota // overwrite the existing file
+	Skip                    // keep the existing file, do not write
+	Suffix                  // append the first free numeric suffix, e.g., '-1'
+	Hash                    // append a hash of the key, e.g., the DOI
+)
+
+// HashLen is the number of hex digits of the hash appended by Hash.
+const HashLen = 8
+
+// Set sets the value of the policy based on the provided policy name.
+func (p *Policy) Set(name string) error {
+	for i, n := range names {
+		if name == n {
+			*p = Policy(i)
+			return nil
+		}
+	}
+	return ErrBadPolicy
+}
+
+// String returns the Policy (name) as a user-friendly string.
+func (p Policy) String() string {
+	return names[p]
+}
+
+// Type returns the type used by Policy.Set.
+func (p Policy) Type() string {
+	return "string"
+}
+
+// Reserve chooses the name of the output file with the base name and the
+// extension ext, e.g., '.pdf', according to the policy, and reserves it
+// until it is released by Release.
+// The key identifies the file content, e.g., by a DOI, and is used by Hash,
+// if the key hash is taken as well, the file is assumed to have the same
+// content, and is overwritten.
+// ErrExists is returned if the file exists and the policy is Skip.
+func (p Policy) Reserve(name, ext, key string) (string, error) {
+	reservedMu.Lock()
+	defer reservedMu.Unlock()
+
+	out := name + ext
+	if taken(out) {
+		switch p {
+		case Skip:
+			return "", fmt.Errorf("%v: %w", out, ErrExists)
+		case Suffix:
+			for i := 1; taken(out); i++ {
+				out = fmt.Sprintf("%v-%d%v", name, i, ext)
+			}
+		case Hash:
+			out = name + "-" + HashKey(key) + ext
+		}
+	}
+	reserved[out] = true
+	return out, nil
+}
+
+// taken reports whether the file exists, or has been reserved.
+func taken(name string) bool {
+	if reserved[name] {
+		return true
+	}
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// Release releases a file name reserved by Policy.Reserve.
+func Release(name string) {
+	reservedMu.Lock()
+	defer reservedMu.Unlock()
+	delete(reserved, name)
+}
+
+// HashKey returns the (shortened) hex SHA-256 hash of the key.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:HashLen]
+}
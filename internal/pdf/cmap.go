@@ -0,0 +1,141 @@
+package pdf
+
+import (
+	"sort"
+	"unicode/utf16"
+)
+
+// codeRange is a code space range of a CMap, i.e., the range of valid
+// character codes of a particular length.
+type codeRange struct {
+	lo, hi []byte
+}
+
+// contains reports whether the code lies within the range.
+func (r codeRange) contains(code []byte) bool {
+	if len(code) != len(r.lo) {
+		return false
+	}
+	for i := range code {
+		if code[i] < r.lo[i] || code[i] > r.hi[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// cmap maps character codes to Unicode text, as described by a ToUnicode
+// CMap, see PDF 32000-1:2008, 9.10.3.
+type cmap struct {
+	space []codeRange
+	chars map[string]string
+}
+
+// parseCMap parses a ToUnicode CMap. Malformed entries are ignored.
+func parseCMap(data []byte) *cmap {
+	c := &cmap{chars: make(map[string]string)}
+	operations(data, func(op string, args []Object) bool {
+		switch op {
+		case "endcodespacerange":
+			for i := 0; i+1 < len(args); i += 2 {
+				lo, ok1 := args[i].(String)
+				hi, ok2 := args[i+1].(String)
+				if ok1 && ok2 && len(lo) == len(hi) && len(lo) > 0 {
+					c.space = append(c.space, codeRange{lo: lo, hi: hi})
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(args); i += 2 {
+				src, ok1 := args[i].(String)
+				dst, ok2 := args[i+1].(String)
+				if ok1 && ok2 {
+					c.chars[string(src)] = utf16BE(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(args); i += 3 {
+				lo, ok1 := args[i].(String)
+				hi, ok2 := args[i+1].(String)
+				if !ok1 || !ok2 || len(lo) != len(hi) || len(lo) == 0 {
+					continue
+				}
+				c.addRange(lo, hi, args[i+2])
+			}
+		}
+		return true
+	})
+	// longer codes are matched last
+	sort.SliceStable(c.space, func(i, j int) bool {
+		return len(c.space[i].lo) < len(c.space[j].lo)
+	})
+	return c
+}
+
+// maxRange is the maximum number of codes of a bfrange entry.
+const maxRange = 1 << 16
+
+// addRange adds a bfrange entry, mapping codes lo through hi, which may
+// differ only in the last byte, to consecutive characters starting from
+// dst, or to the strings of the array dst.
+func (c *cmap) addRange(lo, hi String, dst Object) {
+	n := len(lo) - 1
+	for i := 0; i < n; i++ {
+		if lo[i] != hi[i] {
+			return
+		}
+	}
+	code := append(String(nil), lo...)
+	for b, k := int(lo[n]), 0; b <= int(hi[n]) && k < maxRange; b, k = b+1, k+1 {
+		code[n] = byte(b)
+		switch d := dst.(type) {
+		case String:
+			s := append(String(nil), d...)
+			if len(s) > 0 {
+				s[len(s)-1] += byte(k)
+			}
+			c.chars[string(code)] = utf16BE(s)
+		case Array:
+			if k < len(d) {
+				if s, ok := d[k].(String); ok {
+					c.chars[string(code)] = utf16BE(s)
+				}
+			}
+		}
+	}
+}
+
+// codeLen returns the length of the character code at the start of s.
+func (c *cmap) codeLen(s []byte) int {
+	for _, r := range c.space {
+		if len(r.lo) <= len(s) && r.contains(s[:len(r.lo)]) {
+			return len(r.lo)
+		}
+	}
+	if len(c.space) != 0 {
+		return len(c.space[0].lo)
+	}
+	return 1
+}
+
+// decode maps the character codes of s to text. Codes without a mapping
+// are dropped.
+func (c *cmap) decode(s String) string {
+	var b []rune
+	for len(s) > 0 {
+		n := minInt(c.codeLen(s), len(s))
+		if t, found := c.chars[string(s[:n])]; found {
+			b = append(b, []rune(t)...)
+		}
+		s = s[n:]
+	}
+	return string(b)
+}
+
+// utf16BE decodes UTF-16BE encoded data.
+func utf16BE(s []byte) string {
+	u := make([]uint16, 0, len(s)/2)
+	for i := 0; i+1 < len(s); i += 2 {
+		u = append(u, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+	return string(utf16.Decode(u))
+}
@@ -0,0 +1,69 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// maxOperands is the maximum number of operands of a content stream
+// operator, operators with more operands are assumed to be garbage.
+const maxOperands = 4096
+
+// operations parses a content stream (or a CMap), and calls fn for each
+// operator with its operands. Inline images are skipped.
+// Parsing stops at the first error, which is returned, or when fn
+// returns false.
+func operations(data []byte, fn func(op string, args []Object) bool) error {
+	l := &lexer{data: data}
+	var args []Object
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.data) {
+			return nil
+		}
+		tok, err := l.token()
+		if err != nil {
+			return err
+		}
+		if kw, ok := tok.(keyword); ok {
+			switch kw {
+			case "true", "false", "null":
+			default:
+				if kw == "ID" {
+					l.skipInlineImage()
+				}
+				if !fn(string(kw), args) {
+					return nil
+				}
+				args = args[:0]
+				continue
+			}
+		}
+		o, err := l.objectFrom(tok)
+		if err != nil {
+			return err
+		}
+		if len(args) == maxOperands {
+			return fmt.Errorf("pdf: too many operands at offset %d", l.pos)
+		}
+		args = append(args, o)
+	}
+}
+
+// skipInlineImage skips inline image data, after the 'ID' operator,
+// up to and including the 'EI' operator.
+func (l *lexer) skipInlineImage() {
+	l.pos++ // single white-space character after 'ID'
+	for l.pos < len(l.data) {
+		i := bytes.Index(l.data[l.pos:], []byte("EI"))
+		if i < 0 {
+			break
+		}
+		l.pos += i + 2
+		if (l.pos-3 < 0 || isWhite(l.data[l.pos-3])) &&
+			(l.pos >= len(l.data) || isWhite(l.data[l.pos])) {
+			return
+		}
+	}
+	l.pos = len(l.data)
+}
@@ -0,0 +1,107 @@
+package pdf
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// winAnsiHigh maps the WinAnsiEncoding codes 0x80 through 0x9f, which
+// differ from Latin-1, to Unicode. Undefined codes are mapped to 0.
+var winAnsiHigh = [32]rune{
+	'€', 0, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0, 'Ž', 0,
+	0, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0, 'ž', 'Ÿ',
+}
+
+// pdfDocHigh maps the PDFDocEncoding codes 0x80 through 0x9f, which
+// differ from Latin-1, to Unicode. Undefined codes are mapped to 0.
+var pdfDocHigh = [32]rune{
+	'•', '†', '‡', '…', '—', '–', 'ƒ', '⁄', '‹', '›', '−', '‰', '„', '“', '”', '‘',
+	'’', '‚', '™', 'ﬁ', 'ﬂ', 'Ł', 'Œ', 'Š', 'Ÿ', 'Ž', 'ı', 'ł', 'œ', 'š', 'ž', 0,
+}
+
+// winAnsi returns the WinAnsiEncoding character of the code, or 0.
+func winAnsi(c byte) rune {
+	switch {
+	case c >= 0x80 && c < 0xa0:
+		return winAnsiHigh[c-0x80]
+	case c < 0x20 && c != '\t' && c != '\n' && c != '\r':
+		return 0
+	}
+	return rune(c)
+}
+
+// Text returns the string decoded as a PDF text string, i.e., as UTF-16BE
+// or UTF-8, if it starts with a byte order mark, or as PDFDocEncoding
+// otherwise.
+func (s String) Text() string {
+	switch {
+	case len(s) >= 2 && s[0] == 0xfe && s[1] == 0xff:
+		return utf16BE(s[2:])
+	case len(s) >= 3 && s[0] == 0xef && s[1] == 0xbb && s[2] == 0xbf:
+		return strings.ToValidUTF8(string(s[3:]), "")
+	}
+	b := make([]rune, 0, len(s))
+	for _, c := range s {
+		r := rune(c)
+		if c >= 0x80 && c < 0xa0 {
+			r = pdfDocHigh[c-0x80]
+		}
+		if r != 0 {
+			b = append(b, r)
+		}
+	}
+	return string(b)
+}
+
+// glyphNames maps common glyph names, which are used in font encoding
+// differences, to Unicode text. Names of single letters, and 'uniXXXX'
+// and 'uXXXX[XX]' names are mapped by glyphText.
+var glyphNames = map[string]string{
+	"space": " ", "exclam": "!", "quotedbl": "\"", "numbersign": "#",
+	"dollar": "$", "percent": "%", "ampersand": "&", "quotesingle": "'",
+	"parenleft": "(", "parenright": ")", "asterisk": "*", "plus": "+",
+	"comma": ",", "hyphen": "-", "period": ".", "slash": "/",
+	"zero": "0", "one": "1", "two": "2", "three": "3", "four": "4",
+	"five": "5", "six": "6", "seven": "7", "eight": "8", "nine": "9",
+	"colon": ":", "semicolon": ";", "less": "<", "equal": "=",
+	"greater": ">", "question": "?", "at": "@", "bracketleft": "[",
+	"backslash": "\\", "bracketright": "]", "asciicircum": "^",
+	"underscore": "_", "grave": "`", "braceleft": "{", "bar": "|",
+	"braceright": "}", "asciitilde": "~", "quoteleft": "‘",
+	"quoteright": "’", "quotedblleft": "“", "quotedblright": "”",
+	"quotesinglbase": "‚", "quotedblbase": "„", "endash": "–",
+	"emdash": "—", "minus": "−", "bullet": "•", "ellipsis": "…",
+	"dagger": "†", "daggerdbl": "‡", "degree": "°", "section": "§",
+	"paragraph": "¶", "copyright": "©", "registered": "®",
+	"trademark": "™", "fi": "fi", "fl": "fl", "ff": "ff", "ffi": "ffi",
+	"ffl": "ffl", "dotlessi": "ı", "germandbls": "ß", "ae": "æ", "AE": "Æ",
+	"oe": "œ", "OE": "Œ", "oslash": "ø", "Oslash": "Ø", "multiply": "×",
+	"divide": "÷", "plusminus": "±", "periodcentered": "·", "nbspace": " ",
+}
+
+// glyphText returns the Unicode text of the glyph name, or an empty
+// string if the name is not known.
+func glyphText(name string) string {
+	if t, found := glyphNames[name]; found {
+		return t
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		return name
+	}
+	// e.g., 'uni0041' or 'u1D400'
+	var hex string
+	switch {
+	case strings.HasPrefix(name, "uni") && len(name) == 7:
+		hex = name[3:]
+	case strings.HasPrefix(name, "u") && len(name) >= 5 && len(name) <= 7:
+		hex = name[1:]
+	default:
+		return ""
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || !utf8.ValidRune(rune(v)) {
+		return ""
+	}
+	return string(rune(v))
+}
@@ -0,0 +1,508 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// xrefEntry is a cross-reference table entry.
+type xrefEntry struct {
+	// Type is 0 for free objects, 1 for objects stored at Offset,
+	// and 2 for objects stored in the object stream Stream, at Index.
+	Type   int
+	Offset int64
+	Gen    int
+	Stream int
+	Index  int
+}
+
+// File is a parsed PDF file.
+// Objects are parsed lazily, when they are requested.
+type File struct {
+	// Data is the raw file data.
+	Data []byte
+	// Trailer is the (most recent) trailer dictionary.
+	Trailer Dict
+	// StartXRef is the offset of the (most recent) cross-reference section.
+	StartXRef int64
+	// XRefStream reports whether the (most recent) cross-reference section
+	// is a cross-reference stream, rather than a table.
+	XRefStream bool
+
+	xref    map[int]xrefEntry
+	objects map[int]Object // parsed object cache
+	objStms map[int]*objStm
+	loading map[int]bool // objects being parsed, to detect cycles
+}
+
+// maxLoadDepth is the maximum number of objects being parsed at once,
+// i.e., the depth of objects whose parsing requires parsing others,
+// e.g., streams with indirect lengths or objects in object streams.
+const maxLoadDepth = 64
+
+// objStm is a parsed object stream.
+type objStm struct {
+	data    []byte
+	offsets []int
+}
+
+// Open parses the PDF file data, i.e., the header, the cross-reference
+// sections and the trailer. An error is returned if the data is not a
+// structurally valid PDF file.
+func Open(data []byte) (*File, error) {
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	if !bytes.Contains(head, []byte("%PDF-")) {
+		return nil, fmt.Errorf("pdf: missing header")
+	}
+	f := &File{
+		Data:    data,
+		xref:    make(map[int]xrefEntry),
+		objects: make(map[int]Object),
+		objStms: make(map[int]*objStm),
+		loading: make(map[int]bool),
+	}
+	start, err := f.findStartXRef()
+	if err != nil {
+		return nil, err
+	}
+	f.StartXRef = start
+
+	seen := make(map[int64]bool)
+	for off := start; off >= 0; {
+		if seen[off] {
+			return nil, fmt.Errorf("pdf: cross-reference loop at offset %d", off)
+		}
+		seen[off] = true
+
+		trailer, isStream, err := f.readXRef(off)
+		if err != nil {
+			return nil, err
+		}
+		if f.Trailer == nil {
+			f.Trailer = trailer
+			f.XRefStream = isStream
+		}
+		// hybrid files hold additional entries in a cross-reference stream
+		if stm, ok := trailer.Int("XRefStm"); ok && !isStream {
+			if _, _, err := f.readXRef(int64(stm)); err != nil {
+				return nil, err
+			}
+		}
+		off = -1
+		if prev, ok := trailer.Int("Prev"); ok {
+			off = int64(prev)
+		}
+	}
+	if _, ok := f.Trailer["Root"].(Ref); !ok {
+		return nil, fmt.Errorf("pdf: trailer has no document catalog")
+	}
+	return f, nil
+}
+
+// Validate reports whether the PDF file data is structurally valid, i.e.,
+// whether the cross-reference sections and the trailer can be parsed,
+// and the document catalog can be read.
+func Validate(data []byte) error {
+	f, err := Open(data)
+	if err != nil {
+		return err
+	}
+	root, err := f.Resolve(f.Trailer["Root"])
+	if err != nil {
+		return err
+	}
+	if d, ok := root.(Dict); !ok || d.Name("Type") != "Catalog" {
+		return fmt.Errorf("pdf: bad document catalog")
+	}
+	return nil
+}
+
+// Size returns the number of objects in the file, i.e., one greater than
+// the highest object number, as recorded in the trailer.
+func (f *File) Size() int {
+	n, _ := f.Trailer.Int("Size")
+	return n
+}
+
+// findStartXRef returns the offset of the most recent cross-reference
+// section, as recorded after the last 'startxref' keyword.
+func (f *File) findStartXRef() (int64, error) {
+	tail := f.Data
+	base := 0
+	if len(tail) > 2048 {
+		base = len(tail) - 2048
+		tail = tail[base:]
+	}
+	i := bytes.LastIndex(tail, []byte("startxref"))
+	if i == -1 {
+		return 0, fmt.Errorf("pdf: missing startxref")
+	}
+	l := &lexer{data: f.Data, pos: base + i + len("startxref")}
+	tok, err := l.token()
+	if err != nil {
+		return 0, err
+	}
+	off, ok := tok.(int64)
+	if !ok || off < 0 || off >= int64(len(f.Data)) {
+		return 0, fmt.Errorf("pdf: bad startxref offset")
+	}
+	return off, nil
+}
+
+// readXRef reads the cross-reference section at the offset, either a table
+// or a stream, and returns its trailer dictionary. Entries already read,
+// i.e., from more recent sections, take precedence.
+func (f *File) readXRef(off int64) (Dict, bool, error) {
+	if off < 0 || off >= int64(len(f.Data)) {
+		return nil, false, fmt.Errorf("pdf: cross-reference offset out of range: %d", off)
+	}
+	l := &lexer{data: f.Data, pos: int(off)}
+	tok, err := l.token()
+	if err != nil {
+		return nil, false, err
+	}
+	if tok == keyword("xref") {
+		d, err := f.readXRefTable(l)
+		return d, false, err
+	}
+	d, err := f.readXRefStream(off)
+	return d, true, err
+}
+
+// readXRefTable reads a cross-reference table, after the 'xref' keyword.
+func (f *File) readXRefTable(l *lexer) (Dict, error) {
+	for {
+		tok, err := l.token()
+		if err != nil {
+			return nil, err
+		}
+		if tok == keyword("trailer") {
+			break
+		}
+		start, ok := tok.(int64)
+		if !ok {
+			return nil, fmt.Errorf("pdf: bad cross-reference table at offset %d", l.pos)
+		}
+		tok, err = l.token()
+		if err != nil {
+			return nil, err
+		}
+		count, ok := tok.(int64)
+		if !ok {
+			return nil, fmt.Errorf("pdf: bad cross-reference table at offset %d", l.pos)
+		}
+		for i := int64(0); i < count; i++ {
+			var fields [3]interface{}
+			for j := range fields {
+				if fields[j], err = l.token(); err != nil {
+					return nil, err
+				}
+			}
+			off, ok1 := fields[0].(int64)
+			gen, ok2 := fields[1].(int64)
+			typ, ok3 := fields[2].(keyword)
+			if !ok1 || !ok2 || !ok3 || (typ != "n" && typ != "f") {
+				return nil, fmt.Errorf("pdf: bad cross-reference entry at offset %d", l.pos)
+			}
+			num := int(start + i)
+			if _, found := f.xref[num]; found {
+				continue
+			}
+			e := xrefEntry{Offset: off, Gen: int(gen)}
+			if typ == "n" {
+				e.Type = 1
+			}
+			f.xref[num] = e
+		}
+	}
+	o, err := l.object()
+	if err != nil {
+		return nil, err
+	}
+	d, ok := o.(Dict)
+	if !ok {
+		return nil, fmt.Errorf("pdf: bad trailer")
+	}
+	return d, nil
+}
+
+// readXRefStream reads a cross-reference stream object at the offset.
+func (f *File) readXRefStream(off int64) (Dict, error) {
+	_, o, err := f.readIndirect(off)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := o.(*Stream)
+	if !ok || s.Dict.Name("Type") != "XRef" {
+		return nil, fmt.Errorf("pdf: bad cross-reference stream at offset %d", off)
+	}
+	data, err := f.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	w, ok := s.Dict["W"].(Array)
+	if !ok || len(w) != 3 {
+		return nil, fmt.Errorf("pdf: bad cross-reference stream field widths")
+	}
+	var widths [3]int
+	for i := range widths {
+		v, ok := w[i].(int64)
+		if !ok || v < 0 || v > 8 {
+			return nil, fmt.Errorf("pdf: bad cross-reference stream field widths")
+		}
+		widths[i] = int(v)
+	}
+	size, _ := s.Dict.Int("Size")
+	index := Array{int64(0), int64(size)}
+	if idx, ok := s.Dict["Index"].(Array); ok {
+		index = idx
+	}
+
+	entry := widths[0] + widths[1] + widths[2]
+	if entry == 0 {
+		return nil, fmt.Errorf("pdf: bad cross-reference stream field widths")
+	}
+	pos := 0
+	for i := 0; i+1 < len(index); i += 2 {
+		start, ok1 := index[i].(int64)
+		count, ok2 := index[i+1].(int64)
+		if !ok1 || !ok2 || start < 0 || count < 0 {
+			return nil, fmt.Errorf("pdf: bad cross-reference stream index")
+		}
+		if count > int64((len(data)-pos)/entry) {
+			return nil, fmt.Errorf("pdf: truncated cross-reference stream")
+		}
+		for j := int64(0); j < count; j++ {
+			if pos+entry > len(data) {
+				return nil, fmt.Errorf("pdf: truncated cross-reference stream")
+			}
+			var fields [3]int64
+			for k, wd := range widths {
+				for _, b := range data[pos : pos+wd] {
+					fields[k] = fields[k]<<8 | int64(b)
+				}
+				pos += wd
+			}
+			if widths[0] == 0 {
+				fields[0] = 1
+			}
+			num := int(start + j)
+			if _, found := f.xref[num]; found {
+				continue
+			}
+			switch fields[0] {
+			case 1:
+				f.xref[num] = xrefEntry{Type: 1, Offset: fields[1], Gen: int(fields[2])}
+			case 2:
+				f.xref[num] = xrefEntry{Type: 2, Stream: int(fields[1]), Index: int(fields[2])}
+			default:
+				f.xref[num] = xrefEntry{}
+			}
+		}
+	}
+	return s.Dict, nil
+}
+
+// readIndirect reads the indirect object ('N G obj ... endobj') at the
+// offset, and returns its reference and value.
+func (f *File) readIndirect(off int64) (Ref, Object, error) {
+	if off < 0 || off >= int64(len(f.Data)) {
+		return Ref{}, nil, fmt.Errorf("pdf: object offset out of range: %d", off)
+	}
+	l := &lexer{data: f.Data, pos: int(off)}
+	var hdr [3]interface{}
+	for i := range hdr {
+		tok, err := l.token()
+		if err != nil {
+			return Ref{}, nil, err
+		}
+		hdr[i] = tok
+	}
+	num, ok1 := hdr[0].(int64)
+	gen, ok2 := hdr[1].(int64)
+	if !ok1 || !ok2 || hdr[2] != keyword("obj") {
+		return Ref{}, nil, fmt.Errorf("pdf: bad object header at offset %d", off)
+	}
+	ref := Ref{Num: int(num), Gen: int(gen)}
+	o, err := l.object()
+	if err != nil {
+		return ref, nil, err
+	}
+	d, ok := o.(Dict)
+	if !ok {
+		return ref, o, nil
+	}
+	save := l.pos
+	if tok, err := l.token(); err != nil || tok != keyword("stream") {
+		l.pos = save
+		return ref, d, nil
+	}
+	// stream data starts after the end-of-line marker
+	if l.pos < len(f.Data) && f.Data[l.pos] == '\r' {
+		l.pos++
+	}
+	if l.pos < len(f.Data) && f.Data[l.pos] == '\n' {
+		l.pos++
+	}
+	start := l.pos
+	length := -1
+	switch v := d["Length"].(type) {
+	case int64:
+		length = int(v)
+	case Ref:
+		if v.Num != ref.Num {
+			if lo, err := f.Resolve(v); err == nil {
+				if n, ok := lo.(int64); ok {
+					length = int(n)
+				}
+			}
+		}
+	}
+	end := start + length
+	if length < 0 || end > len(f.Data) ||
+		!bytes.Contains(f.Data[end:minInt(end+32, len(f.Data))], []byte("endstream")) {
+		// recover from a bad length
+		i := bytes.Index(f.Data[start:], []byte("endstream"))
+		if i == -1 {
+			return ref, nil, fmt.Errorf("pdf: unterminated stream at offset %d", off)
+		}
+		end = start + i
+		for end > start && (f.Data[end-1] == '\n' || f.Data[end-1] == '\r') {
+			end--
+		}
+	}
+	return ref, &Stream{Dict: d, Data: f.Data[start:end]}, nil
+}
+
+// minInt returns the smaller of a and b.
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Object returns the object with the object number.
+// Free or missing objects are returned as nil (null). An error is returned
+// if parsing the object requires parsing the object itself, e.g., if the
+// length of a stream refers back to the stream, or if objects are nested
+// too deeply.
+func (f *File) Object(num int) (Object, error) {
+	if o, found := f.objects[num]; found {
+		return o, nil
+	}
+	e, found := f.xref[num]
+	if !found {
+		return nil, nil
+	}
+	if f.loading[num] {
+		return nil, fmt.Errorf("pdf: object %d refers to itself", num)
+	}
+	if len(f.loading) >= maxLoadDepth {
+		return nil, fmt.Errorf("pdf: objects nested too deeply at object %d", num)
+	}
+	f.loading[num] = true
+	defer delete(f.loading, num)
+
+	var o Object
+	var err error
+	switch e.Type {
+	case 1:
+		var ref Ref
+		ref, o, err = f.readIndirect(e.Offset)
+		if err == nil && ref.Num != num {
+			err = fmt.Errorf("pdf: object %d not found at offset %d", num, e.Offset)
+		}
+	case 2:
+		o, err = f.objectFromStream(e.Stream, e.Index)
+	}
+	if err != nil {
+		return nil, err
+	}
+	f.objects[num] = o
+	return o, nil
+}
+
+// objectFromStream returns the object at the index in the object stream.
+func (f *File) objectFromStream(num, index int) (Object, error) {
+	stm, found := f.objStms[num]
+	if !found {
+		o, err := f.Object(num)
+		if err != nil {
+			return nil, err
+		}
+		s, ok := o.(*Stream)
+		if !ok || s.Dict.Name("Type") != "ObjStm" {
+			return nil, fmt.Errorf("pdf: object %d is not an object stream", num)
+		}
+		data, err := f.Decode(s)
+		if err != nil {
+			return nil, err
+		}
+		n, _ := s.Dict.Int("N")
+		first, _ := s.Dict.Int("First")
+		if first < 0 {
+			return nil, fmt.Errorf("pdf: bad object stream %d", num)
+		}
+		stm = &objStm{data: data}
+		l := &lexer{data: data}
+		for i := 0; i < n; i++ {
+			var pair [2]int64
+			for j := range pair {
+				tok, err := l.token()
+				if err != nil {
+					return nil, err
+				}
+				v, ok := tok.(int64)
+				if !ok || v < 0 || v > int64(len(data)) {
+					return nil, fmt.Errorf("pdf: bad object stream %d", num)
+				}
+				pair[j] = v
+			}
+			stm.offsets = append(stm.offsets, first+int(pair[1]))
+		}
+		f.objStms[num] = stm
+	}
+	if index < 0 || index >= len(stm.offsets) || stm.offsets[index] >= len(stm.data) {
+		return nil, fmt.Errorf("pdf: object stream %d index out of range: %d", num, index)
+	}
+	l := &lexer{data: stm.data, pos: stm.offsets[index]}
+	return l.object()
+}
+
+// Resolve returns the object referenced by o, if o is a reference,
+// otherwise o itself.
+func (f *File) Resolve(o Object) (Object, error) {
+	for depth := 0; depth < 32; depth++ {
+		r, ok := o.(Ref)
+		if !ok {
+			return o, nil
+		}
+		var err error
+		if o, err = f.Object(r.Num); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("pdf: reference chain too long")
+}
+
+// ResolveDict returns the dictionary referenced by o, or o itself.
+// The dictionary of a stream is returned for streams.
+func (f *File) ResolveDict(o Object) (Dict, error) {
+	o, err := f.Resolve(o)
+	if err != nil {
+		return nil, err
+	}
+	switch d := o.(type) {
+	case Dict:
+		return d, nil
+	case *Stream:
+		return d.Dict, nil
+	case nil:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("pdf: not a dictionary: %v", o)
+}
@@ -0,0 +1,248 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// buildPDF assembles a minimal PDF file from the object bodies,
+// numbered from 1, with a cross-reference table and a trailer.
+func buildPDF(objs ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offs := make([]int, len(objs))
+	for i, o := range objs {
+		offs[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offs {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(objs)+1, xref)
+	return b.Bytes()
+}
+
+func deflate(s string) string {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	w.Write([]byte(s))
+	w.Close()
+	return b.String()
+}
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+	stream := deflate("BT /F1 12 Tf (Hello) Tj ET")
+	var tests = []struct {
+		Name  string
+		Data  []byte
+		Error bool
+	}{
+		{
+			Name: "valid",
+			Data: buildPDF(
+				"<< /Type /Catalog /Pages 2 0 R >>",
+				"<< /Type /Pages /Kids [] /Count 0 >>",
+			),
+		},
+		{
+			Name: "valid-stream",
+			Data: buildPDF(
+				"<< /Type /Catalog /Pages 2 0 R >>",
+				"<< /Type /Pages /Kids [] /Count 0 >>",
+				fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream",
+					len(stream), stream),
+			),
+		},
+		{
+			Name:  "not-catalog",
+			Data:  buildPDF("<< /Type /Pages /Kids [] /Count 0 >>"),
+			Error: true,
+		},
+		{
+			Name:  "html",
+			Data:  []byte("<!DOCTYPE html><html><body>captcha</body></html>"),
+			Error: true,
+		},
+		{
+			Name:  "truncated",
+			Data:  buildPDF("<< /Type /Catalog >>")[:40],
+			Error: true,
+		},
+	}
+	for _, tt := range tests {
+		err := Validate(tt.Data)
+		assert.Equal(tt.Error, err != nil, "%v: %v", tt.Name, err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	assert := assert.New(t)
+	var tests = []struct {
+		Name   string
+		Stream *Stream
+		Data   string
+	}{
+		{
+			Name:   "flate",
+			Stream: &Stream{Dict: Dict{"Filter": Name("FlateDecode")}, Data: []byte(deflate("hello"))},
+			Data:   "hello",
+		},
+		{
+			Name:   "hex",
+			Stream: &Stream{Dict: Dict{"Filter": Array{Name("ASCIIHexDecode")}}, Data: []byte("68 65 6c 6c 6f>")},
+			Data:   "hello",
+		},
+		{
+			Name: "flate-png-up",
+			Stream: &Stream{
+				Dict: Dict{
+					"Filter":      Name("FlateDecode"),
+					"DecodeParms": Dict{"Predictor": int64(12), "Columns": int64(2)},
+				},
+				Data: []byte(deflate("\x02\x01\x02\x02\x01\x01")),
+			},
+			Data: "\x01\x02\x02\x03",
+		},
+	}
+	f := &File{}
+	for _, tt := range tests {
+		data, err := f.Decode(tt.Stream)
+		assert.Nil(err, tt.Name)
+		assert.Equal(tt.Data, string(data), tt.Name)
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	objStm := func(dict, data string) string {
+		return fmt.Sprintf("<< /Type /ObjStm %s /Length %d >>\nstream\n%s\nendstream",
+			dict, len(data), data)
+	}
+	xrefStm := func(dict string) []byte {
+		return []byte("%PDF-1.5\n1 0 obj\n<< /Type /XRef " + dict +
+			" /Root 1 0 R /Length 0 >>\nstream\n\nendstream\nendobj\nstartxref\n9\n%%EOF\n")
+	}
+	catalog := "<< /Type /Catalog /Pages 2 0 R >>"
+	pages := "<< /Type /Pages /Kids [] /Count 0 >>"
+	// a hybrid file, whose cross-reference stream offset is negative
+	hybrid := bytes.Replace(buildPDF(catalog, pages),
+		[]byte("/Root 1 0 R"), []byte("/Root 1 0 R /XRefStm -5"), 1)
+	var tests = []struct {
+		Name  string
+		Data  []byte
+		XRef  map[int]xrefEntry // entries replacing those of the table
+		Num   int               // object requested
+		Error bool
+	}{
+		{
+			Name: "length-cycle",
+			Data: buildPDF(catalog, pages,
+				"<< /Length 4 0 R >>\nstream\nabc\nendstream",
+				"<< /Length 3 0 R >>\nstream\nxyz\nendstream",
+			),
+			Num: 3,
+		},
+		{
+			Name: "length-self",
+			Data: buildPDF(catalog, pages, "<< /Length 3 0 R >>\nstream\nabc\nendstream"),
+			Num:  3,
+		},
+		{
+			Name:  "objstm-self",
+			Data:  buildPDF(catalog, pages, objStm("/N 1 /First 4", "3 0 (x)")),
+			XRef:  map[int]xrefEntry{3: {Type: 2, Stream: 3}},
+			Num:   3,
+			Error: true,
+		},
+		{
+			Name: "objstm-cycle",
+			Data: buildPDF(catalog, pages,
+				objStm("/N 1 /First 4", "4 0 (x)"),
+				objStm("/N 1 /First 4", "3 0 (x)"),
+			),
+			XRef:  map[int]xrefEntry{3: {Type: 2, Stream: 4}, 4: {Type: 2, Stream: 3}},
+			Num:   3,
+			Error: true,
+		},
+		{
+			Name:  "objstm-negative-first",
+			Data:  buildPDF(catalog, pages, objStm("/N 1 /First -50", "4 0 (x)")),
+			XRef:  map[int]xrefEntry{4: {Type: 2, Stream: 3}},
+			Num:   4,
+			Error: true,
+		},
+		{
+			Name:  "objstm-negative-offset",
+			Data:  buildPDF(catalog, pages, objStm("/N 1 /First 6", "4 -50 (x)")),
+			XRef:  map[int]xrefEntry{4: {Type: 2, Stream: 3}},
+			Num:   4,
+			Error: true,
+		},
+		{
+			Name:  "objstm-negative-index",
+			Data:  buildPDF(catalog, pages, objStm("/N 1 /First 4", "4 0 (x)")),
+			XRef:  map[int]xrefEntry{4: {Type: 2, Stream: 3, Index: -1}},
+			Num:   4,
+			Error: true,
+		},
+		{
+			Name:  "xref-stream-zero-widths",
+			Data:  xrefStm("/W [0 0 0] /Index [0 9999999999] /Size 1"),
+			Error: true,
+		},
+		{
+			Name:  "xref-stream-huge-count",
+			Data:  xrefStm("/W [1 0 0] /Index [0 9999999999] /Size 1"),
+			Error: true,
+		},
+		{
+			Name:  "xref-stream-negative-index",
+			Data:  xrefStm("/W [1 0 0] /Index [0 -1] /Size 1"),
+			Error: true,
+		},
+		{
+			Name:  "xref-stream-negative-offset",
+			Data:  hybrid,
+			Error: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			f, err := Open(tt.Data)
+			if err == nil && tt.Num != 0 {
+				for num, e := range tt.XRef {
+					f.xref[num] = e
+				}
+				_, err = f.Object(tt.Num)
+			}
+			assert.Equal(t, tt.Error, err != nil, "%v", err)
+		})
+	}
+}
+
+func TestDecodeBadPredictor(t *testing.T) {
+	for _, p := range []Dict{
+		{"Predictor": int64(12), "Columns": int64(0)},
+		{"Predictor": int64(12), "Columns": int64(-4)},
+		{"Predictor": int64(2), "Columns": int64(0)},
+		{"Predictor": int64(12), "Colors": int64(0)},
+		{"Predictor": int64(12), "Colors": int64(-1)},
+		{"Predictor": int64(12), "BitsPerComponent": int64(0)},
+		{"Predictor": int64(12), "Columns": int64(1 << 40)},
+	} {
+		s := &Stream{
+			Dict: Dict{"Filter": Name("FlateDecode"), "DecodeParms": p},
+			Data: []byte(deflate("\x02\x01\x02\x02\x01\x01")),
+		}
+		_, err := (&File{}).Decode(s)
+		assert.NotNil(t, err, "%v", p)
+	}
+}
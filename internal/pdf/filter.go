@@ -0,0 +1,233 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// Decode returns the decoded stream data, i.e., with all stream filters
+// applied. Only the FlateDecode (with PNG and TIFF predictors),
+// ASCIIHexDecode and ASCII85Decode filters are supported.
+func (f *File) Decode(s *Stream) ([]byte, error) {
+	filters, err := f.asArray(s.Dict["Filter"])
+	if err != nil {
+		return nil, err
+	}
+	parms, err := f.asArray(s.Dict["DecodeParms"])
+	if err != nil {
+		return nil, err
+	}
+
+	data := s.Data
+	for i, fo := range filters {
+		name, ok := fo.(Name)
+		if !ok {
+			return nil, fmt.Errorf("pdf: bad stream filter: %v", fo)
+		}
+		var p Dict
+		if i < len(parms) {
+			if p, err = f.ResolveDict(parms[i]); err != nil {
+				return nil, err
+			}
+		}
+		switch name {
+		case "FlateDecode", "Fl":
+			if data, err = inflate(data); err != nil {
+				return nil, err
+			}
+			if data, err = unpredict(data, p); err != nil {
+				return nil, err
+			}
+		case "ASCIIHexDecode", "AHx":
+			if data, err = decodeHex(data); err != nil {
+				return nil, err
+			}
+		case "ASCII85Decode", "A85":
+			if data, err = decodeASCII85(data); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("pdf: unsupported stream filter: %v", name)
+		}
+	}
+	return data, nil
+}
+
+// asArray returns o, resolved, as an array. A single object is returned
+// as an array of one, and null as an empty array.
+func (f *File) asArray(o Object) (Array, error) {
+	o, err := f.Resolve(o)
+	if err != nil {
+		return nil, err
+	}
+	switch a := o.(type) {
+	case nil:
+		return nil, nil
+	case Array:
+		for i := range a {
+			if a[i], err = f.Resolve(a[i]); err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	}
+	return Array{o}, nil
+}
+
+// inflate decompresses zlib (or, as a fallback, raw deflate) data.
+// Truncated data is decompressed as far as possible.
+func inflate(data []byte) ([]byte, error) {
+	var out []byte
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err == nil {
+		out, err = io.ReadAll(r)
+	}
+	if err != nil && len(out) == 0 {
+		out, err = io.ReadAll(flate.NewReader(bytes.NewReader(data)))
+	}
+	if err != nil && len(out) == 0 {
+		return nil, fmt.Errorf("pdf: %w", err)
+	}
+	return out, nil
+}
+
+// unpredict reverses the PNG or TIFF predictor applied to the data,
+// as set in the decode parameters.
+func unpredict(data []byte, p Dict) ([]byte, error) {
+	pred, _ := p.Int("Predictor")
+	if pred <= 1 {
+		return data, nil
+	}
+	colors, ok := p.Int("Colors")
+	if !ok {
+		colors = 1
+	}
+	bpc, ok := p.Int("BitsPerComponent")
+	if !ok {
+		bpc = 8
+	}
+	columns, ok := p.Int("Columns")
+	if !ok {
+		columns = 1
+	}
+	// bounded, so that row lengths do not overflow
+	if colors < 1 || colors > 32 || columns < 1 || columns > 1<<24 {
+		return nil, fmt.Errorf("pdf: bad predictor parameters: %d colors, %d columns",
+			colors, columns)
+	}
+	switch bpc {
+	case 1, 2, 4, 8, 16:
+	default:
+		return nil, fmt.Errorf("pdf: bad predictor bit depth: %d", bpc)
+	}
+	bpp := (colors*bpc + 7) / 8
+	rowLen := (colors*bpc*columns + 7) / 8
+
+	if pred == 2 {
+		if bpc != 8 {
+			return nil, fmt.Errorf("pdf: unsupported TIFF predictor bit depth: %d", bpc)
+		}
+		out := append([]byte(nil), data...)
+		for r := 0; r+rowLen <= len(out); r += rowLen {
+			for i := bpp; i < rowLen; i++ {
+				out[r+i] += out[r+i-bpp]
+			}
+		}
+		return out, nil
+	}
+
+	// PNG predictors, each row is prefixed by its filter type
+	var out []byte
+	prev := make([]byte, rowLen)
+	for r := 0; r+rowLen+1 <= len(data); r += rowLen + 1 {
+		typ := data[r]
+		row := append([]byte(nil), data[r+1:r+1+rowLen]...)
+		for i := range row {
+			var left, upLeft byte
+			up := prev[i]
+			if i >= bpp {
+				left = row[i-bpp]
+				upLeft = prev[i-bpp]
+			}
+			switch typ {
+			case 0: // None
+			case 1: // Sub
+				row[i] += left
+			case 2: // Up
+				row[i] += up
+			case 3: // Average
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4: // Paeth
+				row[i] += paeth(left, up, upLeft)
+			default:
+				return nil, fmt.Errorf("pdf: bad PNG predictor row type: %d", typ)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+// paeth is the PNG Paeth predictor function.
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+// abs returns the absolute value of i.
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+// decodeHex decodes ASCIIHexDecode data, ignoring white space, up to the
+// '>' end-of-data marker.
+func decodeHex(data []byte) ([]byte, error) {
+	var digits []byte
+	for _, c := range data {
+		if c == '>' {
+			break
+		}
+		if !isWhite(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	if _, err := hex.Decode(out, digits); err != nil {
+		return nil, fmt.Errorf("pdf: %w", err)
+	}
+	return out, nil
+}
+
+// decodeASCII85 decodes ASCII85Decode data, up to the '~>' end-of-data
+// marker.
+func decodeASCII85(data []byte) ([]byte, error) {
+	if i := bytes.Index(data, []byte("~>")); i != -1 {
+		data = data[:i]
+	}
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+	out := make([]byte, 4*len(data)/5+4)
+	n, _, err := ascii85.Decode(out, data, true)
+	if err != nil {
+		return nil, fmt.Errorf("pdf: %w", err)
+	}
+	return out[:n], nil
+}
@@ -0,0 +1,172 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metadata is the bibliographic metadata of a document.
+type Metadata struct {
+	Title    string
+	Authors  []string
+	DOI      string
+	Journal  string
+	Year     int
+	Keywords []string
+	// Modified is the modification date of the metadata.
+	Modified time.Time
+}
+
+// xmpPadding is the amount of white space padding of XMP packets, which
+// allows the packet to be edited in place.
+const xmpPadding = 2048
+
+// SetMetadata embeds the metadata into the PDF file data, i.e., into the
+// document information dictionary and an XMP metadata stream, by an
+// incremental update, and returns the updated file data.
+// Existing information dictionary entries are retained, unless they are
+// set by the metadata, while an existing XMP metadata stream is replaced.
+// Encrypted files are not supported.
+func SetMetadata(data []byte, m Metadata) ([]byte, error) {
+	f, err := Open(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, found := f.Trailer["Encrypt"]; found {
+		return nil, fmt.Errorf("pdf: cannot update encrypted files")
+	}
+	root, _ := f.Trailer["Root"].(Ref)
+	catalog, err := f.ResolveDict(root)
+	if err != nil {
+		return nil, err
+	}
+	u := f.NewUpdate()
+
+	info := make(Dict)
+	if r, ok := f.Trailer["Info"].(Ref); ok {
+		old, err := f.ResolveDict(r)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range old {
+			info[k] = v
+		}
+		m.setInfo(info)
+		u.Set(r, info)
+	} else {
+		m.setInfo(info)
+		u.Trailer["Info"] = u.Add(info)
+	}
+
+	cat := make(Dict, len(catalog)+1)
+	for k, v := range catalog {
+		cat[k] = v
+	}
+	cat["Metadata"] = u.Add(&Stream{
+		Dict: Dict{"Type": Name("Metadata"), "Subtype": Name("XML")},
+		Data: m.xmp(),
+	})
+	u.Set(root, cat)
+
+	return u.Bytes(), nil
+}
+
+// pdfDate formats t as a PDF date string, e.g., 'D:20160211093000Z'.
+func pdfDate(t time.Time) String {
+	return String(t.UTC().Format("D:20060102150405Z"))
+}
+
+// setInfo sets the document information dictionary entries.
+func (m Metadata) setInfo(d Dict) {
+	if len(m.Title) != 0 {
+		d["Title"] = TextString(m.Title)
+	}
+	if len(m.Authors) != 0 {
+		d["Author"] = TextString(strings.Join(m.Authors, "; "))
+	}
+	var subject []string
+	if len(m.Journal) != 0 {
+		subject = append(subject, m.Journal)
+	}
+	if m.Year > 0 {
+		subject = append(subject, strconv.Itoa(m.Year))
+	}
+	if len(m.DOI) != 0 {
+		subject = append(subject, "doi:"+m.DOI)
+		d["doi"] = TextString(m.DOI)
+	}
+	if len(subject) != 0 {
+		d["Subject"] = TextString(strings.Join(subject, ", "))
+	}
+	if len(m.Keywords) != 0 {
+		d["Keywords"] = TextString(strings.Join(m.Keywords, ", "))
+	}
+	if !m.Modified.IsZero() {
+		d["ModDate"] = pdfDate(m.Modified)
+	}
+}
+
+// xmp returns the metadata as an XMP packet, holding Dublin Core and
+// PRISM properties.
+func (m Metadata) xmp() []byte {
+	esc := func(s string) string {
+		var b bytes.Buffer
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+	var b strings.Builder
+	b.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString(" <rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	b.WriteString("  <rdf:Description rdf:about=\"\"\n" +
+		"    xmlns:dc=\"http://purl.org/dc/elements/1.1/\"\n" +
+		"    xmlns:prism=\"http://prismstandard.org/namespaces/basic/2.0/\"\n" +
+		"    xmlns:pdf=\"http://ns.adobe.com/pdf/1.3/\"\n" +
+		"    xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\">\n")
+	b.WriteString("   <dc:format>application/pdf</dc:format>\n")
+	if len(m.Title) != 0 {
+		fmt.Fprintf(&b, "   <dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">%v</rdf:li></rdf:Alt></dc:title>\n",
+			esc(m.Title))
+	}
+	if len(m.Authors) != 0 {
+		b.WriteString("   <dc:creator><rdf:Seq>")
+		for _, a := range m.Authors {
+			fmt.Fprintf(&b, "<rdf:li>%v</rdf:li>", esc(a))
+		}
+		b.WriteString("</rdf:Seq></dc:creator>\n")
+	}
+	if m.Year > 0 {
+		fmt.Fprintf(&b, "   <dc:date><rdf:Seq><rdf:li>%d</rdf:li></rdf:Seq></dc:date>\n", m.Year)
+		fmt.Fprintf(&b, "   <prism:coverDate>%d</prism:coverDate>\n", m.Year)
+	}
+	if len(m.Keywords) != 0 {
+		b.WriteString("   <dc:subject><rdf:Bag>")
+		for _, k := range m.Keywords {
+			fmt.Fprintf(&b, "<rdf:li>%v</rdf:li>", esc(k))
+		}
+		b.WriteString("</rdf:Bag></dc:subject>\n")
+		fmt.Fprintf(&b, "   <pdf:Keywords>%v</pdf:Keywords>\n", esc(strings.Join(m.Keywords, ", ")))
+	}
+	if len(m.DOI) != 0 {
+		fmt.Fprintf(&b, "   <dc:identifier>doi:%v</dc:identifier>\n", esc(m.DOI))
+		fmt.Fprintf(&b, "   <prism:doi>%v</prism:doi>\n", esc(m.DOI))
+		fmt.Fprintf(&b, "   <prism:url>https://doi.org/%v</prism:url>\n", esc(m.DOI))
+	}
+	if len(m.Journal) != 0 {
+		fmt.Fprintf(&b, "   <prism:publicationName>%v</prism:publicationName>\n", esc(m.Journal))
+	}
+	if !m.Modified.IsZero() {
+		fmt.Fprintf(&b, "   <xmp:MetadataDate>%v</xmp:MetadataDate>\n",
+			m.Modified.UTC().Format(time.RFC3339))
+	}
+	b.WriteString("  </rdf:Description>\n </rdf:RDF>\n</x:xmpmeta>\n")
+	for i := 0; i < xmpPadding/64; i++ {
+		b.WriteString(strings.Repeat(" ", 63) + "\n")
+	}
+	b.WriteString("<?xpacket end=\"w\"?>")
+	return []byte(b.String())
+}
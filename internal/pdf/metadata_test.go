@@ -0,0 +1,106 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// buildXRefStreamPDF assembles a minimal PDF file from the object bodies,
+// numbered from 1, with a cross-reference stream.
+func buildXRefStreamPDF(objs ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.5\n")
+	var entries []byte
+	entries = append(entries, 0, 0, 0, 0xff)
+	for i, o := range objs {
+		off := b.Len()
+		entries = append(entries, 1, byte(off>>8), byte(off), 0)
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := b.Len()
+	n := len(objs) + 1
+	entries = append(entries, 1, byte(xref>>8), byte(xref), 0)
+	fmt.Fprintf(&b, "%d 0 obj\n<< /Type /XRef /Size %d /W [1 2 1] /Root 1 0 R /Length %d >>\nstream\n",
+		n, n+1, len(entries))
+	b.Write(entries)
+	fmt.Fprintf(&b, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", xref)
+	return b.Bytes()
+}
+
+func TestSetMetadata(t *testing.T) {
+	assert := assert.New(t)
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [] /Count 0 >>",
+	}
+	m := Metadata{
+		Title:    "Schrödinger (cat)",
+		Authors:  []string{"B. P. Abbott", "R. Abbott"},
+		DOI:      "10.1103/PhysRevLett.116.061102",
+		Journal:  "Physical Review Letters",
+		Year:     2016,
+		Keywords: []string{"gravitational waves"},
+		Modified: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	var tests = []struct {
+		Name   string
+		Data   []byte
+		Stream bool
+	}{
+		{Name: "xref-table", Data: buildPDF(objs...)},
+		{Name: "xref-stream", Data: buildXRefStreamPDF(objs...), Stream: true},
+		{
+			Name: "existing-info",
+			Data: bytes.Replace(
+				buildPDF(append(objs, "<< /Producer (junk) /Title (junk) >>")...),
+				[]byte("/Root 1 0 R"), []byte("/Root 1 0 R /Info 3 0 R"), 1),
+		},
+	}
+	for _, tt := range tests {
+		out, err := SetMetadata(tt.Data, m)
+		if !assert.Nil(err, tt.Name) {
+			continue
+		}
+		// the original file is left unchanged
+		assert.True(bytes.HasPrefix(out, tt.Data), tt.Name)
+		assert.Nil(Validate(out), tt.Name)
+
+		f, err := Open(out)
+		if !assert.Nil(err, tt.Name) {
+			continue
+		}
+		assert.Equal(tt.Stream, f.XRefStream, tt.Name)
+
+		info, err := f.ResolveDict(f.Trailer["Info"])
+		assert.Nil(err, tt.Name)
+		assert.Equal(TextString(m.Title), info["Title"], tt.Name)
+		assert.Equal(String("B. P. Abbott; R. Abbott"), info["Author"], tt.Name)
+		assert.Equal(String("D:20240102030405Z"), info["ModDate"], tt.Name)
+		if tt.Name == "existing-info" {
+			assert.Equal(String("junk"), info["Producer"], tt.Name)
+		}
+
+		cat, err := f.ResolveDict(f.Trailer["Root"])
+		assert.Nil(err, tt.Name)
+		assert.Equal(Name("Catalog"), cat.Name("Type"), tt.Name)
+		assert.NotNil(cat["Pages"], tt.Name)
+		md, err := f.Resolve(cat["Metadata"])
+		assert.Nil(err, tt.Name)
+		if s, ok := md.(*Stream); assert.True(ok, tt.Name) {
+			assert.Contains(string(s.Data), "<prism:doi>10.1103/PhysRevLett.116.061102</prism:doi>")
+			assert.Contains(string(s.Data), "<rdf:li>R. Abbott</rdf:li>")
+			assert.Contains(string(s.Data), "Schrödinger (cat)")
+		}
+	}
+}
+
+func TestSetMetadataEncrypted(t *testing.T) {
+	data := buildPDF("<< /Type /Catalog >>")
+	data = bytes.Replace(data, []byte("/Root 1 0 R"), []byte("/Root 1 0 R /Encrypt 1 0 R"), 1)
+	_, err := SetMetadata(data, Metadata{Title: "x"})
+	assert.NotNil(t, err)
+}
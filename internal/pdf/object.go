@@ -0,0 +1,355 @@
+package pdf
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Object is a PDF object, i.e., one of: nil (null), bool, int64, float64,
+// String, Name, Array, Dict, Ref or *Stream.
+type Object interface{}
+
+// Name is a PDF name object, e.g., /Type, stored without the leading '/'.
+type Name string
+
+// String is a PDF (literal or hexadecimal) string object.
+type String []byte
+
+// Array is a PDF array object.
+type Array []Object
+
+// Dict is a PDF dictionary object.
+type Dict map[Name]Object
+
+// Ref is a PDF indirect object reference, e.g., '12 0 R'.
+type Ref struct {
+	Num int
+	Gen int
+}
+
+// String returns the reference as it appears in a PDF file.
+func (r Ref) String() string {
+	return fmt.Sprintf("%d %d R", r.Num, r.Gen)
+}
+
+// Stream is a PDF stream object. Data holds the raw (encoded) stream data.
+type Stream struct {
+	Dict Dict
+	Data []byte
+}
+
+// Name returns the value of the key as a Name, or an empty Name if the
+// key is not set or is not a Name.
+func (d Dict) Name(key Name) Name {
+	n, _ := d[key].(Name)
+	return n
+}
+
+// Int returns the value of the key as an integer, and whether the key
+// is set and is an integer.
+func (d Dict) Int(key Name) (int, bool) {
+	i, ok := d[key].(int64)
+	return int(i), ok
+}
+
+// keyword is a bare PDF keyword token, e.g., 'obj', 'R' or 'trailer'.
+type keyword string
+
+// delimiter is a PDF delimiter token, e.g., '[', '<<' or '>>'.
+type delimiter string
+
+// isWhite reports whether c is a PDF white-space character.
+func isWhite(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+// isDelim reports whether c is a PDF delimiter character.
+func isDelim(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// isRegular reports whether c is a regular PDF character, i.e., part of
+// a keyword, number or name.
+func isRegular(c byte) bool {
+	return !isWhite(c) && !isDelim(c)
+}
+
+// lexer splits PDF data into tokens.
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+// skipSpace skips white space and comments.
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isWhite(c) {
+			l.pos++
+		} else if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		} else {
+			return
+		}
+	}
+}
+
+// token reads the next token, which is one of: keyword, delimiter,
+// Name, String, int64 or float64.
+func (l *lexer) token() (interface{}, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, fmt.Errorf("pdf: unexpected end of data")
+	}
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		return l.name(), nil
+	case c == '(':
+		return l.literalString()
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		return delimiter("<<"), nil
+	case c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+		l.pos += 2
+		return delimiter(">>"), nil
+	case c == '<':
+		return l.hexString()
+	case c == '[' || c == ']' || c == '{' || c == '}':
+		l.pos++
+		return delimiter(c), nil
+	case isDelim(c):
+		return nil, fmt.Errorf("pdf: unexpected %q at offset %d", c, l.pos)
+	}
+	start := l.pos
+	for l.pos < len(l.data) && isRegular(l.data[l.pos]) {
+		l.pos++
+	}
+	word := string(l.data[start:l.pos])
+	if i, err := strconv.ParseInt(word, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(word, 64); err == nil && isNumeric(word) {
+		return f, nil
+	}
+	return keyword(word), nil
+}
+
+// isNumeric reports whether s looks like a PDF real number.
+func isNumeric(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9') && c != '.' && c != '-' && c != '+' {
+			return false
+		}
+	}
+	return true
+}
+
+// name reads a name token, decoding '#xx' escapes.
+func (l *lexer) name() Name {
+	l.pos++ // skip '/'
+	var b []byte
+	for l.pos < len(l.data) && isRegular(l.data[l.pos]) {
+		c := l.data[l.pos]
+		if c == '#' && l.pos+2 < len(l.data) {
+			if v, err := strconv.ParseUint(string(l.data[l.pos+1:l.pos+3]), 16, 8); err == nil {
+				b = append(b, byte(v))
+				l.pos += 3
+				continue
+			}
+		}
+		b = append(b, c)
+		l.pos++
+	}
+	return Name(b)
+}
+
+// literalString reads a literal string token, e.g., '(text)'.
+func (l *lexer) literalString() (String, error) {
+	l.pos++ // skip '('
+	var b []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return String(b), nil
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				break
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r': // line continuation
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data); i++ {
+						d := l.data[l.pos]
+						if d < '0' || d > '7' {
+							break
+						}
+						v = v*8 + int(d-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		b = append(b, c)
+	}
+	return nil, fmt.Errorf("pdf: unterminated string")
+}
+
+// hexString reads a hexadecimal string token, e.g., '<48656C6C6F>'.
+func (l *lexer) hexString() (String, error) {
+	l.pos++ // skip '<'
+	var b []byte
+	var digits []byte
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		if c == '>' {
+			if len(digits)%2 == 1 {
+				digits = append(digits, '0')
+			}
+			for i := 0; i < len(digits); i += 2 {
+				v, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+				if err != nil {
+					return nil, fmt.Errorf("pdf: bad hex string")
+				}
+				b = append(b, byte(v))
+			}
+			return String(b), nil
+		}
+		if !isWhite(c) {
+			digits = append(digits, c)
+		}
+	}
+	return nil, fmt.Errorf("pdf: unterminated hex string")
+}
+
+// object reads the next object. References ('1 0 R') are recognized
+// by looking ahead after integers.
+func (l *lexer) object() (Object, error) {
+	tok, err := l.token()
+	if err != nil {
+		return nil, err
+	}
+	return l.objectFrom(tok)
+}
+
+// objectFrom reads an object starting with the (already read) token.
+func (l *lexer) objectFrom(tok interface{}) (Object, error) {
+	switch t := tok.(type) {
+	case int64:
+		// look ahead for a reference
+		save := l.pos
+		if gen, err := l.token(); err == nil {
+			if g, ok := gen.(int64); ok {
+				if r, err := l.token(); err == nil && r == keyword("R") {
+					return Ref{Num: int(t), Gen: int(g)}, nil
+				}
+			}
+		}
+		l.pos = save
+		return t, nil
+	case float64, Name, String:
+		return t, nil
+	case keyword:
+		switch t {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return nil, fmt.Errorf("pdf: unexpected keyword %q at offset %d", t, l.pos)
+	case delimiter:
+		switch t {
+		case "[":
+			return l.array()
+		case "<<":
+			return l.dict()
+		}
+	}
+	return nil, fmt.Errorf("pdf: unexpected token %v at offset %d", tok, l.pos)
+}
+
+// array reads the rest of an array object, after '['.
+func (l *lexer) array() (Array, error) {
+	a := Array{}
+	for {
+		tok, err := l.token()
+		if err != nil {
+			return nil, err
+		}
+		if tok == delimiter("]") {
+			return a, nil
+		}
+		o, err := l.objectFrom(tok)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, o)
+	}
+}
+
+// dict reads the rest of a dictionary object, after '<<'.
+func (l *lexer) dict() (Dict, error) {
+	d := Dict{}
+	for {
+		tok, err := l.token()
+		if err != nil {
+			return nil, err
+		}
+		if tok == delimiter(">>") {
+			return d, nil
+		}
+		key, ok := tok.(Name)
+		if !ok {
+			return nil, fmt.Errorf("pdf: dictionary key is not a name at offset %d", l.pos)
+		}
+		o, err := l.object()
+		if err != nil {
+			return nil, err
+		}
+		d[key] = o
+	}
+}
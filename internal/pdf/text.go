@@ -0,0 +1,317 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxPageDepth is the maximum depth of the page tree.
+const maxPageDepth = 64
+
+// font decodes the strings shown using a particular font.
+type font struct {
+	toUnicode *cmap
+	// composite fonts use multi-byte codes, which cannot be decoded
+	// without a ToUnicode CMap.
+	composite   bool
+	differences map[byte]string
+}
+
+// decode returns the text of the string shown using the font.
+func (ft *font) decode(s String) string {
+	switch {
+	case ft == nil:
+		return s.winAnsi()
+	case ft.toUnicode != nil:
+		return ft.toUnicode.decode(s)
+	case ft.composite:
+		return ""
+	}
+	var b strings.Builder
+	for _, c := range s {
+		if t, found := ft.differences[c]; found {
+			b.WriteString(t)
+		} else if r := winAnsi(c); r != 0 {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// winAnsi returns the string decoded as WinAnsiEncoding.
+func (s String) winAnsi() string {
+	b := make([]rune, 0, len(s))
+	for _, c := range s {
+		if r := winAnsi(c); r != 0 {
+			b = append(b, r)
+		}
+	}
+	return string(b)
+}
+
+// loadFont loads the font from the font dictionary. Standard and
+// built-in font encodings, other than WinAnsiEncoding, are not supported,
+// and are decoded as WinAnsiEncoding.
+func (f *File) loadFont(o Object) *font {
+	d, err := f.ResolveDict(o)
+	if err != nil || d == nil {
+		return nil
+	}
+	ft := &font{composite: d.Name("Subtype") == "Type0"}
+	if s, ok := f.resolveStream(d["ToUnicode"]); ok {
+		if data, err := f.Decode(s); err == nil {
+			ft.toUnicode = parseCMap(data)
+		}
+	}
+	if enc, err := f.ResolveDict(d["Encoding"]); err == nil && enc != nil {
+		diffs, _ := f.Resolve(enc["Differences"])
+		if a, ok := diffs.(Array); ok {
+			ft.differences = make(map[byte]string)
+			code := 0
+			for _, v := range a {
+				switch v := v.(type) {
+				case int64:
+					code = int(v)
+				case Name:
+					if code >= 0 && code < 256 {
+						if t := glyphText(string(v)); len(t) != 0 {
+							ft.differences[byte(code)] = t
+						}
+					}
+					code++
+				}
+			}
+		}
+	}
+	return ft
+}
+
+// resolveStream returns the stream referenced by o.
+func (f *File) resolveStream(o Object) (*Stream, bool) {
+	o, err := f.Resolve(o)
+	if err != nil {
+		return nil, false
+	}
+	s, ok := o.(*Stream)
+	return s, ok
+}
+
+// Text extracts the text of the document, in content stream order, with
+// pages separated by form feeds ('\f'). The text is decoded using the
+// fonts' ToUnicode CMaps or simple font encodings, and is approximate,
+// i.e., the layout is not reconstructed, although line breaks and word
+// spacing are retained where possible.
+// Pages whose content cannot be decoded are skipped.
+// Encrypted files are not supported.
+func (f *File) Text() (string, error) {
+	if _, found := f.Trailer["Encrypt"]; found {
+		return "", fmt.Errorf("pdf: cannot extract text from encrypted files")
+	}
+	root, err := f.ResolveDict(f.Trailer["Root"])
+	if err != nil {
+		return "", err
+	}
+	if root == nil {
+		return "", fmt.Errorf("pdf: bad document catalog")
+	}
+	t := &textWriter{f: f, fonts: make(map[Ref]*font)}
+	if err := t.walk(root["Pages"], nil, make(map[Ref]bool), 0); err != nil {
+		return "", err
+	}
+	return t.b.String(), nil
+}
+
+// textWriter accumulates the text extracted from pages.
+type textWriter struct {
+	f     *File
+	b     strings.Builder
+	fonts map[Ref]*font // loaded fonts, by reference
+}
+
+// walk extracts the text of the pages in the page tree node o, whose
+// inherited resources are res.
+func (t *textWriter) walk(o Object, res Dict, seen map[Ref]bool, depth int) error {
+	if r, ok := o.(Ref); ok {
+		if seen[r] {
+			return fmt.Errorf("pdf: page tree loop at object %d", r.Num)
+		}
+		seen[r] = true
+	}
+	if depth > maxPageDepth {
+		return fmt.Errorf("pdf: page tree too deep")
+	}
+	node, err := t.f.ResolveDict(o)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return nil
+	}
+	if r, err := t.f.ResolveDict(node["Resources"]); err == nil && r != nil {
+		res = r
+	}
+	if node.Name("Type") == "Page" || node["Kids"] == nil {
+		t.page(node, res)
+		return nil
+	}
+	kids, err := t.f.asArrayRefs(node["Kids"])
+	if err != nil {
+		return err
+	}
+	for _, k := range kids {
+		if err := t.walk(k, res, seen, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// asArrayRefs returns o, resolved, as an array, without resolving
+// its elements.
+func (f *File) asArrayRefs(o Object) (Array, error) {
+	o, err := f.Resolve(o)
+	if err != nil {
+		return nil, err
+	}
+	a, _ := o.(Array)
+	return a, nil
+}
+
+// page extracts the text of the page, with resources res.
+func (t *textWriter) page(page, res Dict) {
+	contents, err := t.f.asArray(page["Contents"])
+	if err != nil {
+		return
+	}
+	var data []byte
+	for _, c := range contents {
+		s, ok := c.(*Stream)
+		if !ok {
+			continue
+		}
+		d, err := t.f.Decode(s)
+		if err != nil {
+			return
+		}
+		data = append(append(data, d...), '\n')
+	}
+
+	fonts, _ := t.f.ResolveDict(res["Font"])
+	var cur *font
+	var lastY float64
+	operations(data, func(op string, args []Object) bool {
+		switch op {
+		case "Tf":
+			if len(args) > 0 {
+				if name, ok := args[0].(Name); ok {
+					cur = t.font(fonts[name])
+				}
+			}
+		case "Tj":
+			if len(args) > 0 {
+				t.show(cur, args[0])
+			}
+		case "'":
+			t.newline()
+			if len(args) > 0 {
+				t.show(cur, args[len(args)-1])
+			}
+		case "\"":
+			t.newline()
+			if len(args) > 2 {
+				t.show(cur, args[2])
+			}
+		case "TJ":
+			if len(args) == 0 {
+				break
+			}
+			a, _ := args[0].(Array)
+			for _, v := range a {
+				// large negative adjustments are word spaces
+				if n, ok := number(v); ok && n < -200 {
+					t.space()
+				} else {
+					t.show(cur, v)
+				}
+			}
+		case "Td", "TD":
+			if len(args) > 1 {
+				if n, _ := number(args[1]); n != 0 {
+					t.newline()
+				} else {
+					t.space()
+				}
+			}
+		case "T*":
+			t.newline()
+		case "Tm":
+			if len(args) > 5 {
+				if y, _ := number(args[5]); y != lastY {
+					lastY = y
+					t.newline()
+				} else {
+					t.space()
+				}
+			}
+		case "ET":
+			t.newline()
+		}
+		return true
+	})
+	t.b.WriteByte('\f')
+}
+
+// font returns the font from the font dictionary o.
+func (t *textWriter) font(o Object) *font {
+	r, isRef := o.(Ref)
+	if isRef {
+		if ft, found := t.fonts[r]; found {
+			return ft
+		}
+	}
+	ft := t.f.loadFont(o)
+	if isRef {
+		t.fonts[r] = ft
+	}
+	return ft
+}
+
+// show writes the text of the string o shown using the font.
+func (t *textWriter) show(ft *font, o Object) {
+	if s, ok := o.(String); ok {
+		t.b.WriteString(ft.decode(s))
+	}
+}
+
+// space writes a space, unless the text already ends with white space.
+func (t *textWriter) space() {
+	if !t.endsWithSpace() {
+		t.b.WriteByte(' ')
+	}
+}
+
+// newline writes a line break, unless the text already ends with one.
+func (t *textWriter) newline() {
+	s := t.b.String()
+	if len(s) != 0 && s[len(s)-1] != '\n' && s[len(s)-1] != '\f' {
+		t.b.WriteByte('\n')
+	}
+}
+
+// endsWithSpace reports whether the text is empty, or ends with
+// white space.
+func (t *textWriter) endsWithSpace() bool {
+	s := t.b.String()
+	return len(s) == 0 || isWhite(s[len(s)-1])
+}
+
+// number returns the numeric object o as a float.
+func number(o Object) (float64, bool) {
+	switch n := o.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,65 @@
+package pdf
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestText(t *testing.T) {
+	assert := assert.New(t)
+	cmap := `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+1 begincodespacerange <0000> <FFFF> endcodespacerange
+2 beginbfchar
+<0001> <0044>
+<0002> <004F>
+endbfchar
+1 beginbfrange
+<0003> <0004> <0049>
+endbfrange
+endcmap
+CMapName currentdict /CMap defineresource pop
+end
+end`
+	page1 := deflate("BT /F1 12 Tf 72 720 Td (A Title) Tj 0 -14 Td [(Ex) 20 (ample) -300 (text)] TJ ET")
+	page2 := "BT /F2 10 Tf (\x01\x02) Tj T* /F3 10 Tf <0001000200030004> Tj ET"
+	data := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /Resources << /Font << /F1 5 0 R /F2 6 0 R /F3 7 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 8 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 9 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /Encoding << /Differences [1 /fi /uni00E9] >> >>",
+		"<< /Type /Font /Subtype /Type0 /ToUnicode 10 0 R >>",
+		fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", len(page1), page1),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(page2), page2),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(cmap), cmap),
+	)
+	f, err := Open(data)
+	if !assert.Nil(err) {
+		return
+	}
+	text, err := f.Text()
+	assert.Nil(err)
+	assert.Equal("A Title\nExample text\n\ffié\nDOIJ\n\f", text)
+}
+
+func TestStringText(t *testing.T) {
+	assert := assert.New(t)
+	var tests = []struct {
+		Name string
+		In   String
+		Out  string
+	}{
+		{"ascii", String("10.1000/182"), "10.1000/182"},
+		{"pdfdoc", String("\x93quoted\x94 caf\xe9"), "ﬁquotedﬂ café"},
+		{"utf16", TextString("Schrödinger"), "Schrödinger"},
+		{"utf8", String("\xef\xbb\xbfSchrödinger"), "Schrödinger"},
+	}
+	for _, tt := range tests {
+		assert.Equal(tt.Out, tt.In.Text(), tt.Name)
+	}
+}
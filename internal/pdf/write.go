@@ -0,0 +1,282 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+)
+
+// TextString returns s as a PDF text string, i.e., as is, if it consists
+// of printable ASCII characters only, or UTF-16BE encoded with a byte
+// order mark otherwise.
+func TextString(s string) String {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7e {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return String(s)
+	}
+	b := []byte{0xfe, 0xff}
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(u>>8), byte(u))
+	}
+	return String(b)
+}
+
+// writeObject writes the object to b in PDF syntax.
+func writeObject(b *bytes.Buffer, o Object) {
+	switch v := o.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case int:
+		b.WriteString(strconv.Itoa(v))
+	case int64:
+		b.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		b.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+	case Name:
+		writeName(b, v)
+	case String:
+		writeString(b, v)
+	case Ref:
+		b.WriteString(v.String())
+	case Array:
+		b.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			writeObject(b, e)
+		}
+		b.WriteByte(']')
+	case Dict:
+		writeDict(b, v)
+	case *Stream:
+		d := make(Dict, len(v.Dict)+1)
+		for k, e := range v.Dict {
+			d[k] = e
+		}
+		d["Length"] = int64(len(v.Data))
+		writeDict(b, d)
+		b.WriteString("\nstream\n")
+		b.Write(v.Data)
+		b.WriteString("\nendstream")
+	default:
+		panic(fmt.Sprintf("pdf: cannot write object of type %T", o))
+	}
+}
+
+// writeDict writes the dictionary, with sorted keys, to b.
+func writeDict(b *bytes.Buffer, d Dict) {
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+	b.WriteString("<<")
+	for _, k := range keys {
+		writeName(b, Name(k))
+		b.WriteByte(' ')
+		writeObject(b, d[Name(k)])
+	}
+	b.WriteString(">>")
+}
+
+// writeName writes the name to b, escaping irregular characters as '#xx'.
+func writeName(b *bytes.Buffer, n Name) {
+	b.WriteByte('/')
+	for i := 0; i < len(n); i++ {
+		c := n[i]
+		if c == '#' || c < '!' || c > '~' || !isRegular(c) {
+			fmt.Fprintf(b, "#%02x", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+}
+
+// writeString writes the string to b as a literal string.
+func writeString(b *bytes.Buffer, s String) {
+	b.WriteByte('(')
+	for _, c := range s {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c > 0x7e:
+			fmt.Fprintf(b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+}
+
+// Update is an incremental update of a PDF file, i.e., new and replaced
+// objects, and a new cross-reference section and trailer, which are
+// appended to the original file, leaving its content unchanged.
+type Update struct {
+	// Trailer is the trailer dictionary of the update, initially a copy
+	// of the most recent trailer of the file.
+	Trailer Dict
+
+	f       *File
+	objects map[int]Object
+	next    int
+}
+
+// trailerKeys are the keys of a trailer dictionary, which are retained
+// from a cross-reference stream dictionary.
+var trailerKeys = []Name{"Root", "Info", "ID", "Encrypt"}
+
+// NewUpdate starts an incremental update of the file.
+func (f *File) NewUpdate() *Update {
+	u := &Update{
+		Trailer: make(Dict),
+		f:       f,
+		objects: make(map[int]Object),
+		next:    f.Size(),
+	}
+	for _, k := range trailerKeys {
+		if v, found := f.Trailer[k]; found {
+			u.Trailer[k] = v
+		}
+	}
+	return u
+}
+
+// Add adds a new object to the file, and returns a reference to it.
+func (u *Update) Add(o Object) Ref {
+	num := u.next
+	u.next++
+	u.objects[num] = o
+	return Ref{Num: num}
+}
+
+// Set replaces the referenced object.
+func (u *Update) Set(r Ref, o Object) {
+	u.objects[r.Num] = o
+}
+
+// Bytes returns the updated file, i.e., the original file followed by the
+// update. The cross-reference section is written as a stream, if the most
+// recent section of the original file is a stream, and as a table otherwise.
+func (u *Update) Bytes() []byte {
+	var b bytes.Buffer
+	b.Write(u.f.Data)
+	if len(u.f.Data) > 0 && u.f.Data[len(u.f.Data)-1] != '\n' {
+		b.WriteByte('\n')
+	}
+
+	nums := make([]int, 0, len(u.objects)+1)
+	for num := range u.objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	offsets := make(map[int]int, len(nums)+1)
+	gens := make(map[int]int, len(nums)+1)
+	for _, num := range nums {
+		if e, found := u.f.xref[num]; found && e.Type == 1 {
+			gens[num] = e.Gen
+		}
+		offsets[num] = b.Len()
+		fmt.Fprintf(&b, "%d %d obj\n", num, gens[num])
+		writeObject(&b, u.objects[num])
+		b.WriteString("\nendobj\n")
+	}
+
+	trailer := make(Dict, len(u.Trailer)+2)
+	for k, v := range u.Trailer {
+		trailer[k] = v
+	}
+	trailer["Prev"] = u.f.StartXRef
+
+	start := b.Len()
+	if u.f.XRefStream {
+		// the cross-reference stream has an entry of its own
+		num := u.next
+		nums = append(nums, num)
+		offsets[num] = start
+		trailer["Size"] = int64(num + 1)
+		u.writeXRefStream(&b, num, nums, offsets, gens, trailer)
+	} else {
+		trailer["Size"] = int64(u.next)
+		u.writeXRefTable(&b, nums, offsets, gens, trailer)
+	}
+	fmt.Fprintf(&b, "\nstartxref\n%d\n%%%%EOF\n", start)
+	return b.Bytes()
+}
+
+// subsections groups sorted object numbers into runs of consecutive
+// numbers, i.e., cross-reference subsections.
+func subsections(nums []int) [][]int {
+	var subs [][]int
+	for i, num := range nums {
+		if i == 0 || num != nums[i-1]+1 {
+			subs = append(subs, nil)
+		}
+		subs[len(subs)-1] = append(subs[len(subs)-1], num)
+	}
+	return subs
+}
+
+// writeXRefTable writes a cross-reference table and the trailer.
+func (u *Update) writeXRefTable(
+	b *bytes.Buffer,
+	nums []int,
+	offsets, gens map[int]int,
+	trailer Dict,
+) {
+	b.WriteString("xref\n")
+	for _, sub := range subsections(nums) {
+		fmt.Fprintf(b, "%d %d\n", sub[0], len(sub))
+		for _, num := range sub {
+			fmt.Fprintf(b, "%010d %05d n \n", offsets[num], gens[num])
+		}
+	}
+	b.WriteString("trailer\n")
+	writeDict(b, trailer)
+}
+
+// writeXRefStream writes a cross-reference stream object, holding
+// the trailer, with the object number num.
+func (u *Update) writeXRefStream(
+	b *bytes.Buffer,
+	num int,
+	nums []int,
+	offsets, gens map[int]int,
+	trailer Dict,
+) {
+	// offset field width, in bytes
+	w := 1
+	for offsets[num] >= 1<<(8*w) {
+		w++
+	}
+	var data []byte
+	var index Array
+	for _, sub := range subsections(nums) {
+		index = append(index, int64(sub[0]), int64(len(sub)))
+		for _, n := range sub {
+			data = append(data, 1)
+			for i := w - 1; i >= 0; i-- {
+				data = append(data, byte(offsets[n]>>(8*i)))
+			}
+			data = append(data, byte(gens[n]>>8), byte(gens[n]))
+		}
+	}
+	d := trailer
+	d["Type"] = Name("XRef")
+	d["W"] = Array{int64(1), int64(w), int64(2)}
+	d["Index"] = index
+	fmt.Fprintf(b, "%d 0 obj\n", num)
+	writeObject(b, &Stream{Dict: d, Data: data})
+	b.WriteString("\nendobj")
+}
@@ -0,0 +1,244 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// RefreshInterval is the interval at which bars are redrawn.
+	RefreshInterval = 200 * time.Millisecond
+
+	barWidth  = 20
+	nameWidth = 32
+)
+
+// IsTerminal reports whether f is a terminal (character device).
+func IsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Bar tracks the progress of a single transfer.
+// A nil Bar is valid, and tracks nothing.
+type Bar struct {
+	name  string
+	total int64 // total size, negative if unknown
+	base  int64 // bytes transferred before the bar was added
+	start time.Time
+	cur   int64 // bytes transferred, accessed atomically
+}
+
+// Add records n transferred bytes.
+func (b *Bar) Add(n int) {
+	if b != nil {
+		atomic.AddInt64(&b.cur, int64(n))
+	}
+}
+
+// Reader returns a reader which records bytes read from r to the bar.
+func (b *Bar) Reader(r io.Reader) io.Reader {
+	if b == nil {
+		return r
+	}
+	return &reader{r: r, b: b}
+}
+
+// reader records bytes read to a bar.
+type reader struct {
+	r io.Reader
+	b *Bar
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.b.Add(n)
+	return n, err
+}
+
+// Pool draws the progress bars of concurrent transfers, followed by an
+// aggregate bar, and redraws them periodically.
+// Since the bars are redrawn in place, all other output to the same writer
+// should go through the Pool, e.g., by setting it as the log output.
+// A nil Pool is valid, and draws nothing.
+type Pool struct {
+	mu    sync.Mutex
+	w     io.Writer
+	bars  []*Bar // active bars
+	done  []*Bar // finished bars, counted in the aggregate
+	lines int    // number of lines last drawn
+	start time.Time
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// New creates a new Pool which draws to w.
+func New(w io.Writer) *Pool {
+	return &Pool{w: w}
+}
+
+// Start starts periodically redrawing the bars.
+func (p *Pool) Start() {
+	if p == nil {
+		return
+	}
+	p.start = time.Now()
+	p.stop = make(chan struct{})
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		t := time.NewTicker(RefreshInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				p.mu.Lock()
+				p.draw()
+				p.mu.Unlock()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops redrawing the bars, and clears them.
+func (p *Pool) Stop() {
+	if p == nil {
+		return
+	}
+	close(p.stop)
+	p.wg.Wait()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+}
+
+// Add adds a new bar for a transfer of total bytes, or of unknown size if
+// total is negative, of which done bytes have already been transferred.
+func (p *Pool) Add(name string, total, done int64) *Bar {
+	if p == nil {
+		return nil
+	}
+	b := &Bar{name: name, total: total, base: done, start: time.Now()}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bars = append(p.bars, b)
+	return b
+}
+
+// Done removes the bar from display, once its transfer has finished,
+// successfully or otherwise.
+func (p *Pool) Done(b *Bar) {
+	if p == nil || b == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.bars {
+		if p.bars[i] == b {
+			p.bars = append(p.bars[:i], p.bars[i+1:]...)
+			p.done = append(p.done, b)
+			break
+		}
+	}
+}
+
+// Write writes buf above the bars.
+func (p *Pool) Write(buf []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	n, err := p.w.Write(buf)
+	p.draw()
+	return n, err
+}
+
+// clear erases the lines last drawn.
+func (p *Pool) clear() {
+	if p.lines == 0 {
+		return
+	}
+	fmt.Fprintf(p.w, "\x1b[%dA\r\x1b[J", p.lines)
+	p.lines = 0
+}
+
+// draw redraws the bars in place.
+func (p *Pool) draw() {
+	if p.start.IsZero() || len(p.bars) == 0 {
+		p.clear()
+		return
+	}
+	var b strings.Builder
+	if p.lines > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", p.lines)
+	}
+	var cur, total, base int64
+	for _, bar := range append(p.bars, p.done...) {
+		c := bar.base + atomic.LoadInt64(&bar.cur)
+		cur += c
+		base += bar.base
+		if total >= 0 && bar.total >= 0 {
+			total += bar.total
+		} else {
+			total = -1
+		}
+	}
+	for _, bar := range p.bars {
+		c := bar.base + atomic.LoadInt64(&bar.cur)
+		line(&b, bar.name, c, bar.total, c-bar.base, time.Since(bar.start))
+	}
+	line(&b, fmt.Sprintf("total (%d/%d)", len(p.done), len(p.done)+len(p.bars)),
+		cur, total, cur-base, time.Since(p.start))
+	p.lines = len(p.bars) + 1
+	io.WriteString(p.w, b.String())
+}
+
+// line writes a single progress bar line, i.e., the name, the bar,
+// the transferred and total size, the transfer rate and the ETA.
+func line(b *strings.Builder, name string, cur, total, moved int64, elapsed time.Duration) {
+	if r := []rune(name); len(r) > nameWidth {
+		name = string(r[:nameWidth-1]) + "…"
+	}
+	var rate float64
+	if s := elapsed.Seconds(); s > 0 {
+		rate = float64(moved) / s
+	}
+	fmt.Fprintf(b, "\r\x1b[K%-*s ", nameWidth, name)
+	if total > 0 {
+		fill := int(barWidth * cur / total)
+		if fill > barWidth {
+			fill = barWidth
+		}
+		fmt.Fprintf(b, "[%s%s] %s / %s",
+			strings.Repeat("=", fill), strings.Repeat(" ", barWidth-fill),
+			FormatBytes(cur), FormatBytes(total))
+	} else {
+		fmt.Fprintf(b, "[%s] %s", strings.Repeat("?", barWidth), FormatBytes(cur))
+	}
+	fmt.Fprintf(b, "  %s/s", FormatBytes(int64(rate)))
+	if total > 0 && rate > 0 && cur < total {
+		eta := time.Duration(float64(total-cur) / rate * float64(time.Second))
+		fmt.Fprintf(b, "  ETA %s", eta.Round(time.Second))
+	}
+	b.WriteString("\n")
+}
+
+// FormatBytes formats n bytes using binary (IEC) units, e.g., '1.5 MiB'.
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,51 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatBytes(t *testing.T) {
+	assert := assert.New(t)
+	var tests = []struct {
+		Input  int64
+		Output string
+	}{
+		{Input: 0, Output: "0 B"},
+		{Input: 1023, Output: "1023 B"},
+		{Input: 1536, Output: "1.5 KiB"},
+		{Input: 5 << 20, Output: "5.0 MiB"},
+		{Input: 3 << 30, Output: "3.0 GiB"},
+	}
+	for _, tt := range tests {
+		assert.Equal(tt.Output, FormatBytes(tt.Input))
+	}
+}
+
+func TestPool(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	p := New(&buf)
+	p.Start()
+	b := p.Add("article", 2048, 1024)
+	b.Add(512)
+	p.Write([]byte("log line\n"))
+	out := buf.String()
+	assert.Contains(out, "log line\n")
+	assert.Contains(out, "1.5 KiB / 2.0 KiB")
+	assert.Contains(out, "total (0/1)")
+
+	p.Done(b)
+	p.Stop()
+	assert.True(strings.HasSuffix(buf.String(), "\x1b[J"))
+
+	// nil pools and bars do nothing
+	var np *Pool
+	np.Start()
+	assert.Nil(np.Add("article", 1, 0))
+	np.Done(nil)
+	np.Stop()
+}
@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// defaultPorts are the default ports of URL schemes.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Proxy rewrites URLs so that they are requested through an institutional
+// (library) proxy, e.g., EZproxy or OpenAthens.
+// Two rewriting styles are supported: prefix-style, in which the escaped
+// URL is appended to a login URL, and hostname-style, in which the URL
+// host name is rewritten as a subdomain of the proxy host.
+// If both are set, Prefix is used.
+type Proxy struct {
+	// Prefix is prepended to the (escaped) URL, e.g.,
+	// 'https://ezproxy.example.edu/login?url=' or
+	// 'https://go.openathens.net/redirector/example.edu?url='.
+	Prefix string
+	// Host is the proxy host name, e.g., 'ezproxy.example.edu', in which
+	// case 'www.publisher.com' is rewritten as
+	// 'www-publisher-com.ezproxy.example.edu'.
+	Host string
+}
+
+// IsSet reports whether a proxy is configured.
+func (p Proxy) IsSet() bool {
+	return len(p.Prefix) != 0 || len(p.Host) != 0
+}
+
+// IsProxied reports whether the URL already points to the proxy.
+func (p Proxy) IsProxied(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	if len(p.Prefix) != 0 {
+		if pu, err := url.Parse(p.Prefix); err == nil &&
+			host == strings.ToLower(pu.Hostname()) {
+			return true
+		}
+	}
+	if len(p.Host) != 0 {
+		h := strings.ToLower(p.Host)
+		return host == h || strings.HasSuffix(host, "."+h)
+	}
+	return false
+}
+
+// Rewrite returns the URL rewritten so that it is requested through the
+// proxy. The URL is returned unchanged if no proxy is configured, or if
+// it already points to the proxy. Hostname-style rewriting fails for URLs
+// with a non-default port, which cannot be expressed in the host name.
+func (p Proxy) Rewrite(u *url.URL) (*url.URL, error) {
+	if !p.IsSet() || p.IsProxied(u) {
+		return u, nil
+	}
+	if len(p.Prefix) != 0 {
+		return url.Parse(p.Prefix + url.QueryEscape(u.String()))
+	}
+	// the proxy serves the host on its own port
+	if port := u.Port(); len(port) != 0 && defaultPorts[u.Scheme] != port {
+		return nil, fmt.Errorf("proxy: cannot rewrite %v, non-default port", u)
+	}
+	// EZproxy escapes dashes by doubling them, and replaces dots by dashes
+	host := strings.ReplaceAll(strings.ToLower(u.Hostname()), "-", "--")
+	host = strings.ReplaceAll(host, ".", "-")
+
+	r := *u
+	r.Scheme = "https"
+	r.Host = host + "." + p.Host
+	return &r, nil
+}
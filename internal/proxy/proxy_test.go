@@ -0,0 +1,126 @@
+package proxy
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewrite(t *testing.T) {
+	prefix := Proxy{Prefix: "https://ezproxy.example.edu/login?url="}
+	host := Proxy{Host: "ezproxy.example.edu"}
+	var tests = []struct {
+		Name   string
+		Proxy  Proxy
+		URL    string
+		Output string
+		Error  bool
+	}{
+		{
+			Name:   "unset",
+			URL:    "https://www.publisher.com/article",
+			Output: "https://www.publisher.com/article",
+		},
+		{
+			Name:   "prefix",
+			Proxy:  prefix,
+			URL:    "https://www.publisher.com/article?id=1&v=2",
+			Output: "https://ezproxy.example.edu/login?url=https%3A%2F%2Fwww.publisher.com%2Farticle%3Fid%3D1%26v%3D2",
+		},
+		{
+			Name:   "prefix-port",
+			Proxy:  prefix,
+			URL:    "http://www.publisher.com:8080/article",
+			Output: "https://ezproxy.example.edu/login?url=http%3A%2F%2Fwww.publisher.com%3A8080%2Farticle",
+		},
+		{
+			Name:   "prefix-proxied",
+			Proxy:  prefix,
+			URL:    "https://ezproxy.example.edu/login?url=https%3A%2F%2Fwww.publisher.com%2Farticle",
+			Output: "https://ezproxy.example.edu/login?url=https%3A%2F%2Fwww.publisher.com%2Farticle",
+		},
+		{
+			Name:   "host",
+			Proxy:  host,
+			URL:    "http://www.Publisher.com/content/article.pdf?download=true",
+			Output: "https://www-publisher-com.ezproxy.example.edu/content/article.pdf?download=true",
+		},
+		{
+			Name:   "host-dashes",
+			Proxy:  host,
+			URL:    "https://pubs-online.my-publisher.org/article",
+			Output: "https://pubs--online-my--publisher-org.ezproxy.example.edu/article",
+		},
+		{
+			Name:   "host-default-port",
+			Proxy:  host,
+			URL:    "https://www.publisher.com:443/article",
+			Output: "https://www-publisher-com.ezproxy.example.edu/article",
+		},
+		{
+			Name:  "host-port",
+			Proxy: host,
+			URL:   "https://www.publisher.com:8443/article",
+			Error: true,
+		},
+		{
+			Name:   "host-proxied",
+			Proxy:  host,
+			URL:    "https://www-publisher-com.ezproxy.example.edu/article",
+			Output: "https://www-publisher-com.ezproxy.example.edu/article",
+		},
+		{
+			Name:   "host-proxy",
+			Proxy:  host,
+			URL:    "https://EZproxy.example.edu:443/login",
+			Output: "https://EZproxy.example.edu:443/login",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			u, err := url.Parse(tt.URL)
+			if !assert.Nil(t, err) {
+				return
+			}
+			r, err := tt.Proxy.Rewrite(u)
+			if tt.Error {
+				assert.NotNil(t, err)
+				return
+			}
+			if assert.Nil(t, err) {
+				assert.Equal(t, tt.Output, r.String())
+				// rewritten URLs are not rewritten again
+				if tt.Proxy.IsSet() {
+					assert.True(t, tt.Proxy.IsProxied(r))
+					rr, err := tt.Proxy.Rewrite(r)
+					assert.Nil(t, err)
+					assert.Equal(t, tt.Output, rr.String())
+				}
+			}
+		})
+	}
+}
+
+func TestIsProxied(t *testing.T) {
+	var tests = []struct {
+		Proxy Proxy
+		URL   string
+		Want  bool
+	}{
+		{Proxy: Proxy{}, URL: "https://ezproxy.example.edu/login", Want: false},
+		{Proxy: Proxy{Prefix: "https://ezproxy.example.edu/login?url="}, URL: "https://ezproxy.example.edu/login", Want: true},
+		{Proxy: Proxy{Prefix: "https://ezproxy.example.edu/login?url="}, URL: "https://www.publisher.com/", Want: false},
+		{Proxy: Proxy{Host: "ezproxy.example.edu"}, URL: "https://www-publisher-com.ezproxy.example.edu:443/", Want: true},
+		{Proxy: Proxy{Host: "ezproxy.example.edu"}, URL: "https://notezproxy.example.edu/", Want: false},
+		{Proxy: Proxy{Host: "ezproxy.example.edu"}, URL: "https://www.publisher.com/", Want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.URL, func(t *testing.T) {
+			u, err := url.Parse(tt.URL)
+			if assert.Nil(t, err) {
+				assert.Equal(t, tt.Want, tt.Proxy.IsProxied(u))
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package pubmed
+
+import "encoding/xml"
+
+// Some™ models used by NCBI's PMC ID converter, E-utilities and PMC OA
+// web service APIs.
+//
+// For more information about the particular fields see:
+//	https://www.ncbi.nlm.nih.gov/pmc/tools/id-converter-api/
+//	https://www.ncbi.nlm.nih.gov/books/NBK25499/#chapter4.ESummary
+//	https://www.ncbi.nlm.nih.gov/pmc/tools/oa-service/
+
+// NCBI's API URLs. These are variables so that they can be pointed at
+// a local (HTTP) stand-in, e.g., when testing.
+var (
+	// IDConvURL is the URL of the PMC ID converter API.
+	IDConvURL = "https://www.ncbi.nlm.nih.gov/pmc/utils/idconv/v1.0/"
+	// ESummaryURL is the URL of the E-utilities ESummary endpoint.
+	ESummaryURL = "https://eutils.ncbi.nlm.nih.gov/entrez/eutils/esummary.fcgi"
+	// OAURL is the URL of the PMC OA web service.
+	OAURL = "https://www.ncbi.nlm.nih.gov/pmc/utils/oa/oa.fcgi"
+)
+
+// NCBI's API query parameters.
+const (
+	QueryKeyIDs      string = "ids"
+	QueryKeyID       string = "id"
+	QueryKeyFormat   string = "format"
+	QueryValFormat   string = "json"
+	QueryKeyDB       string = "db"
+	QueryValDBPubMed string = "pubmed"
+	QueryValDBPMC    string = "pmc"
+	QueryKeyRetMode  string = "retmode"
+	QueryValRetMode  string = "json"
+	QueryKeyTool     string = "tool"
+	QueryValTool     string = "fetchref"
+	QueryKeyEmail    string = "email"
+	OAFormatPDF      string = "pdf"
+	ArticleIDTypeDOI string = "doi"
+)
+
+// ArticleID holds an identifier of a PubMed record.
+type ArticleID struct {
+	// IDType is the identifier type, e.g., 'pubmed', 'doi' or 'pmc'.
+	IDType string `json:"idtype"`
+	Value  string `json:"value"`
+}
+
+// Author holds the name of an author of a PubMed record.
+type Author struct {
+	// Name is the author name in 'Family I' form, e.g., 'Smith JA'.
+	Name     string `json:"name"`
+	AuthType string `json:"authtype"`
+}
+
+// IDConvMessage is the response from the PMC ID converter API.
+type IDConvMessage struct {
+	Status  string         `json:"status"`
+	Records []IDConvRecord `json:"records"`
+}
+
+// IDConvRecord holds the identifiers of a single article.
+type IDConvRecord struct {
+	PMCID  string `json:"pmcid"`
+	PMID   string `json:"pmid"`
+	DOI    string `json:"doi"`
+	Status string `json:"status"`
+	ErrMsg string `json:"errmsg"`
+}
+
+// OAError holds an error returned by the PMC OA web service, e.g., if
+// the article is not in the open access subset.
+type OAError struct {
+	Code    string `xml:"code,attr"`
+	Message string `xml:",chardata"`
+}
+
+// OALink holds a link to an open access full-text package or PDF.
+type OALink struct {
+	// Format is the file format, one of 'tgz' or 'pdf'.
+	Format  string `xml:"format,attr"`
+	Updated string `xml:"updated,attr"`
+	Href    string `xml:"href,attr"`
+}
+
+// OAMessage is the response from the PMC OA web service.
+type OAMessage struct {
+	XMLName xml.Name   `xml:"OA"`
+	Error   *OAError   `xml:"error"`
+	Records []OARecord `xml:"records>record"`
+}
+
+// OARecord holds data about an article in the PMC open access subset.
+type OARecord struct {
+	ID        string   `xml:"id,attr"`
+	Citation  string   `xml:"citation,attr"`
+	License   string   `xml:"license,attr"`
+	Retracted string   `xml:"retracted,attr"`
+	Links     []OALink `xml:"link"`
+}
+
+// Summary holds the document summary of a PubMed (or PMC) record.
+type Summary struct {
+	UID             string      `json:"uid"`
+	PubDate         string      `json:"pubdate"`
+	EPubDate        string      `json:"epubdate"`
+	Source          string      `json:"source"`
+	Authors         []Author    `json:"authors"`
+	Title           string      `json:"title"`
+	Volume          string      `json:"volume"`
+	Issue           string      `json:"issue"`
+	Pages           string      `json:"pages"`
+	ISSN            string      `json:"issn"`
+	ESSN            string      `json:"essn"`
+	PubType         []string    `json:"pubtype"`
+	ArticleIDs      []ArticleID `json:"articleids"`
+	FullJournalName string      `json:"fulljournalname"`
+	ELocationID     string      `json:"elocationid"`
+}
@@ -0,0 +1,93 @@
+package pubmed
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Milover/fetchref/internal/crossref"
+)
+
+var (
+	// pmid matches PubMed identifiers, e.g., '23193287'.
+	pmid = regexp.MustCompile(`^[1-9][0-9]{0,8}$`)
+
+	// pmcid matches PubMed Central identifiers, e.g., 'PMC3531190'.
+	pmcid = regexp.MustCompile(`^PMC[0-9]{1,9}$`)
+
+	// pubYear matches the year of a publication date, e.g., '2013 Jan'.
+	pubYear = regexp.MustCompile(`^[0-9]{4}`)
+)
+
+// trimPrefix strips a case-insensitive prefix from s, if present.
+func trimPrefix(s, prefix string) string {
+	if len(s) > len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+		return s[len(prefix):]
+	}
+	return s
+}
+
+// CleanPMID strips the (case-insensitive) 'PMID:' prefix from a PMID,
+// if present.
+func CleanPMID(n string) string {
+	return strings.TrimSpace(trimPrefix(n, "pmid:"))
+}
+
+// CleanPMCID strips the (case-insensitive) 'PMCID:' prefix from a PMCID,
+// if present, and returns it with an upper-case 'PMC' prefix.
+func CleanPMCID(n string) string {
+	return strings.ToUpper(strings.TrimSpace(trimPrefix(n, "pmcid:")))
+}
+
+// IsPMID reports whether n is a syntactically valid PMID, with an
+// optional 'PMID:' prefix.
+func IsPMID(n string) bool {
+	return pmid.MatchString(CleanPMID(n))
+}
+
+// IsPMCID reports whether n is a syntactically valid PMCID, with an
+// optional 'PMCID:' prefix.
+func IsPMCID(n string) bool {
+	return pmcid.MatchString(CleanPMCID(n))
+}
+
+// Work returns the summary as a Crossref work, with doi, if not empty,
+// as its DOI.
+func (s Summary) Work(doi string) crossref.Work {
+	w := crossref.Work{
+		DOI:    doi,
+		Title:  []string{strings.TrimSuffix(s.Title, ".")},
+		Volume: s.Volume,
+		Issue:  s.Issue,
+		Page:   s.Pages,
+		Type:   "journal-article",
+		Source: "pubmed",
+	}
+	if len(s.FullJournalName) != 0 {
+		w.ContainerTitle = []string{s.FullJournalName}
+	}
+	if len(s.Source) != 0 {
+		w.ShortContainerTitle = []string{s.Source}
+	}
+	for _, id := range []string{s.ISSN, s.ESSN} {
+		if len(id) != 0 {
+			w.ISSN = append(w.ISSN, id)
+		}
+	}
+	// names are in the 'Family Initials' form, e.g., 'Smith JA'
+	for _, a := range s.Authors {
+		f := strings.Fields(a.Name)
+		if len(f) < 2 {
+			w.Author = append(w.Author, crossref.Author{Name: a.Name})
+			continue
+		}
+		w.Author = append(w.Author, crossref.Author{
+			Family: strings.Join(f[:len(f)-1], " "),
+			Given:  f[len(f)-1],
+		})
+	}
+	if y, err := strconv.Atoi(pubYear.FindString(s.PubDate)); err == nil {
+		w.Issued.DateParts = [][]int{{y}}
+	}
+	return w
+}
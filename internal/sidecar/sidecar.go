@@ -0,0 +1,90 @@
+package sidecar
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/Milover/fetchref/internal/outfile"
+)
+
+// Extension is the sidecar file name extension. It differs from that of
+// CSL-JSON citation files ('.json'), which may share the document's name.
+const Extension = ".fetchref.json"
+
+// Sidecar holds the metadata of a downloaded document, and is written
+// to a file next to it, i.e., '<name>.fetchref.json' for a document
+// '<name>.pdf'.
+// The SHA-256 hash of the document allows matching the sidecar to it,
+// even if either of the files is renamed.
+type Sidecar struct {
+	Handle     string         `json:"handle"`
+	HandleType string         `json:"handle_type"`
+	DOI        string         `json:"doi,omitempty"`
+	Title      string         `json:"title,omitempty"`
+	File       string         `json:"file"` // base name of the document
+	Size       int64          `json:"size"`
+	SHA256     string         `json:"sha256"`
+	Source     string         `json:"source,omitempty"` // download source name
+	SourceURL  string         `json:"source_url,omitempty"`
+	License    string         `json:"license,omitempty"`
+	Retrieved  time.Time      `json:"retrieved"`
+	Version    string         `json:"fetchref_version"`
+	Meta       *crossref.Work `json:"metadata,omitempty"`
+}
+
+// Path returns the name of the sidecar file of the document file name.
+func Path(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + Extension
+}
+
+// HashFile returns the hex SHA-256 hash and the size of a file.
+func HashFile(name string) (string, int64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// Marshal returns the sidecar file contents.
+func Marshal(s Sidecar) ([]byte, error) {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// Write writes the sidecar to the named file atomically.
+func Write(name string, s Sidecar) error {
+	b, err := Marshal(s)
+	if err != nil {
+		return err
+	}
+	return outfile.WriteFile(name, b, 0666)
+}
+
+// Read reads a sidecar from the named file.
+func Read(name string) (Sidecar, error) {
+	var s Sidecar
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(b, &s)
+	return s, err
+}
@@ -0,0 +1,47 @@
+package sidecar
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Milover/fetchref/internal/crossref"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPath(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(filepath.Join("2016", "abbott.fetchref.json"), Path(filepath.Join("2016", "abbott.pdf")))
+	assert.Equal("abbott.v2.fetchref.json", Path("abbott.v2.djvu"))
+}
+
+func TestWriteRead(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	doc := filepath.Join(dir, "article.pdf")
+	assert.Nil(os.WriteFile(doc, []byte("%PDF-1.4\n"), 0666))
+
+	sum, size, err := HashFile(doc)
+	assert.Nil(err)
+	assert.Equal(int64(9), size)
+
+	s := Sidecar{
+		Handle:     "10.1000/182",
+		HandleType: "doi",
+		DOI:        "10.1000/182",
+		File:       filepath.Base(doc),
+		Size:       size,
+		SHA256:     sum,
+		Retrieved:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Meta:       &crossref.Work{Title: []string{"DOI Handbook"}},
+	}
+	assert.Nil(Write(Path(doc), s))
+	r, err := Read(Path(doc))
+	assert.Nil(err)
+	if assert.NotNil(r.Meta) {
+		assert.Equal(s.Meta.Title, r.Meta.Title)
+	}
+	s.Meta, r.Meta = nil, nil
+	assert.Equal(s, r)
+}
@@ -0,0 +1,71 @@
+package unpaywall
+
+// Some™ models used by Unpaywall's REST API.
+//
+// For more information about the particular fields see:
+//	https://unpaywall.org/data-format
+//	https://unpaywall.org/products/api
+
+const (
+	// URL is the URL of Unpaywall's REST API.
+	URL string = "api.unpaywall.org"
+	// API is the path to the (current version of) Unpaywall's REST API.
+	API string = "v2"
+)
+
+// Unpaywall's REST API query parameters.
+const (
+	QueryKeyEmail string = "email"
+)
+
+// Unpaywall host types.
+const (
+	HostTypePublisher  string = "publisher"
+	HostTypeRepository string = "repository"
+)
+
+// Location holds information about an open access copy of a work.
+type Location struct {
+	// URL is the URL of the PDF if there is one, otherwise of the
+	// landing page.
+	URL               string `json:"url"`
+	URLForPDF         string `json:"url_for_pdf"`
+	URLForLandingPage string `json:"url_for_landing_page"`
+	// Evidence describes how the open access copy was found.
+	Evidence string `json:"evidence"`
+	// License is the license of the copy, e.g., 'cc-by' or 'implied-oa'.
+	License string `json:"license"`
+	// Version is one of 'publishedVersion', 'acceptedVersion' or
+	// 'submittedVersion'.
+	Version string `json:"version"`
+	// HostType is one of 'publisher' or 'repository'.
+	HostType              string `json:"host_type"`
+	IsBest                bool   `json:"is_best"`
+	PMHID                 string `json:"pmh_id"`
+	EndpointID            string `json:"endpoint_id"`
+	RepositoryInstitution string `json:"repository_institution"`
+	Updated               string `json:"updated"`
+}
+
+// Work holds information about the open access status of a work, and
+// the locations of its open access copies.
+type Work struct {
+	DOI           string `json:"doi"`
+	DOIURL        string `json:"doi_url"`
+	Title         string `json:"title"`
+	Genre         string `json:"genre"`
+	IsParatext    bool   `json:"is_paratext"`
+	Year          int    `json:"year"`
+	JournalName   string `json:"journal_name"`
+	JournalISSNs  string `json:"journal_issns"`
+	JournalIsOA   bool   `json:"journal_is_oa"`
+	Publisher     string `json:"publisher"`
+	PublishedDate string `json:"published_date"`
+	IsOA          bool   `json:"is_oa"`
+	// OAStatus is one of 'gold', 'hybrid', 'bronze', 'green' or 'closed'.
+	OAStatus       string     `json:"oa_status"`
+	HasRepoCopy    bool       `json:"has_repository_copy"`
+	BestOALocation *Location  `json:"best_oa_location"`
+	OALocations    []Location `json:"oa_locations"`
+	Updated        string     `json:"updated"`
+}
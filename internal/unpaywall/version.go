@@ -0,0 +1,67 @@
+package unpaywall
+
+import "fmt"
+
+var (
+	ErrBadVersion = fmt.Errorf(
+		"unknown version, available versions are: %q",
+		names)
+)
+
+var (
+	// names are the user-friendly Version names.
+	names = [...]string{
+		"published",
+		"accepted",
+		"submitted",
+	}
+	// values are the Version names used by Unpaywall's REST API.
+	values = [...]string{
+		"publishedVersion",
+		"acceptedVersion",
+		"submittedVersion",
+	}
+)
+
+// Version represents the version of an open access copy of a work,
+// ordered from the most to the least final version.
+type Version int
+
+// Open access copy versions reported by Unpaywall.
+const (
+	Published Version = iota
+	Accepted
+	Submitted
+)
+
+// ParseVersion returns the Version corresponding to the version name used
+// by Unpaywall's REST API, and whether the name is known.
+func ParseVersion(value string) (Version, bool) {
+	for i, v := range values {
+		if value == v {
+			return Version(i), true
+		}
+	}
+	return Submitted, false
+}
+
+// Set sets the value of the version based on the provided version name.
+func (v *Version) Set(name string) error {
+	for i, n := range names {
+		if name == n {
+			*v = Version(i)
+			return nil
+		}
+	}
+	return ErrBadVersion
+}
+
+// String returns the Version (name) as a user-friendly string.
+func (v Version) String() string {
+	return names[v]
+}
+
+// Type returns the type used by Version.Set.
+func (v Version) Type() string {
+	return "string"
+}